@@ -1,17 +1,41 @@
 package clitest
 
 import (
+	"context"
+	"log"
+	"os"
+
 	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/credentials"
 	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/logging"
 	"github.com/abhinav/git-pr/repo"
+	"github.com/abhinav/git-pr/settings"
 )
 
 // ConfigBuilder may be used to build a cli.Config from static values.
 type ConfigBuilder struct {
+	// Defaults to context.Background() if unset.
+	Context context.Context
+
 	Git        gateway.Git
 	Repo       *repo.Repo
 	GitHub     gateway.GitHub
 	GitHubUser string
+
+	// Defaults to a cli.TextReporter that writes to stderr and stdout if
+	// unset.
+	Reporter cli.Reporter
+
+	// Defaults to a nil logger, which discards everything, if unset.
+	Logger *logging.Logger
+
+	// Defaults to settings.New(), which has default values for everything,
+	// if unset.
+	Settings *settings.Settings
+
+	// Defaults to an empty credentials.NewMemory() store if unset.
+	Credentials credentials.Store
 }
 
 // Build the cli.Config. This function may also be used as a
@@ -24,6 +48,13 @@ func (c *ConfigBuilder) Build() (cli.Config, error) {
 
 type config struct{ data ConfigBuilder }
 
+func (c *config) Context() context.Context {
+	if c.data.Context == nil {
+		return context.Background()
+	}
+	return c.data.Context
+}
+
 func (c *config) Git() gateway.Git {
 	return c.data.Git
 }
@@ -39,3 +70,28 @@ func (c *config) CurrentGitHubUser() string {
 func (c *config) GitHub() gateway.GitHub {
 	return c.data.GitHub
 }
+
+func (c *config) Logger() *logging.Logger {
+	return c.data.Logger
+}
+
+func (c *config) Settings() *settings.Settings {
+	if c.data.Settings == nil {
+		return settings.New()
+	}
+	return c.data.Settings
+}
+
+func (c *config) Credentials() credentials.Store {
+	if c.data.Credentials == nil {
+		c.data.Credentials = credentials.NewMemory()
+	}
+	return c.data.Credentials
+}
+
+func (c *config) Reporter() cli.Reporter {
+	if c.data.Reporter == nil {
+		return cli.NewTextReporter(log.New(os.Stderr, "", 0), os.Stdout)
+	}
+	return c.data.Reporter
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jessevdk/go-flags"
+)
+
+// Shells for which completion scripts are available.
+var _completionScripts = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(_bashCompletion)),
+	"zsh":  template.Must(template.New("zsh").Parse(_zshCompletion)),
+	"fish": template.Must(template.New("fish").Parse(_fishCompletion)),
+}
+
+// The scripts call the program back with GO_FLAGS_COMPLETION set to the
+// name of the shell. The program prints the completions in the format
+// expected by that shell. They work for both "git-pr" and "git pr".
+
+const _bashCompletion = `_{{.Func}}() {
+	local args=("${COMP_WORDS[@]:0:$((COMP_CWORD + 1))}")
+	if [[ "${args[0]}" == git ]]; then
+		args=("${args[@]:2}")
+	else
+		args=("${args[@]:1}")
+	fi
+
+	local IFS=$'\n'
+	COMPREPLY=($(GO_FLAGS_COMPLETION=bash {{.Program}} "${args[@]}" 2>/dev/null))
+}
+complete -o default -F _{{.Func}} {{.Program}}
+`
+
+const _zshCompletion = `#compdef {{.Program}}
+
+_{{.Program}}() {
+	local -a completions
+	completions=("${(@f)$(GO_FLAGS_COMPLETION=zsh {{.Program}} "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	_describe -t values '{{.Program}}' completions
+}
+
+compdef _{{.Program}} {{.Program}}
+`
+
+const _fishCompletion = `function __{{.Func}}_complete
+	set -l args (commandline -opc)
+	if test "$args[1]" = git
+		set args $args[3..-1]
+	else
+		set args $args[2..-1]
+	end
+	env GO_FLAGS_COMPLETION=fish {{.Program}} $args (commandline -ct) 2>/dev/null
+end
+
+complete -c {{.Program}} -f -a '(__{{.Func}}_complete)'
+complete -c git -n '__fish_seen_subcommand_from {{.Command}}' -f -a '(__{{.Func}}_complete)'
+`
+
+type completionCmd struct {
+	Args struct {
+		Shell string `positional-arg-name:"SHELL" required:"yes" description:"Shell for which the script is printed: bash, zsh, or fish."`
+	} `positional-args:"yes"`
+
+	getConfig ConfigBuilder
+	program   string
+}
+
+func newCompletionCommand(cbuild ConfigBuilder) flags.Commander {
+	return &completionCmd{getConfig: cbuild, program: filepath.Base(os.Args[0])}
+}
+
+func (c *completionCmd) Execute([]string) error {
+	tmpl, ok := _completionScripts[c.Args.Shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q: use bash, zsh, or fish", c.Args.Shell)
+	}
+
+	cfg, err := c.getConfig()
+	if err != nil {
+		return err
+	}
+
+	var script bytes.Buffer
+	err = tmpl.Execute(&script, struct {
+		Program string // git-pr
+		Command string // pr
+		Func    string // git_pr
+	}{
+		Program: c.program,
+		Command: strings.TrimPrefix(c.program, "git-"),
+		Func:    strings.Replace(c.program, "-", "_", -1),
+	})
+	if err != nil {
+		return err
+	}
+
+	// The JSON reporter discards the script so it's reported as the result
+	// instead.
+	cfg.Reporter().Result(completionResult{Shell: c.Args.Shell, Script: script.String()})
+	_, err = script.WriteTo(cfg.Reporter().Writer())
+	return err
+}
+
+type completionResult struct {
+	Shell  string `json:"shell"`
+	Script string `json:"script"`
+}
+
+// printCompletions prints completions in the format expected by the given
+// shell.
+func printCompletions(w io.Writer, shell string, items []flags.Completion) {
+	for _, item := range items {
+		switch {
+		case shell == "zsh" && item.Description != "":
+			fmt.Fprintf(w, "%v:%v\n", strings.Replace(item.Item, ":", `\:`, -1), item.Description)
+		case shell == "zsh":
+			fmt.Fprintln(w, strings.Replace(item.Item, ":", `\:`, -1))
+		case shell == "fish" && item.Description != "":
+			fmt.Fprintf(w, "%v\t%v\n", item.Item, item.Description)
+		default:
+			fmt.Fprintln(w, item.Item)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionCmd(t *testing.T) {
+	tests := []struct {
+		Shell string
+		Want  []string
+	}{
+		{
+			Shell: "bash",
+			Want: []string{
+				"_git_pr() {",
+				"GO_FLAGS_COMPLETION=bash git-pr",
+				"complete -o default -F _git_pr git-pr",
+			},
+		},
+		{
+			Shell: "zsh",
+			Want: []string{
+				"#compdef git-pr",
+				"_git-pr() {",
+				"GO_FLAGS_COMPLETION=zsh git-pr",
+				"compdef _git-pr git-pr",
+			},
+		},
+		{
+			Shell: "fish",
+			Want: []string{
+				"function __git_pr_complete",
+				"env GO_FLAGS_COMPLETION=fish git-pr",
+				"complete -c git-pr -f -a '(__git_pr_complete)'",
+				"complete -c git -n '__fish_seen_subcommand_from pr' -f -a '(__git_pr_complete)'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Shell, func(t *testing.T) {
+			var out bytes.Buffer
+			g := globalConfig{reporter: NewTextReporter(log.New(ioutil.Discard, "", 0), &out)}
+
+			cmd := completionCmd{getConfig: g.BuildGlobal, program: "git-pr"}
+			cmd.Args.Shell = tt.Shell
+			require.NoError(t, cmd.Execute(nil))
+
+			for _, want := range tt.Want {
+				assert.Contains(t, out.String(), want)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		rep := NewJSONReporter(&out)
+		g := globalConfig{reporter: rep}
+
+		cmd := completionCmd{getConfig: g.BuildGlobal, program: "git-pr"}
+		cmd.Args.Shell = "bash"
+		require.NoError(t, cmd.Execute(nil))
+		require.NoError(t, rep.Finish(nil))
+
+		var res struct {
+			OK     bool `json:"ok"`
+			Result struct {
+				Shell  string `json:"shell"`
+				Script string `json:"script"`
+			} `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &res))
+		assert.True(t, res.OK)
+		assert.Equal(t, "bash", res.Result.Shell)
+		assert.Contains(t, res.Result.Script, "complete -o default -F _git_pr git-pr")
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		cmd := completionCmd{program: "git-pr"}
+		cmd.Args.Shell = "tcsh"
+		err := cmd.Execute(nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unsupported shell "tcsh"`)
+	})
+}
+
+func TestPrintCompletions(t *testing.T) {
+	items := []flags.Completion{
+		{Item: "feature1", Description: "#1: Add feature"},
+		{Item: "user:feature2"},
+	}
+
+	tests := []struct {
+		Shell string
+		Want  string
+	}{
+		{
+			Shell: "bash",
+			Want:  "feature1\nuser:feature2\n",
+		},
+		{
+			Shell: "zsh",
+			Want:  "feature1:#1: Add feature\nuser\\:feature2\n",
+		},
+		{
+			Shell: "fish",
+			Want:  "feature1\t#1: Add feature\nuser:feature2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Shell, func(t *testing.T) {
+			var out bytes.Buffer
+			printCompletions(&out, tt.Shell, items)
+			assert.Equal(t, tt.Want, out.String())
+		})
+	}
+}
@@ -4,15 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"net/http"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/abhinav/git-pr/credentials"
 	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/gateway/recording"
 	"github.com/abhinav/git-pr/git"
 	"github.com/abhinav/git-pr/github"
+	"github.com/abhinav/git-pr/logging"
+	"github.com/abhinav/git-pr/prcache"
 	"github.com/abhinav/git-pr/repo"
+	"github.com/abhinav/git-pr/settings"
 
 	gh "github.com/google/go-github/github"
 	"github.com/zalando/go-keyring"
+	"go.uber.org/multierr"
 	"golang.org/x/oauth2"
 )
 
@@ -20,36 +30,117 @@ const _keyringServiceName = "git-fu"
 
 // Config is the common configuration for all programs in this package.
 type Config interface {
+	// Context for the current command. This is cancelled if the user
+	// interrupts the program.
+	Context() context.Context
+
 	Git() gateway.Git
 	Repo() *repo.Repo
 	GitHub() gateway.GitHub
 	CurrentGitHubUser() string
+
+	// Reporter through which commands report progress and results.
+	Reporter() Reporter
+
+	// Logger for diagnostic output. Debug messages are written only if
+	// verbose output was requested.
+	Logger() *logging.Logger
+
+	// Settings from configuration files and git-config.
+	Settings() *settings.Settings
+
+	// Credentials holds stored GitHub tokens.
+	Credentials() credentials.Store
 }
 
 // ConfigBuilder builds a configuration lazily.
 type ConfigBuilder func() (Config, error)
 
 type globalConfig struct {
-	RepoName    string `short:"r" long:"repo" value-name:"OWNER/REPO" description:"Name of the GitHub repository in the format 'owner/repo'. Defaults to the repository for the current directory."`
-	GitHubUser  string `short:"u" long:"user" value-name:"USERNAME" env:"GITHUB_USER" description:"GitHub username."`
+	RepoName    string `short:"r" long:"repo" value-name:"[HOST/]OWNER/REPO" description:"Name of the GitHub repository in the format 'owner/repo', or 'host/owner/repo' for GitHub Enterprise. Defaults to the repository for the current directory."`
+	GitHubUser  string `short:"u" long:"user" value-name:"USERNAME" env:"GITHUB_USER" description:"GitHub username. Defaults to the owner of the GitHub token."`
 	GitHubToken string `short:"t" long:"token" env:"GITHUB_TOKEN" value-name:"TOKEN" description:"GitHub token used to make requests."`
+	Verbose     bool   `short:"v" long:"verbose" env:"GIT_PR_VERBOSE" description:"Trace git commands and GitHub requests to stderr."`
+	Record      string `long:"record" value-name:"FILE" description:"Record all calls made to git and GitHub to FILE. Attach this file to bug reports."`
+	Output      string `long:"output" value-name:"FORMAT" default:"text" choice:"text" choice:"json" description:"Format of the output. With json, a single JSON object describing the result of the command is printed."`
+
+	AppID             int64  `long:"app-id" env:"GIT_PR_APP_ID" value-name:"ID" description:"Authenticate as an installation of the GitHub App with this ID instead of using a token."`
+	AppInstallationID int64  `long:"app-installation-id" env:"GIT_PR_APP_INSTALLATION_ID" value-name:"ID" description:"ID of the installation of the GitHub App."`
+	AppPrivateKey     string `long:"app-private-key" env:"GIT_PR_APP_PRIVATE_KEY" value-name:"FILE" description:"Path to the private key of the GitHub App."`
 
-	token  string
-	repo   *repo.Repo
-	git    gateway.Git
-	github gateway.GitHub
+	ctx         context.Context
+	token       string
+	repo        *repo.Repo
+	git         gateway.Git
+	gitCLI      *git.Gateway
+	github      gateway.GitHub
+	reporter    Reporter
+	logger      *logging.Logger
+	recorder    *recording.Recorder
+	prCache     *prcache.Cache
+	settings    *settings.Settings
+	credentials credentials.Store
 }
 
 var _ Config = (*globalConfig)(nil)
 
-func (g *globalConfig) buildGit() (gateway.Git, error) {
-	if g.git != nil {
-		return g.git, nil
+// buildGit sets up the git gateway selected by the gitBackend setting. gw is
+// used if the repository can't be read in-process.
+func (g *globalConfig) buildGit(gw *git.Gateway) {
+	g.git = gw
+	g.gitCLI = gw
+	if g.settings.String("gitBackend") == "in-process" {
+		inproc, err := git.NewInProcessGateway(gw.RootDir(), g.Logger().Named("git"))
+		if err != nil {
+			g.Logger().Debug("falling back to the git CLI", logging.Error(err))
+		} else {
+			g.git = inproc
+		}
+	}
+
+	if rec := g.buildRecorder(); rec != nil {
+		g.git = rec.Git(g.git)
+	}
+}
+
+func (g *globalConfig) buildRecorder() *recording.Recorder {
+	if g.recorder == nil && g.Record != "" {
+		g.recorder = recording.NewRecorder()
+	}
+	return g.recorder
+}
+
+// saveRecording saves the calls recorded with --record, if any.
+func (g *globalConfig) saveRecording() (err error) {
+	if g.recorder == nil {
+		return nil
+	}
+
+	f, err := os.Create(g.Record)
+	if err != nil {
+		return fmt.Errorf("failed to save recording: %v", err)
+	}
+	defer func() {
+		err = multierr.Append(err, f.Close())
+	}()
+
+	return g.recorder.Save(f)
+}
+
+// savePullRequestCache writes the pull requests seen by the command to the
+// cache used by shell completion.
+func (g *globalConfig) savePullRequestCache() {
+	if g.prCache != nil {
+		g.prCache.Save()
 	}
+}
 
-	var err error
-	g.git, err = git.NewGateway("")
-	return g.git, err
+// host returns the GitHub host of the repository.
+func (g *globalConfig) host() string {
+	if g.repo == nil || g.repo.Host == "" {
+		return github.DefaultHost
+	}
+	return g.repo.Host
 }
 
 func (g *globalConfig) Token() (string, error) {
@@ -61,22 +152,56 @@ func (g *globalConfig) Token() (string, error) {
 		return g.GitHubToken, nil
 	}
 
-	var err error
-	g.token, err = keyring.Get(_keyringServiceName, g.GitHubUser)
+	// Sources that fail are skipped so that, for example, an encrypted file
+	// that can't be unlocked without a terminal doesn't hide a token
+	// available elsewhere. The errors are reported only if no source had a
+	// token.
+	var errs error
+	host := g.host()
+	token, err := g.Credentials().Get(host)
 	switch err {
 	case nil:
-		return g.token, nil
-	case keyring.ErrNotFound:
-		return g.askForToken()
+		g.token = token
+		return token, nil
+	case credentials.ErrNotFound:
+		// Look elsewhere.
 	default:
-		return "", fmt.Errorf("failed to retrieve GitHub token from keyring: %v", err)
+		err = fmt.Errorf("failed to retrieve GitHub token from %v: %v", g.Credentials(), err)
+		g.Logger().Info("skipping credential store", logging.Error(err))
+		errs = multierr.Append(errs, err)
+	}
+
+	for _, src := range credentialSources(os.Getenv) {
+		token, err := src.Token(host)
+		if err != nil {
+			err = fmt.Errorf("failed to retrieve GitHub token from %v: %v", src.Name, err)
+			g.Logger().Info("skipping credential source", logging.Error(err))
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		if token != "" {
+			g.Logger().Debug("using GitHub token", logging.String("source", src.Name))
+			g.token = token
+			return token, nil
+		}
+	}
+
+	if errs != nil {
+		return "", errs
 	}
+	return g.askForToken(host)
 }
 
-func (g *globalConfig) askForToken() (string, error) {
-	fmt.Println("GitHub token not found. " +
-		"Please generate one at https://github.com/settings/tokens")
-	fmt.Printf("GitHub token for %v: ", g.GitHubUser)
+func (g *globalConfig) askForToken(host string) (string, error) {
+	// Prompts go to stderr so that they don't mix with the output of the
+	// command.
+	fmt.Fprintf(os.Stderr, "GitHub token not found. "+
+		"Please generate one at https://%v/settings/tokens\n", host)
+	if g.GitHubUser != "" {
+		fmt.Fprintf(os.Stderr, "GitHub token for %v: ", g.GitHubUser)
+	} else {
+		fmt.Fprint(os.Stderr, "GitHub token: ")
+	}
 	if _, err := fmt.Scanln(&g.token); err != nil {
 		return "", err
 	}
@@ -86,48 +211,238 @@ func (g *globalConfig) askForToken() (string, error) {
 		return "", fmt.Errorf("GitHub token cannot be blank")
 	}
 
-	// TODO: verify token validity before storing
+	info, err := credentials.Check(g.Context(), g.newGitHubClient(g.token).Users)
+	if err != nil {
+		g.token = ""
+		return "", err
+	}
+	if g.GitHubUser == "" {
+		g.GitHubUser = info.Login
+	}
 
-	if err := keyring.Set(_keyringServiceName, g.GitHubUser, g.token); err != nil {
-		return "", fmt.Errorf("failed to store GitHub token in keyring: %v", err)
+	if err := g.Credentials().Set(host, g.token); err != nil {
+		return "", fmt.Errorf("failed to store GitHub token: %v", err)
 	}
 
 	return g.token, nil
 }
 
-// globalConfig.Build is a ConfigBuilder
-func (g *globalConfig) Build() (_ Config, err error) {
-	if g.GitHubUser == "" {
-		return nil, errors.New(
-			"please provide a GitHub username using the -u/--user flag " +
-				"or by setting the GITHUB_USER environment variable")
+// globalConfig.BuildGlobal is a ConfigBuilder for commands that don't need a
+// repository. Neither the git nor the GitHub gateway is available to them.
+func (g *globalConfig) BuildGlobal() (Config, error) {
+	return g, nil
+}
+
+// globalConfig.BuildLocal is a ConfigBuilder for commands that need only
+// the local repository. The GitHub gateway is not available to them.
+func (g *globalConfig) BuildLocal() (_ Config, err error) {
+	if g.git != nil {
+		return g, nil
+	}
+
+	gw, err := git.NewGateway("", g.Logger().Named("git"))
+	if err != nil {
+		return nil, err
 	}
 
-	git, err := g.buildGit()
+	// Settings decide which git gateway is used so they're always read with
+	// the git CLI.
+	g.settings, err = settings.Load(settings.LoadConfig{
+		Git: gw,
+		Log: g.Logger().Named("settings"),
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	g.buildGit(gw)
+	return g, nil
+}
+
+// globalConfig.Build is a ConfigBuilder
+func (g *globalConfig) Build() (_ Config, err error) {
+	if _, err := g.BuildLocal(); err != nil {
+		return nil, err
+	}
+	git := g.git
+
 	if g.RepoName != "" {
 		g.repo, err = repo.Parse(g.RepoName)
 	} else {
-		g.repo, err = repo.Guess(git)
+		g.repo, err = repo.Guess(git, g.settings.String("remote"))
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	token, err := g.Token()
+	var client *gh.Client
+	if g.AppID != 0 {
+		client, err = g.newAppClient()
+	} else {
+		client, err = g.newUserClient()
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	httpClient := oauth2.NewClient(context.Background(), tokenSource)
-	g.github = github.NewGatewayForRepository(gh.NewClient(httpClient), g.repo)
+	g.github = github.NewGatewayForRepository(client, g.repo)
+	if dir, err := g.gitCLI.CommonDir(); err != nil {
+		g.Logger().Debug("not caching pull requests", logging.Error(err))
+	} else {
+		g.prCache = prcache.New(prcache.Path(dir), g.Logger().Named("prcache"))
+		g.github = g.prCache.Wrap(g.github)
+	}
+	if rec := g.buildRecorder(); rec != nil {
+		g.github = rec.GitHub(g.github)
+	}
 	return g, nil
 }
 
+// newUserClient builds a GitHub client which authenticates with the user's
+// token.
+func (g *globalConfig) newUserClient() (*gh.Client, error) {
+	token, err := g.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	client := g.newGitHubClient(token)
+	if g.GitHubUser == "" {
+		info, err := credentials.Check(g.Context(), client.Users)
+		if err != nil {
+			return nil, err
+		}
+		g.GitHubUser = info.Login
+	}
+	return client, nil
+}
+
+// newAppClient builds a GitHub client which authenticates as an
+// installation of a GitHub App.
+func (g *globalConfig) newAppClient() (*gh.Client, error) {
+	if g.AppInstallationID == 0 || g.AppPrivateKey == "" {
+		return nil, errors.New(
+			"--app-id requires --app-installation-id and --app-private-key")
+	}
+
+	key, err := credentials.ReadPrivateKey(g.AppPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	log := g.Logger().Named("github")
+	app := credentials.AppConfig{
+		AppID:          g.AppID,
+		InstallationID: g.AppInstallationID,
+		PrivateKey:     key,
+		BaseURL:        github.APIURL(g.host()),
+		HTTPClient: &http.Client{
+			Transport: github.NewLoggingTransport(g.githubTransport(), log),
+		},
+	}
+
+	if g.GitHubUser == "" {
+		g.GitHubUser, err = credentials.AppLogin(app)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tokenSource := credentials.NewAppTokenSource(app)
+
+	// Pushes and fetches over HTTPS are made as the app too. Its
+	// installation tokens are the only credentials a bot may have.
+	g.gitCLI.SetHTTPSToken(g.host(), func() (string, error) {
+		token, err := tokenSource.Token()
+		if err != nil {
+			return "", err
+		}
+		return token.AccessToken, nil
+	})
+
+	return github.NewClientFromTokenSource(g.githubContext(), g.host(), tokenSource, log), nil
+}
+
+func (g *globalConfig) newGitHubClient(token string) *gh.Client {
+	return github.NewClient(g.githubContext(), g.host(), token, g.Logger().Named("github"))
+}
+
+// githubContext returns the context with which GitHub clients are built.
+// oauth2 makes requests through the http.Client attached to it.
+func (g *globalConfig) githubContext() context.Context {
+	return context.WithValue(g.Context(), oauth2.HTTPClient, &http.Client{
+		Transport: g.githubTransport(),
+	})
+}
+
+// githubTransport returns the transport through which requests to GitHub are
+// made. Requests time out after the number of seconds in the requestTimeout
+// setting. nil means that http.DefaultTransport is used.
+func (g *globalConfig) githubTransport() http.RoundTripper {
+	if g.settings == nil {
+		return nil
+	}
+
+	timeout := g.settings.Int("requestTimeout")
+	if timeout <= 0 {
+		return nil
+	}
+	return github.NewTimeoutTransport(nil, time.Duration(timeout)*time.Second)
+}
+
+// Credentials returns where GitHub tokens are stored. The OS keyring is
+// used if it's available, and an encrypted file otherwise.
+func (g *globalConfig) Credentials() credentials.Store {
+	if g.credentials == nil {
+		g.credentials = credentials.NewFallback(
+			credentials.NewKeyring(_keyringServiceName),
+			credentials.NewFile(credentials.DefaultFile(), askPassphrase),
+		)
+		migrateLegacyToken(g.credentials, g.GitHubUser, g.Logger())
+	}
+	return g.credentials
+}
+
+// migrateLegacyToken moves the github.com token that older versions stored
+// in the keyring under the name of the user into store.
+func migrateLegacyToken(store credentials.Store, user string, log *logging.Logger) {
+	if user == "" {
+		return
+	}
+
+	token, err := keyring.Get(_keyringServiceName, user)
+	if err != nil {
+		// Nothing to migrate or the keyring isn't available.
+		return
+	}
+
+	switch _, err := store.Get(github.DefaultHost); err {
+	case nil:
+		// A token stored with auth login takes precedence.
+	case credentials.ErrNotFound:
+		if err := store.Set(github.DefaultHost, token); err != nil {
+			log.Info("failed to migrate GitHub token", logging.Error(err))
+			return
+		}
+	default:
+		log.Info("failed to migrate GitHub token", logging.Error(err))
+		return
+	}
+
+	if err := keyring.Delete(_keyringServiceName, user); err != nil {
+		log.Info("failed to delete old GitHub token from keyring", logging.Error(err))
+		return
+	}
+	log.Debug("migrated GitHub token", logging.String("user", user), logging.String("store", store.String()))
+}
+
+func (g *globalConfig) Context() context.Context {
+	if g.ctx == nil {
+		return context.Background()
+	}
+	return g.ctx
+}
+
 func (g *globalConfig) Repo() *repo.Repo {
 	return g.repo
 }
@@ -143,3 +458,33 @@ func (g *globalConfig) GitHub() gateway.GitHub {
 func (g *globalConfig) Git() gateway.Git {
 	return g.git
 }
+
+func (g *globalConfig) Settings() *settings.Settings {
+	return g.settings
+}
+
+func (g *globalConfig) Logger() *logging.Logger {
+	if g.logger != nil {
+		return g.logger
+	}
+
+	level := logging.Info
+	if g.Verbose {
+		level = logging.Debug
+	}
+	g.logger = logging.New(os.Stderr, level)
+	return g.logger
+}
+
+func (g *globalConfig) Reporter() Reporter {
+	if g.reporter != nil {
+		return g.reporter
+	}
+
+	if g.Output == "json" {
+		g.reporter = NewJSONReporter(os.Stdout)
+	} else {
+		g.reporter = NewTextReporter(log.New(os.Stderr, "", 0), os.Stdout)
+	}
+	return g.reporter
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/abhinav/git-pr/credentials"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func TestMigrateLegacyToken(t *testing.T) {
+	tests := []struct {
+		Desc   string
+		User   string
+		Legacy string
+		Stored string
+
+		Want       string
+		WantLegacy bool
+	}{
+		{Desc: "no user", Legacy: "old", WantLegacy: true},
+		{Desc: "nothing to migrate", User: "abhinav", Stored: "new", Want: "new"},
+		{Desc: "migrate", User: "abhinav", Legacy: "old", Want: "old"},
+		{Desc: "already stored", User: "abhinav", Legacy: "old", Stored: "new", Want: "new"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			keyring.MockInit()
+			if tt.Legacy != "" {
+				require.NoError(t, keyring.Set(_keyringServiceName, "abhinav", tt.Legacy))
+			}
+
+			store := credentials.NewMemory()
+			if tt.Stored != "" {
+				require.NoError(t, store.Set("github.com", tt.Stored))
+			}
+
+			migrateLegacyToken(store, tt.User, nil)
+
+			got, err := store.Get("github.com")
+			if tt.Want == "" {
+				assert.Equal(t, credentials.ErrNotFound, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.Want, got)
+			}
+
+			_, err = keyring.Get(_keyringServiceName, "abhinav")
+			if tt.WantLegacy {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, keyring.ErrNotFound, err)
+			}
+		})
+	}
+}
+
+type brokenStore struct{ credentials.Store }
+
+func (brokenStore) Get(string) (string, error) {
+	return "", errors.New("could not read passphrase: not a terminal")
+}
+
+func (brokenStore) String() string { return "broken store" }
+
+func TestTokenSkipsBrokenStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-pr")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Keep git credential helpers and the gh configuration of the user out
+	// of the test.
+	setenv := func(key, value string) (restore func()) {
+		old, ok := os.LookupEnv(key)
+		require.NoError(t, os.Setenv(key, value))
+		return func() {
+			if ok {
+				os.Setenv(key, old)
+			} else {
+				os.Unsetenv(key)
+			}
+		}
+	}
+	defer setenv("HOME", dir)()
+	defer setenv("XDG_CONFIG_HOME", dir)()
+	defer setenv("GIT_CONFIG_NOSYSTEM", "1")()
+	defer setenv("GH_CONFIG_DIR", dir)()
+
+	t.Run("found elsewhere", func(t *testing.T) {
+		defer setenv("GH_TOKEN", "token")()
+
+		g := globalConfig{credentials: brokenStore{}}
+		token, err := g.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "token", token)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		defer setenv("GH_TOKEN", "")()
+
+		g := globalConfig{credentials: brokenStore{}}
+		_, err := g.Token()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to retrieve GitHub token from broken store")
+		assert.Contains(t, err.Error(), "not a terminal")
+	})
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+	"gopkg.in/yaml.v2"
+)
+
+// credentialSource is a place other than the keyring from which a GitHub
+// token may be read.
+type credentialSource struct {
+	Name string
+
+	// Token returns the token for the given host or an empty string if this
+	// source doesn't have one.
+	Token func(host string) (string, error)
+}
+
+// credentialSources returns the sources consulted, in order, if a token
+// wasn't provided and isn't in the keyring.
+func credentialSources(getenv func(string) string) []credentialSource {
+	return []credentialSource{
+		{Name: "git credential", Token: gitCredentialToken},
+		{Name: "gh", Token: func(host string) (string, error) {
+			return ghHostsToken(ghConfigDir(getenv), host)
+		}},
+		{Name: "GH_TOKEN", Token: func(string) (string, error) {
+			return getenv("GH_TOKEN"), nil
+		}},
+	}
+}
+
+// gitCredentialToken asks git's credential helpers for the password stored
+// for host. git is not allowed to prompt for it.
+func gitCredentialToken(host string) (string, error) {
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%v\n\n", host))
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		// git credential fill fails if no helper had a password and it
+		// wasn't allowed to prompt for one.
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to run git credential fill: %v", err)
+	}
+
+	return parseCredential(stdout.String())["password"], nil
+}
+
+// parseCredential parses the key=value lines printed by git credential.
+func parseCredential(out string) map[string]string {
+	attrs := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+		if len(parts) == 2 {
+			attrs[parts[0]] = parts[1]
+		}
+	}
+	return attrs
+}
+
+// ghConfigDir returns the directory in which the GitHub CLI stores its
+// configuration.
+func ghConfigDir(getenv func(string) string) string {
+	if dir := getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	if dir := getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh")
+	}
+	return filepath.Join(getenv("HOME"), ".config", "gh")
+}
+
+// ghHostsToken reads the token for host from the hosts.yml of the GitHub
+// CLI. Newer versions of the GitHub CLI keep the token in the keyring
+// instead, in which case no token is found here.
+func ghHostsToken(dir, host string) (string, error) {
+	path := filepath.Join(dir, "hosts.yml")
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read %v: %v", path, err)
+	}
+
+	var hosts map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	}
+	if err := yaml.Unmarshal(contents, &hosts); err != nil {
+		return "", fmt.Errorf("failed to parse %v: %v", path, err)
+	}
+	return hosts[host].OAuthToken, nil
+}
+
+// askPassphrase returns the passphrase for the encrypted credentials file
+// from GIT_PR_PASSPHRASE, asking for it if that isn't set.
+func askPassphrase() (string, error) {
+	if p := os.Getenv("GIT_PR_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return "", errors.New("the OS keyring is not available: " +
+			"set GIT_PR_PASSPHRASE to store GitHub tokens in an encrypted file instead")
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase for the credentials file: ")
+	p, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %v", err)
+	}
+	return string(p), nil
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCredential(t *testing.T) {
+	got := parseCredential("protocol=https\nhost=github.com\nusername=abhinav\npassword=a=b\n")
+	assert.Equal(t, map[string]string{
+		"protocol": "https",
+		"host":     "github.com",
+		"username": "abhinav",
+		"password": "a=b",
+	}, got)
+}
+
+func TestGHConfigDir(t *testing.T) {
+	tests := []struct {
+		Desc string
+		Env  map[string]string
+		Want string
+	}{
+		{
+			Desc: "GH_CONFIG_DIR",
+			Env:  map[string]string{"GH_CONFIG_DIR": "/gh", "XDG_CONFIG_HOME": "/xdg", "HOME": "/home"},
+			Want: "/gh",
+		},
+		{
+			Desc: "XDG_CONFIG_HOME",
+			Env:  map[string]string{"XDG_CONFIG_HOME": "/xdg", "HOME": "/home"},
+			Want: "/xdg/gh",
+		},
+		{
+			Desc: "HOME",
+			Env:  map[string]string{"HOME": "/home"},
+			Want: "/home/.config/gh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			getenv := func(k string) string { return tt.Env[k] }
+			assert.Equal(t, tt.Want, ghConfigDir(getenv))
+		})
+	}
+}
+
+func TestGHHostsToken(t *testing.T) {
+	tests := []struct {
+		Desc      string
+		Hosts     string // contents of hosts.yml; no file if empty
+		Want      string
+		WantError string
+	}{
+		{Desc: "no file"},
+		{
+			Desc: "token",
+			Hosts: "github.com:\n" +
+				"    oauth_token: gho_1234\n" +
+				"    user: abhinav\n" +
+				"    git_protocol: https\n" +
+				"example.com:\n" +
+				"    oauth_token: gho_5678\n",
+			Want: "gho_1234",
+		},
+		{
+			Desc:  "token in keyring",
+			Hosts: "github.com:\n    user: abhinav\n",
+		},
+		{
+			Desc:      "bad file",
+			Hosts:     "github.com: [",
+			WantError: "failed to parse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "gh")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			if tt.Hosts != "" {
+				require.NoError(t, ioutil.WriteFile(
+					filepath.Join(dir, "hosts.yml"), []byte(tt.Hosts), 0600))
+			}
+
+			got, err := ghHostsToken(dir, "github.com")
+			if tt.WantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.WantError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.Want, got)
+		})
+	}
+}
@@ -1,9 +1,13 @@
 package cli
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
 
 	"github.com/jessevdk/go-flags"
+	"go.uber.org/multierr"
 )
 
 type mainConfig struct {
@@ -20,17 +24,70 @@ func Main(opts ...Option) {
 		o.apply(&cfg)
 	}
 
-	var gcfg globalConfig
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cancelOnInterrupt(cancel)
+
+	gcfg := globalConfig{ctx: ctx}
 	parser := flags.NewParser(&gcfg, flags.HelpFlag|flags.PassDoubleDash)
-	for _, cmd := range cfg.Commands {
+	parser.CompletionHandler = func(items []flags.Completion) {
+		printCompletions(os.Stdout, os.Getenv("GO_FLAGS_COMPLETION"), items)
+		os.Exit(0)
+	}
+
+	commands := append(cfg.Commands, &Command{
+		Name:      "completion",
+		ShortDesc: "Prints a shell completion script.",
+		Build:     newCompletionCommand,
+		Global:    true,
+	})
+	for _, cmd := range commands {
+		build := gcfg.Build
+		switch {
+		case cmd.Global:
+			build = gcfg.BuildGlobal
+		case cmd.Local:
+			build = gcfg.BuildLocal
+		}
+
 		_, err := parser.AddCommand(
-			cmd.Name, cmd.ShortDesc, "", cmd.Build(gcfg.Build))
+			cmd.Name, cmd.ShortDesc, "", cmd.Build(build))
 		if err != nil {
 			log.Fatalf("Could not register command %q: %v", cmd.Name, err)
 		}
 	}
 
-	if _, err := parser.Parse(); err != nil {
+	_, err := parser.Parse()
+	gcfg.savePullRequestCache()
+	err = multierr.Append(err, gcfg.saveRecording())
+	if ferr, ok := err.(*flags.Error); ok && ferr.Type == flags.ErrHelp {
+		log.Fatalf("%+v", err)
+	}
+
+	if r, ok := gcfg.Reporter().(*JSONReporter); ok {
+		if err := r.Finish(err); err != nil {
+			log.Fatalf("%+v", err)
+		}
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err != nil {
 		log.Fatalf("%+v", err)
 	}
 }
+
+// cancelOnInterrupt calls cancel when the program receives an interrupt.
+// Ongoing operations are given a chance to clean up after themselves. A
+// second interrupt kills the program.
+func cancelOnInterrupt(cancel context.CancelFunc) {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	<-interrupts
+	signal.Stop(interrupts)
+
+	log.Println("Interrupted. Stopping. Press Ctrl-C again to exit immediately.")
+	cancel()
+}
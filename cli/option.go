@@ -19,6 +19,15 @@ type Command struct {
 	Name      string
 	ShortDesc string
 	Build     func(ConfigBuilder) flags.Commander
+
+	// Local commands need only the local repository. They may be run
+	// without GitHub credentials and the GitHub gateway is not available to
+	// them.
+	Local bool
+
+	// Global commands don't need a repository. Only the Context, Reporter,
+	// Logger, and Credentials are available to them.
+	Global bool
 }
 
 func (cmd *Command) apply(c *mainConfig) {
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+
+	"github.com/jessevdk/go-flags"
+	"go.uber.org/multierr"
+)
+
+// Reporter reports the progress and outcome of a command to the user.
+type Reporter interface {
+	// Printf and Println report human-readable progress information.
+	Printf(format string, args ...interface{})
+	Println(args ...interface{})
+
+	// Writer returns the destination for the primary output of commands
+	// that print something for humans, like a graph of pull requests.
+	Writer() io.Writer
+
+	// Result records the outcome of the command. It must be safe to encode
+	// as JSON. Only the last result recorded by a command is reported.
+	Result(v interface{})
+}
+
+// TextReporter is a Reporter for humans. Results are not reported because
+// the progress information already describes them.
+type TextReporter struct {
+	logger *log.Logger
+	w      io.Writer
+}
+
+var _ Reporter = (*TextReporter)(nil)
+
+// NewTextReporter builds a TextReporter which writes progress information
+// to the given logger and the output of commands to w.
+func NewTextReporter(logger *log.Logger, w io.Writer) *TextReporter {
+	return &TextReporter{logger: logger, w: w}
+}
+
+// Printf reports progress information to the logger.
+func (r *TextReporter) Printf(format string, args ...interface{}) {
+	r.logger.Printf(format, args...)
+}
+
+// Println reports progress information to the logger.
+func (r *TextReporter) Println(args ...interface{}) {
+	r.logger.Println(args...)
+}
+
+// Writer returns the writer to which the output of commands is written.
+func (r *TextReporter) Writer() io.Writer {
+	return r.w
+}
+
+// Result is a no-op for TextReporters.
+func (r *TextReporter) Result(interface{}) {}
+
+// JSONReporter is a Reporter for scripts. Human-readable text is discarded
+// and a single JSON object is written when the command finishes.
+//
+// On success, the object contains the result of the command.
+//
+// 	{"ok": true, "result": {...}}
+//
+// On failure, it contains the error and its code.
+//
+// 	{"ok": false, "error": {"code": "pull_request_not_found", "message": "..."}}
+type JSONReporter struct {
+	w      io.Writer
+	result interface{}
+}
+
+var _ Reporter = (*JSONReporter)(nil)
+
+// NewJSONReporter builds a JSONReporter which writes to the given writer.
+func NewJSONReporter(w io.Writer) *JSONReporter {
+	return &JSONReporter{w: w}
+}
+
+// Printf is a no-op for JSONReporters.
+func (r *JSONReporter) Printf(string, ...interface{}) {}
+
+// Println is a no-op for JSONReporters.
+func (r *JSONReporter) Println(...interface{}) {}
+
+// Writer discards everything written to it.
+func (r *JSONReporter) Writer() io.Writer {
+	return ioutil.Discard
+}
+
+// Result records the result of the command.
+func (r *JSONReporter) Result(v interface{}) {
+	r.result = v
+}
+
+type jsonError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonOutput struct {
+	OK     bool        `json:"ok"`
+	Result interface{} `json:"result,omitempty"`
+	Error  *jsonError  `json:"error,omitempty"`
+}
+
+// Finish writes the result of the command, or the given error if it
+// failed, to the underlying writer.
+func (r *JSONReporter) Finish(err error) error {
+	out := jsonOutput{OK: err == nil, Result: r.result}
+	if err != nil {
+		out.Result = nil
+		out.Error = &jsonError{Code: ErrorCode(err), Message: err.Error()}
+	}
+
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("failed to write JSON output: %v", err)
+	}
+	return nil
+}
+
+// CodedError is an error with a machine-readable code. The code is reported
+// alongside the error when the output is in JSON.
+type CodedError interface {
+	error
+
+	Code() string
+}
+
+// WrapErrorf adds context to the given error like fmt.Errorf. The returned
+// error keeps the code of the original error.
+func WrapErrorf(err error, format string, args ...interface{}) error {
+	return &wrappedError{msg: fmt.Sprintf(format, args...), err: err}
+}
+
+type wrappedError struct {
+	msg string
+	err error
+}
+
+func (e *wrappedError) Error() string { return e.msg + ": " + e.err.Error() }
+
+func (e *wrappedError) Code() string { return ErrorCode(e.err) }
+
+// ErrorCode returns the machine-readable code for the given error.
+//
+// If err is a combination of errors, the code of the first error that has
+// one is used. Errors that don't have a code get the code "error".
+func ErrorCode(err error) string {
+	for _, e := range multierr.Errors(err) {
+		switch e := e.(type) {
+		case CodedError:
+			return e.Code()
+		case *flags.Error:
+			return "usage"
+		}
+
+		if e == context.Canceled {
+			return "interrupted"
+		}
+	}
+	return "error"
+}
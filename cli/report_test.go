@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+)
+
+type fakeCodedError struct{}
+
+func (fakeCodedError) Error() string { return "not found" }
+func (fakeCodedError) Code() string  { return "not_found" }
+
+func TestJSONReporter(t *testing.T) {
+	tests := []struct {
+		Desc   string
+		Result interface{}
+		Err    error
+		Want   string
+	}{
+		{
+			Desc:   "success",
+			Result: map[string]string{"branch": "feature1"},
+			Want:   `{"ok": true, "result": {"branch": "feature1"}}`,
+		},
+		{
+			Desc:   "failure",
+			Result: map[string]string{"branch": "feature1"},
+			Err:    errors.New("great sadness"),
+			Want:   `{"ok": false, "error": {"code": "error", "message": "great sadness"}}`,
+		},
+		{
+			Desc: "coded error",
+			Err:  fakeCodedError{},
+			Want: `{"ok": false, "error": {"code": "not_found", "message": "not found"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := NewJSONReporter(&buf)
+			r.Println("human-readable text is not reported")
+			if tt.Result != nil {
+				r.Result(tt.Result)
+			}
+
+			require.NoError(t, r.Finish(tt.Err))
+			assert.JSONEq(t, tt.Want, buf.String())
+		})
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		Desc string
+		Err  error
+		Want string
+	}{
+		{Desc: "plain", Err: errors.New("great sadness"), Want: "error"},
+		{Desc: "coded", Err: fakeCodedError{}, Want: "not_found"},
+		{Desc: "usage", Err: &flags.Error{Type: flags.ErrRequired}, Want: "usage"},
+		{Desc: "interrupted", Err: context.Canceled, Want: "interrupted"},
+		{
+			Desc: "wrapped",
+			Err:  WrapErrorf(fakeCodedError{}, "failed to land %v", "feature1"),
+			Want: "not_found",
+		},
+		{
+			Desc: "wrapped interrupted",
+			Err:  WrapErrorf(context.Canceled, "failed to land %v", "feature1"),
+			Want: "interrupted",
+		},
+		{Desc: "wrapped plain", Err: WrapErrorf(errors.New("great sadness"), "oops"), Want: "error"},
+		{
+			Desc: "combined",
+			Err:  multierr.Append(errors.New("great sadness"), context.Canceled),
+			Want: "interrupted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			assert.Equal(t, tt.Want, ErrorCode(tt.Err))
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/credentials"
+	"github.com/abhinav/git-pr/github"
+
+	"github.com/jessevdk/go-flags"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// checkTokenFunc verifies a token for the given host.
+type checkTokenFunc func(ctx context.Context, cfg cli.Config, host, token string) (*credentials.Info, error)
+
+func checkToken(ctx context.Context, cfg cli.Config, host, token string) (*credentials.Info, error) {
+	client := github.NewClient(ctx, host, token, cfg.Logger().Named("github"))
+	return credentials.Check(ctx, client.Users)
+}
+
+type authCmd struct {
+	Login  authLoginCmd  `command:"login" description:"Stores a GitHub token for a host."`
+	Logout authLogoutCmd `command:"logout" description:"Removes the stored GitHub token for a host."`
+	Status authStatusCmd `command:"status" description:"Shows the stored GitHub tokens and who they belong to."`
+}
+
+func newAuthCommand(cbuild cli.ConfigBuilder) flags.Commander {
+	return &authCmd{
+		Login: authLoginCmd{
+			getConfig:  cbuild,
+			checkToken: checkToken,
+			stdin:      os.Stdin,
+			readToken:  readTokenFromTerminal,
+		},
+		Logout: authLogoutCmd{getConfig: cbuild},
+		Status: authStatusCmd{getConfig: cbuild, checkToken: checkToken},
+	}
+}
+
+func (*authCmd) Execute([]string) error {
+	return errors.New("please specify one of login, logout, or status")
+}
+
+type authLoginCmd struct {
+	Hostname  string `long:"hostname" value-name:"HOST" default:"github.com" description:"Host for which the token is stored."`
+	WithToken bool   `long:"with-token" description:"Read the token from stdin instead of asking for it."`
+
+	getConfig  cli.ConfigBuilder
+	checkToken checkTokenFunc
+	stdin      io.Reader
+	readToken  func(host string) (string, error)
+}
+
+func (l *authLoginCmd) Execute([]string) error {
+	cfg, err := l.getConfig()
+	if err != nil {
+		return err
+	}
+
+	var token string
+	if l.WithToken {
+		token, err = bufio.NewReader(l.stdin).ReadString('\n')
+		if err == io.EOF {
+			err = nil
+		}
+	} else {
+		token, err = l.readToken(l.Hostname)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read token: %v", err)
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return errors.New("GitHub token cannot be blank")
+	}
+
+	info, err := l.checkToken(cfg.Context(), cfg, l.Hostname, token)
+	if err != nil {
+		return err
+	}
+
+	store := cfg.Credentials()
+	if err := store.Set(l.Hostname, token); err != nil {
+		return fmt.Errorf("failed to store GitHub token: %v", err)
+	}
+
+	cfg.Reporter().Printf("Logged in to %v as %v. Token stored in %v.", l.Hostname, info.Login, store)
+	cfg.Reporter().Result(authStatus{
+		Host:   l.Hostname,
+		User:   info.Login,
+		Scopes: info.Scopes,
+		Store:  store.String(),
+	})
+	return nil
+}
+
+func readTokenFromTerminal(host string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return "", errors.New("stdin is not a terminal: use --with-token to read the token from stdin")
+	}
+
+	fmt.Fprintf(os.Stderr, "Generate a token at https://%v/settings/tokens\n", host)
+	fmt.Fprintf(os.Stderr, "GitHub token for %v: ", host)
+	token, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(token), err
+}
+
+type authLogoutCmd struct {
+	Hostname string `long:"hostname" value-name:"HOST" default:"github.com" description:"Host whose token is removed."`
+
+	getConfig cli.ConfigBuilder
+}
+
+func (l *authLogoutCmd) Execute([]string) error {
+	cfg, err := l.getConfig()
+	if err != nil {
+		return err
+	}
+
+	switch err := cfg.Credentials().Delete(l.Hostname); err {
+	case nil:
+	case credentials.ErrNotFound:
+		return fmt.Errorf("not logged in to %v", l.Hostname)
+	default:
+		return fmt.Errorf("failed to remove GitHub token for %v: %v", l.Hostname, err)
+	}
+
+	cfg.Reporter().Printf("Logged out of %v.", l.Hostname)
+	cfg.Reporter().Result(authStatus{Host: l.Hostname})
+	return nil
+}
+
+type authStatusCmd struct {
+	Hostname string `long:"hostname" value-name:"HOST" description:"Show only the token for this host."`
+
+	getConfig  cli.ConfigBuilder
+	checkToken checkTokenFunc
+}
+
+type authStatus struct {
+	Host   string   `json:"host"`
+	User   string   `json:"user,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+	Store  string   `json:"store,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+func (s *authStatusCmd) Execute([]string) error {
+	cfg, err := s.getConfig()
+	if err != nil {
+		return err
+	}
+
+	store := cfg.Credentials()
+	hosts := []string{s.Hostname}
+	if s.Hostname == "" {
+		hosts, err = store.Hosts()
+		if err != nil {
+			return fmt.Errorf("failed to list stored GitHub tokens: %v", err)
+		}
+	}
+
+	if len(hosts) == 0 {
+		cfg.Reporter().Println("Not logged in to any hosts.")
+		cfg.Reporter().Result([]authStatus{})
+		return nil
+	}
+
+	results := make([]authStatus, len(hosts))
+	for i, host := range hosts {
+		token, err := store.Get(host)
+		switch err {
+		case nil:
+		case credentials.ErrNotFound:
+			return fmt.Errorf("not logged in to %v", host)
+		default:
+			return fmt.Errorf("failed to retrieve GitHub token for %v: %v", host, err)
+		}
+
+		result := authStatus{Host: host, Store: store.String()}
+		out := cfg.Reporter().Writer()
+		fmt.Fprintln(out, host)
+		if info, err := s.checkToken(cfg.Context(), cfg, host, token); err != nil {
+			result.Error = err.Error()
+			fmt.Fprintf(out, "  Token is invalid: %v\n", err)
+		} else {
+			result.User = info.Login
+			result.Scopes = info.Scopes
+			fmt.Fprintf(out, "  Logged in as %v\n", info.Login)
+			if info.Scopes != nil {
+				fmt.Fprintf(out, "  Scopes: %v\n", strings.Join(info.Scopes, ", "))
+			}
+		}
+		fmt.Fprintf(out, "  Stored in %v\n", store)
+		results[i] = result
+	}
+
+	cfg.Reporter().Result(results)
+	return nil
+}
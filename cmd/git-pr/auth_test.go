@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/cli/clitest"
+	"github.com/abhinav/git-pr/credentials"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCheckToken accepts tokens of the form "user:scope,scope".
+func fakeCheckToken(_ context.Context, _ cli.Config, host, token string) (*credentials.Info, error) {
+	parts := strings.SplitN(token, ":", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("failed to verify GitHub token: 401 Bad credentials")
+	}
+	return &credentials.Info{Login: parts[0], Scopes: strings.Split(parts[1], ",")}, nil
+}
+
+func TestAuthLoginCmd(t *testing.T) {
+	tests := []struct {
+		Desc      string
+		Hostname  string
+		WithToken bool
+		Stdin     string
+		Prompt    string
+
+		WantOutput string
+		WantError  string
+		WantTokens map[string]string
+	}{
+		{
+			Desc:       "prompt",
+			Hostname:   "github.com",
+			Prompt:     "abhinav:repo",
+			WantOutput: "Logged in to github.com as abhinav. Token stored in memory.\n",
+			WantTokens: map[string]string{"github.com": "abhinav:repo"},
+		},
+		{
+			Desc:       "stdin",
+			Hostname:   "github.example.com",
+			WithToken:  true,
+			Stdin:      "bot:repo\n",
+			WantOutput: "Logged in to github.example.com as bot. Token stored in memory.\n",
+			WantTokens: map[string]string{"github.example.com": "bot:repo"},
+		},
+		{
+			Desc:       "blank",
+			Hostname:   "github.com",
+			WithToken:  true,
+			Stdin:      "\n",
+			WantError:  "GitHub token cannot be blank",
+			WantTokens: map[string]string{},
+		},
+		{
+			Desc:       "invalid",
+			Hostname:   "github.com",
+			Prompt:     "bad",
+			WantError:  "Bad credentials",
+			WantTokens: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			var out bytes.Buffer
+			store := credentials.NewMemory()
+			cb := clitest.ConfigBuilder{
+				Credentials: store,
+				Reporter:    cli.NewTextReporter(log.New(&out, "", 0), ioutil.Discard),
+			}
+
+			cmd := authLoginCmd{
+				Hostname:   tt.Hostname,
+				WithToken:  tt.WithToken,
+				getConfig:  cb.Build,
+				checkToken: fakeCheckToken,
+				stdin:      strings.NewReader(tt.Stdin),
+				readToken: func(host string) (string, error) {
+					assert.Equal(t, tt.Hostname, host)
+					return tt.Prompt, nil
+				},
+			}
+			err := cmd.Execute(nil)
+			if tt.WantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.WantError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.WantOutput, out.String())
+			}
+
+			hosts, err := store.Hosts()
+			require.NoError(t, err)
+			tokens := make(map[string]string)
+			for _, h := range hosts {
+				tokens[h], err = store.Get(h)
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.WantTokens, tokens)
+		})
+	}
+}
+
+func TestAuthLogoutCmd(t *testing.T) {
+	store := credentials.NewMemory()
+	require.NoError(t, store.Set("github.com", "abhinav:repo"))
+
+	var out bytes.Buffer
+	cb := clitest.ConfigBuilder{
+		Credentials: store,
+		Reporter:    cli.NewTextReporter(log.New(&out, "", 0), ioutil.Discard),
+	}
+
+	cmd := authLogoutCmd{Hostname: "github.com", getConfig: cb.Build}
+	require.NoError(t, cmd.Execute(nil))
+	assert.Equal(t, "Logged out of github.com.\n", out.String())
+
+	_, err := store.Get("github.com")
+	assert.Equal(t, credentials.ErrNotFound, err)
+
+	err = cmd.Execute(nil)
+	require.Error(t, err)
+	assert.Equal(t, "not logged in to github.com", err.Error())
+}
+
+func TestAuthStatusCmd(t *testing.T) {
+	tests := []struct {
+		Desc     string
+		Tokens   map[string]string
+		Hostname string
+
+		WantOutput string
+		WantError  string
+	}{
+		{
+			Desc:       "no tokens",
+			WantOutput: "Not logged in to any hosts.\n",
+		},
+		{
+			Desc: "all hosts",
+			Tokens: map[string]string{
+				"github.com":         "abhinav:repo,read:org",
+				"github.example.com": "bad",
+			},
+			WantOutput: "github.com\n" +
+				"  Logged in as abhinav\n" +
+				"  Scopes: repo, read:org\n" +
+				"  Stored in memory\n" +
+				"github.example.com\n" +
+				"  Token is invalid: failed to verify GitHub token: 401 Bad credentials\n" +
+				"  Stored in memory\n",
+		},
+		{
+			Desc: "one host",
+			Tokens: map[string]string{
+				"github.com":         "abhinav:repo",
+				"github.example.com": "bot:repo",
+			},
+			Hostname: "github.example.com",
+			WantOutput: "github.example.com\n" +
+				"  Logged in as bot\n" +
+				"  Scopes: repo\n" +
+				"  Stored in memory\n",
+		},
+		{
+			Desc:      "unknown host",
+			Hostname:  "github.example.com",
+			WantError: "not logged in to github.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			store := credentials.NewMemory()
+			for host, token := range tt.Tokens {
+				require.NoError(t, store.Set(host, token))
+			}
+
+			var out bytes.Buffer
+			cb := clitest.ConfigBuilder{
+				Credentials: store,
+				Reporter:    cli.NewTextReporter(log.New(&out, "", 0), &out),
+			}
+
+			cmd := authStatusCmd{
+				Hostname:   tt.Hostname,
+				getConfig:  cb.Build,
+				checkToken: fakeCheckToken,
+			}
+			err := cmd.Execute(nil)
+			if tt.WantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.WantError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.WantOutput, out.String())
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abhinav/git-pr/git"
+	"github.com/abhinav/git-pr/prcache"
+
+	"github.com/jessevdk/go-flags"
+)
+
+// branchName is the name of a branch. It is completed from local branches
+// and branches of open pull requests.
+type branchName string
+
+var _ flags.Completer = branchName("")
+
+func (branchName) Complete(match string) []flags.Completion {
+	g, err := git.NewGateway("", nil)
+	if err != nil {
+		return nil
+	}
+
+	branches, err := g.ListBranches()
+	if err != nil {
+		return nil
+	}
+
+	// Failing to read the cache shouldn't prevent completion of local
+	// branches.
+	return completeBranches(match, branches, loadCachedPullRequests(g))
+}
+
+// loadCachedPullRequests returns the open pull requests in the cache of the
+// repository, if any.
+func loadCachedPullRequests(g *git.Gateway) []*prcache.PullRequest {
+	dir, err := g.CommonDir()
+	if err != nil {
+		return nil
+	}
+
+	prs, _ := prcache.Load(prcache.Path(dir))
+	return prs
+}
+
+// pullRequestNumber is the number of a pull request. It is completed from
+// open pull requests in the cache.
+type pullRequestNumber int
+
+var _ flags.Completer = pullRequestNumber(0)
+
+func (pullRequestNumber) Complete(match string) []flags.Completion {
+	g, err := git.NewGateway("", nil)
+	if err != nil {
+		return nil
+	}
+	return completePullRequests(match, loadCachedPullRequests(g))
+}
+
+func completePullRequests(match string, prs []*prcache.PullRequest) []flags.Completion {
+	var items []flags.Completion
+	for _, pr := range prs {
+		number := strconv.Itoa(pr.Number)
+		if !strings.HasPrefix(number, match) {
+			continue
+		}
+		items = append(items, flags.Completion{
+			Item:        number,
+			Description: fmt.Sprintf("%v: %v", pr.Branch, pr.Title),
+		})
+	}
+	return items
+}
+
+func completeBranches(match string, branches []string, prs []*prcache.PullRequest) []flags.Completion {
+	descriptions := make(map[string]string)
+	for _, pr := range prs {
+		descriptions[pr.Branch] = fmt.Sprintf("#%v: %v", pr.Number, pr.Title)
+	}
+
+	seen := make(map[string]struct{})
+	var items []flags.Completion
+	add := func(branch string) {
+		if _, ok := seen[branch]; ok || !strings.HasPrefix(branch, match) {
+			return
+		}
+		seen[branch] = struct{}{}
+		items = append(items, flags.Completion{
+			Item:        branch,
+			Description: descriptions[branch],
+		})
+	}
+
+	for _, br := range branches {
+		add(br)
+	}
+	for _, pr := range prs {
+		add(pr.Branch)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Item < items[j].Item
+	})
+	return items
+}
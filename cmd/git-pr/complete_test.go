@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/abhinav/git-pr/prcache"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompleteBranches(t *testing.T) {
+	branches := []string{"master", "feature1", "feature2", "fix"}
+	prs := []*prcache.PullRequest{
+		{Number: 1, Branch: "feature1", Title: "Add feature"},
+		{Number: 3, Branch: "feature3", Title: "Add another feature"},
+	}
+
+	tests := []struct {
+		Match string
+		Want  []flags.Completion
+	}{
+		{
+			Match: "feat",
+			Want: []flags.Completion{
+				{Item: "feature1", Description: "#1: Add feature"},
+				{Item: "feature2"},
+				{Item: "feature3", Description: "#3: Add another feature"},
+			},
+		},
+		{
+			Match: "fi",
+			Want:  []flags.Completion{{Item: "fix"}},
+		},
+		{
+			Match: "",
+			Want: []flags.Completion{
+				{Item: "feature1", Description: "#1: Add feature"},
+				{Item: "feature2"},
+				{Item: "feature3", Description: "#3: Add another feature"},
+				{Item: "fix"},
+				{Item: "master"},
+			},
+		},
+		{Match: "nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Match, func(t *testing.T) {
+			assert.Equal(t, tt.Want, completeBranches(tt.Match, branches, prs))
+		})
+	}
+}
+
+func TestCompletePullRequests(t *testing.T) {
+	prs := []*prcache.PullRequest{
+		{Number: 1, Branch: "feature1", Title: "Add feature"},
+		{Number: 12, Branch: "feature2", Title: "Fix feature"},
+		{Number: 23, Branch: "feature3", Title: "Add another feature"},
+	}
+
+	tests := []struct {
+		Match string
+		Want  []flags.Completion
+	}{
+		{
+			Match: "1",
+			Want: []flags.Completion{
+				{Item: "1", Description: "feature1: Add feature"},
+				{Item: "12", Description: "feature2: Fix feature"},
+			},
+		},
+		{
+			Match: "",
+			Want: []flags.Completion{
+				{Item: "1", Description: "feature1: Add feature"},
+				{Item: "12", Description: "feature2: Fix feature"},
+				{Item: "23", Description: "feature3: Add another feature"},
+			},
+		},
+		{Match: "4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Match, func(t *testing.T) {
+			assert.Equal(t, tt.Want, completePullRequests(tt.Match, prs))
+		})
+	}
+}
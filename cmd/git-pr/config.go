@@ -27,6 +27,11 @@ func newConfigBuilder(cb cli.ConfigBuilder) configBuilder {
 			Service: pr.NewService(pr.ServiceConfig{
 				GitHub: cfg.GitHub(),
 				Git:    cfg.Git(),
+				Log:    cfg.Logger().Named("pr"),
+				User:   cfg.CurrentGitHubUser(),
+
+				Remote:      cfg.Settings().String("remote"),
+				Concurrency: cfg.Settings().Int("concurrency"),
 			}),
 		}, nil
 	}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/git"
+
+	"github.com/jessevdk/go-flags"
+)
+
+type createBranchCmd struct {
+	Args struct {
+		Name string `positional-arg-name:"NAME" required:"yes" description:"Name of the new branch."`
+	} `positional-args:"yes"`
+
+	getConfig configBuilder
+}
+
+func newCreateBranchCommand(cbuild cli.ConfigBuilder) flags.Commander {
+	return &createBranchCmd{getConfig: newConfigBuilder(cbuild)}
+}
+
+func (c *createBranchCmd) Execute([]string) error {
+	cfg, err := c.getConfig()
+	if err != nil {
+		return err
+	}
+
+	name := c.Args.Name
+	parent, err := cfg.Git().CurrentBranch()
+	if err != nil {
+		return err
+	}
+
+	// rev-parse --abbrev-ref reports a detached HEAD as "HEAD". That isn't a
+	// branch that can be recorded as the parent.
+	if parent == "HEAD" {
+		return fmt.Errorf(
+			"cannot create %q: HEAD is detached, check out the parent branch first", name)
+	}
+
+	if err := cfg.Git().CreateBranchAndCheckout(name, parent); err != nil {
+		return err
+	}
+
+	if err := git.SetParent(cfg.Git(), name, parent); err != nil {
+		return err
+	}
+
+	if err := git.SetUpstream(cfg.Git(), name, parent); err != nil {
+		return err
+	}
+
+	cfg.Reporter().Printf("Created branch %q on top of %q", name, parent)
+	cfg.Reporter().Result(createBranchResult{Branch: name, Parent: parent})
+	return nil
+}
+
+type createBranchResult struct {
+	Branch string `json:"branch"`
+	Parent string `json:"parent"`
+}
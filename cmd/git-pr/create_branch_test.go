@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abhinav/git-pr/cli/clitest"
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/repo"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateBranchCmd(t *testing.T) {
+	tests := []struct {
+		Desc string
+
+		Name          string
+		CurrentBranch string
+
+		// Error returned when creating the branch.
+		CreateError error
+
+		// Whether the branch is expected to be created and recorded.
+		WantCreated bool
+
+		// If non-empty, an error with a message matching this will be
+		// expected
+		WantError string
+	}{
+		{
+			Desc:          "success",
+			Name:          "feature2",
+			CurrentBranch: "feature1",
+			WantCreated:   true,
+		},
+		{
+			Desc:          "create failure",
+			Name:          "feature2",
+			CurrentBranch: "feature1",
+			CreateError:   errors.New("branch already exists"),
+			WantError:     "branch already exists",
+		},
+		{
+			Desc:          "detached HEAD",
+			Name:          "feature2",
+			CurrentBranch: "HEAD",
+			WantError:     "HEAD is detached",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			git := gatewaytest.NewMockGit(mockCtrl)
+			cb := &fakeConfigBuilder{
+				ConfigBuilder: clitest.ConfigBuilder{
+					Git:    git,
+					GitHub: gatewaytest.NewMockGitHub(mockCtrl),
+					Repo:   &repo.Repo{Owner: "foo", Name: "bar"},
+				},
+			}
+			cmd := createBranchCmd{getConfig: cb.Build}
+			cmd.Args.Name = tt.Name
+
+			git.EXPECT().CurrentBranch().Return(tt.CurrentBranch, nil)
+			if tt.CurrentBranch != "HEAD" {
+				git.EXPECT().CreateBranchAndCheckout(tt.Name, tt.CurrentBranch).
+					Return(tt.CreateError)
+			}
+
+			if tt.WantCreated {
+				prefix := "branch." + tt.Name
+				git.EXPECT().GetConfig(prefix+".git-pr-parent").Return("", nil)
+				git.EXPECT().SetConfig(prefix+".git-pr-parent", tt.CurrentBranch).Return(nil)
+				git.EXPECT().SetConfig(prefix+".remote", ".").Return(nil)
+				git.EXPECT().SetConfig(prefix+".merge", "refs/heads/"+tt.CurrentBranch).Return(nil)
+			}
+
+			err := cmd.Execute(nil)
+			if tt.WantError != "" {
+				assert.Error(t, err, "expected failure")
+				assert.Contains(t, err.Error(), tt.WantError)
+			} else {
+				assert.NoError(t, err, "command failed")
+			}
+		})
+	}
+}
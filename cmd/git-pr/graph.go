@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/pr"
+
+	"github.com/jessevdk/go-flags"
+	"go.uber.org/multierr"
+)
+
+type graphCmd struct {
+	Base   branchName `long:"base" value-name:"BASE" description:"Name of the base branch whose dependents will be included in the graph. Defaults to the base setting."`
+	Format string     `long:"format" value-name:"FORMAT" default:"ascii" choice:"ascii" choice:"dot" choice:"mermaid" choice:"json" description:"Format in which the graph will be printed."`
+
+	getConfig configBuilder
+}
+
+func newGraphCommand(cbuild cli.ConfigBuilder) flags.Commander {
+	return &graphCmd{getConfig: newConfigBuilder(cbuild)}
+}
+
+func (g *graphCmd) Execute([]string) error {
+	cfg, err := g.getConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := cfg.Context()
+	if g.Base == "" {
+		g.Base = branchName(cfg.Settings().String("base"))
+	}
+
+	roots, err := cfg.GitHub().ListPullRequestsByBase(ctx, string(g.Base))
+	if err != nil {
+		return err
+	}
+
+	// Pull requests from forks are left out because their head branches may
+	// have the same names as ours.
+	concurrency := cfg.Settings().Int("concurrency")
+	graph, err := pr.LoadGraph(pr.GraphConfig{
+		Context:     ctx,
+		GitHub:      cfg.GitHub(),
+		Include:     pr.OwnedPullRequests(ctx, cfg.GitHub()),
+		Concurrency: concurrency,
+	}, roots)
+	if err != nil {
+		return err
+	}
+
+	s := stackGraph{Nodes: []stackNode{{Branch: string(g.Base)}}}
+	for _, branch := range graph.TopologicalOrder() {
+		pull := graph.PullRequest(branch)
+		s.Nodes = append(s.Nodes, stackNode{
+			Branch: branch,
+			Number: pull.GetNumber(),
+			Title:  pull.GetTitle(),
+			URL:    pull.GetHTMLURL(),
+			Author: pull.User.GetLogin(),
+		})
+		s.Edges = append(s.Edges, stackEdge{Base: graph.Parent(branch), Head: branch})
+	}
+
+	if err := fetchNodeStatuses(ctx, cfg.GitHub(), graph, s.Nodes[1:], concurrency); err != nil {
+		return err
+	}
+
+	cfg.Reporter().Result(s)
+
+	out := cfg.Reporter().Writer()
+	switch g.Format {
+	case "dot":
+		return s.WriteDOT(out)
+	case "mermaid":
+		return s.WriteMermaid(out)
+	case "json":
+		return s.WriteJSON(out)
+	default:
+		return s.WriteASCII(out)
+	}
+}
+
+// Maximum number of nodes whose statuses are fetched at the same time unless
+// the concurrency was configured.
+const _nodeStatusConcurrency = 4
+
+// fetchNodeStatuses fills in the review and build states of the given nodes
+// from their pull requests in graph.
+func fetchNodeStatuses(ctx context.Context, gh gateway.GitHub, graph *pr.Graph, nodes []stackNode, concurrency int) (err error) {
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+
+		indexes = make(chan int)
+	)
+	if concurrency <= 0 {
+		concurrency = _nodeStatusConcurrency
+	}
+	for i := 0; i < concurrency && i < len(nodes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				node := &nodes[i]
+				pull := graph.PullRequest(node.Branch)
+
+				reviews, e := gh.ListPullRequestReviews(ctx, node.Number)
+				if e == nil {
+					node.Review = reviewState(reviews)
+
+					var status *gateway.BuildStatus
+					status, e = gh.GetBuildStatus(ctx, pull.Head.GetSHA())
+					if e == nil {
+						node.Build = status.State
+						continue
+					}
+				}
+
+				mu.Lock()
+				err = multierr.Append(err, fmt.Errorf(
+					"failed to get status of %v: %v", pull.GetHTMLURL(), e))
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range nodes {
+		// select picks randomly if both cases are ready so we check this
+		// first.
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return multierr.Append(err, ctx.Err())
+}
+
+// reviewState summarizes the given reviews into a single state. Only the
+// latest review by each user is considered. If any user requested changes,
+// changes are considered requested.
+func reviewState(reviews []*gateway.PullRequestReview) gateway.PullRequestReviewState {
+	latest := make(map[string]gateway.PullRequestReviewState)
+	for _, r := range reviews {
+		switch r.Status {
+		case gateway.PullRequestApproved, gateway.PullRequestChangesRequested:
+			latest[r.User] = r.Status
+		}
+	}
+
+	var state gateway.PullRequestReviewState
+	for _, s := range latest {
+		if s == gateway.PullRequestChangesRequested {
+			return s
+		}
+		state = s
+	}
+	return state
+}
+
+// stackGraph is a printable graph of stacked pull requests.
+type stackGraph struct {
+	// The first node is the base branch. The rest are listed such that
+	// parents appear before their children.
+	Nodes []stackNode `json:"nodes"`
+	Edges []stackEdge `json:"edges"`
+}
+
+// stackNode is a branch in a stackGraph. Everything except the branch name
+// is empty for the base branch.
+type stackNode struct {
+	Branch string                         `json:"branch"`
+	Number int                            `json:"number,omitempty"`
+	Title  string                         `json:"title,omitempty"`
+	URL    string                         `json:"url,omitempty"`
+	Author string                         `json:"author,omitempty"`
+	Review gateway.PullRequestReviewState `json:"review,omitempty"`
+	Build  gateway.BuildState             `json:"build,omitempty"`
+}
+
+// Summary is a short human-readable description of the pull request for
+// this node.
+func (n *stackNode) Summary() string {
+	if n.Number == 0 {
+		return ""
+	}
+
+	parts := []string{fmt.Sprintf("#%v by %v", n.Number, n.Author)}
+	if n.Review != "" {
+		parts = append(parts, strings.ToLower(string(n.Review)))
+	}
+	if n.Build != "" {
+		parts = append(parts, "build "+string(n.Build))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// stackEdge indicates that the head branch depends on the base branch.
+type stackEdge struct {
+	Base string `json:"base"`
+	Head string `json:"head"`
+}
+
+// children returns the nodes that depend on the given branch in the order
+// in which they appear in the graph.
+func (s *stackGraph) children(branch string) []*stackNode {
+	heads := make(map[string]struct{})
+	for _, e := range s.Edges {
+		if e.Base == branch {
+			heads[e.Head] = struct{}{}
+		}
+	}
+
+	var nodes []*stackNode
+	for i := range s.Nodes {
+		if _, ok := heads[s.Nodes[i].Branch]; ok {
+			nodes = append(nodes, &s.Nodes[i])
+		}
+	}
+	return nodes
+}
+
+// WriteASCII writes the graph as a tree.
+//
+// 	master
+// 	`-- feature1 (#1 by abhinav, approved, build success)
+// 	    |-- feature2 (#2 by abhinav)
+// 	    `-- feature3 (#3 by abhinav)
+func (s *stackGraph) WriteASCII(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, s.Nodes[0].Branch); err != nil {
+		return err
+	}
+	return s.writeASCIIChildren(w, s.Nodes[0].Branch, "")
+}
+
+func (s *stackGraph) writeASCIIChildren(w io.Writer, branch, indent string) error {
+	children := s.children(branch)
+	for i, n := range children {
+		connector, childIndent := "|-- ", "|   "
+		if i == len(children)-1 {
+			connector, childIndent = "`-- ", "    "
+		}
+
+		line := indent + connector + n.Branch
+		if summary := n.Summary(); summary != "" {
+			line += " (" + summary + ")"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+
+		if err := s.writeASCIIChildren(w, n.Branch, indent+childIndent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language.
+func (s *stackGraph) WriteDOT(w io.Writer) error {
+	lines := []string{"digraph stack {"}
+	for _, n := range s.Nodes {
+		label := n.Branch
+		if summary := n.Summary(); summary != "" {
+			label += "\n" + summary
+		}
+		lines = append(lines, fmt.Sprintf("\t%q [label=%q];", n.Branch, label))
+	}
+	for _, e := range s.Edges {
+		lines = append(lines, fmt.Sprintf("\t%q -> %q;", e.Base, e.Head))
+	}
+	lines = append(lines, "}")
+
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart.
+func (s *stackGraph) WriteMermaid(w io.Writer) error {
+	// Branch names aren't valid Mermaid identifiers so we number the nodes
+	// instead.
+	ids := make(map[string]string, len(s.Nodes))
+	lines := []string{"graph TD"}
+	for i, n := range s.Nodes {
+		id := fmt.Sprintf("n%v", i)
+		ids[n.Branch] = id
+
+		label := n.Branch
+		if summary := n.Summary(); summary != "" {
+			label += "<br/>" + summary
+		}
+		label = strings.Replace(label, `"`, "#quot;", -1)
+		lines = append(lines, fmt.Sprintf("\t%v[\"%v\"]", id, label))
+	}
+	for _, e := range s.Edges {
+		lines = append(lines, fmt.Sprintf("\t%v --> %v", ids[e.Base], ids[e.Head]))
+	}
+
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// WriteJSON writes the graph as a JSON object with nodes and edges.
+func (s *stackGraph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
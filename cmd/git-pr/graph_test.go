@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/cli/clitest"
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/ptr"
+	"github.com/abhinav/git-pr/repo"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphCmd(t *testing.T) {
+	pull := func(num int, head, base string) *github.PullRequest {
+		return &github.PullRequest{
+			Number:  github.Int(num),
+			Title:   ptr.String("Add " + head),
+			HTMLURL: ptr.String("http://github.com/foo/bar/pull/" + head),
+			User:    &github.User{Login: ptr.String("abhinav")},
+			Head: &github.PullRequestBranch{
+				Ref: ptr.String(head),
+				SHA: ptr.String(head + "sha"),
+			},
+			Base: &github.PullRequestBranch{Ref: ptr.String(base)},
+		}
+	}
+
+	// master -> feature1 -> feature2
+	//                    \
+	//                     -> feature3
+	pr1 := pull(1, "feature1", "master")
+	pr2 := pull(2, "feature2", "feature1")
+	pr3 := pull(3, "feature3", "feature1")
+
+	// Pull request from a fork with the same head as one of ours.
+	fork := pull(4, "feature2", "master")
+	fork.Head.Label = ptr.String("someone:feature2")
+
+	tests := []struct {
+		Format string
+		Want   string
+	}{
+		{
+			Format: "ascii",
+			Want: "master\n" +
+				"`-- feature1 (#1 by abhinav, approved, build success)\n" +
+				"    |-- feature2 (#2 by abhinav, changes_requested, build failure)\n" +
+				"    `-- feature3 (#3 by abhinav, build pending)\n",
+		},
+		{
+			Format: "dot",
+			Want: "digraph stack {\n" +
+				"\t\"master\" [label=\"master\"];\n" +
+				"\t\"feature1\" [label=\"feature1\\n#1 by abhinav, approved, build success\"];\n" +
+				"\t\"feature2\" [label=\"feature2\\n#2 by abhinav, changes_requested, build failure\"];\n" +
+				"\t\"feature3\" [label=\"feature3\\n#3 by abhinav, build pending\"];\n" +
+				"\t\"master\" -> \"feature1\";\n" +
+				"\t\"feature1\" -> \"feature2\";\n" +
+				"\t\"feature1\" -> \"feature3\";\n" +
+				"}\n",
+		},
+		{
+			Format: "mermaid",
+			Want: "graph TD\n" +
+				"\tn0[\"master\"]\n" +
+				"\tn1[\"feature1<br/>#1 by abhinav, approved, build success\"]\n" +
+				"\tn2[\"feature2<br/>#2 by abhinav, changes_requested, build failure\"]\n" +
+				"\tn3[\"feature3<br/>#3 by abhinav, build pending\"]\n" +
+				"\tn0 --> n1\n" +
+				"\tn1 --> n2\n" +
+				"\tn1 --> n3\n",
+		},
+		{
+			Format: "json",
+			Want: `{
+  "nodes": [
+    {
+      "branch": "master"
+    },
+    {
+      "branch": "feature1",
+      "number": 1,
+      "title": "Add feature1",
+      "url": "http://github.com/foo/bar/pull/feature1",
+      "author": "abhinav",
+      "review": "APPROVED",
+      "build": "success"
+    },
+    {
+      "branch": "feature2",
+      "number": 2,
+      "title": "Add feature2",
+      "url": "http://github.com/foo/bar/pull/feature2",
+      "author": "abhinav",
+      "review": "CHANGES_REQUESTED",
+      "build": "failure"
+    },
+    {
+      "branch": "feature3",
+      "number": 3,
+      "title": "Add feature3",
+      "url": "http://github.com/foo/bar/pull/feature3",
+      "author": "abhinav",
+      "build": "pending"
+    }
+  ],
+  "edges": [
+    {
+      "base": "master",
+      "head": "feature1"
+    },
+    {
+      "base": "feature1",
+      "head": "feature2"
+    },
+    {
+      "base": "feature1",
+      "head": "feature3"
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Format, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			var out bytes.Buffer
+			gh := gatewaytest.NewMockGitHub(mockCtrl)
+			cb := &fakeConfigBuilder{
+				ConfigBuilder: clitest.ConfigBuilder{
+					Git:      gatewaytest.NewMockGit(mockCtrl),
+					GitHub:   gh,
+					Repo:     &repo.Repo{Owner: "foo", Name: "bar"},
+					Reporter: cli.NewTextReporter(log.New(ioutil.Discard, "", 0), &out),
+				},
+			}
+
+			cmd := graphCmd{
+				Base:      "master",
+				Format:    tt.Format,
+				getConfig: cb.Build,
+			}
+
+			gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "master").
+				Return([]*github.PullRequest{pr1, fork}, nil)
+			gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature1").
+				Return([]*github.PullRequest{pr3, pr2}, nil)
+			gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature2").Return(nil, nil)
+			gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature3").Return(nil, nil)
+
+			gh.EXPECT().IsOwned(gomock.Any(), fork.Head).Return(false).AnyTimes()
+			gh.EXPECT().IsOwned(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+
+			gh.EXPECT().ListPullRequestReviews(gomock.Any(), 1).
+				Return([]*gateway.PullRequestReview{
+					{User: "foo", Status: gateway.PullRequestChangesRequested},
+					{User: "bar", Status: gateway.PullRequestApproved},
+					{User: "foo", Status: gateway.PullRequestApproved},
+				}, nil)
+			gh.EXPECT().ListPullRequestReviews(gomock.Any(), 2).
+				Return([]*gateway.PullRequestReview{
+					{User: "foo", Status: gateway.PullRequestChangesRequested},
+					{User: "bar", Status: gateway.PullRequestApproved},
+				}, nil)
+			gh.EXPECT().ListPullRequestReviews(gomock.Any(), 3).Return(nil, nil)
+
+			gh.EXPECT().GetBuildStatus(gomock.Any(), "feature1sha").
+				Return(&gateway.BuildStatus{State: gateway.BuildSuccess}, nil)
+			gh.EXPECT().GetBuildStatus(gomock.Any(), "feature2sha").
+				Return(&gateway.BuildStatus{State: gateway.BuildFailure}, nil)
+			gh.EXPECT().GetBuildStatus(gomock.Any(), "feature3sha").
+				Return(&gateway.BuildStatus{State: gateway.BuildPending}, nil)
+
+			require.NoError(t, cmd.Execute(nil))
+			assert.Equal(t, tt.Want, out.String())
+		})
+	}
+}
@@ -1,90 +1,257 @@
 package main
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
 
 	"github.com/abhinav/git-pr/cli"
 	"github.com/abhinav/git-pr/editor"
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/pr"
 	"github.com/abhinav/git-pr/service"
+	"github.com/abhinav/git-pr/settings"
 
 	"github.com/google/go-github/github"
 	"github.com/jessevdk/go-flags"
 )
 
 type landCmd struct {
-	Editor string `long:"editor" env:"EDITOR" default:"vi" value-name:"EDITOR" description:"Editor to use for interactively editing commit messages."`
-	Args   struct {
-		Branch string `positional-arg-name:"BRANCH" description:"Name of the branch to land. Defaults to the branch in the current directory."`
+	Editor      string `long:"editor" value-name:"EDITOR" description:"Editor to use for interactively editing commit messages. Defaults to the editor used by git."`
+	Message     string `short:"m" long:"message" value-name:"MESSAGE" description:"Use MESSAGE as the commit message instead of opening an editor."`
+	MessageFile string `short:"F" long:"message-file" value-name:"FILE" description:"Use the contents of FILE as the commit message instead of opening an editor. Use - to read the message from stdin."`
+	NoEdit      bool   `long:"no-edit" description:"Use the commit message built from the pull request as-is instead of opening an editor."`
+
+	PullRequest pullRequestNumber `long:"pr" value-name:"NUMBER" description:"Number of the pull request to land instead of BRANCH."`
+
+	Args struct {
+		Branch branchName `positional-arg-name:"BRANCH" description:"Name of the branch to land. Defaults to the branch in the current directory."`
 	} `positional-args:"yes"`
 
 	getConfig configBuilder
 	getEditor func(string) (editor.Editor, error)
+	getenv    func(string) string
+	stdin     io.Reader
 }
 
 func newLandCommand(cbuild cli.ConfigBuilder) flags.Commander {
 	return &landCmd{
 		getConfig: newConfigBuilder(cbuild),
 		getEditor: editor.Pick,
+		getenv:    os.Getenv,
+		stdin:     os.Stdin,
 	}
 }
 
-func (l *landCmd) Execute([]string) error {
-	ctx := context.Background()
+// pickEditor picks the editor used to build the commit message. An
+// interactive editor is used only if the message wasn't supplied some other
+// way so that land may run without a terminal.
+func (l *landCmd) pickEditor(g gateway.Git) (editor.Editor, error) {
+	var modes int
+	for _, set := range []bool{l.Message != "", l.MessageFile != "", l.NoEdit} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return nil, errors.New("only one of --message, --message-file, and --no-edit may be used")
+	}
 
+	switch {
+	case l.Message != "":
+		return editor.NewStatic(l.Message), nil
+	case l.MessageFile == "-":
+		return editor.NewReader(l.stdin), nil
+	case l.MessageFile != "":
+		message, err := ioutil.ReadFile(l.MessageFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit message: %v", err)
+		}
+		return editor.NewStatic(string(message)), nil
+	case l.NoEdit:
+		return editor.Noop{}, nil
+	default:
+		if l.Editor != "" {
+			return l.getEditor(l.Editor)
+		}
+
+		coreEditor, err := g.GetConfig("core.editor")
+		if err != nil {
+			return nil, err
+		}
+		return l.getEditor(editor.Resolve(l.getenv, coreEditor))
+	}
+}
+
+func (l *landCmd) Execute([]string) error {
 	cfg, err := l.getConfig()
 	if err != nil {
 		return err
 	}
 
-	editor, err := l.getEditor(l.Editor)
+	ctx := cfg.Context()
+
+	editor, err := l.pickEditor(cfg.Git())
 	if err != nil {
 		return err
 	}
 
-	req := service.LandRequest{Editor: editor}
+	tmpl, err := loadMessageTemplate(cfg.Git(), cfg.Settings())
+	if err != nil {
+		return err
+	}
 
-	// TODO: accept other inputs for the PR to land
-	branch := l.Args.Branch
+	req := service.LandRequest{
+		Editor:          editor,
+		MessageTemplate: tmpl,
+		Trailers:        landTrailers(cfg.Settings()),
+		Lint:            lintRules(cfg.Settings()),
+		MergeMethod:     gateway.MergeMethod(cfg.Settings().String("mergeMethod")),
+	}
+
+	if l.PullRequest != 0 {
+		if l.Args.Branch != "" {
+			return errors.New("only one of BRANCH and --pr may be used")
+		}
+		req.PullRequest, err = l.getPullRequest(cfg)
+	} else {
+		req.PullRequest, req.LocalBranch, err = l.findPullRequest(cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	cfg.Reporter().Println("Landing", *req.PullRequest.HTMLURL)
+	res, err := cfg.Service.Land(ctx, &req)
+	if err != nil {
+		return cli.WrapErrorf(err, "failed to land %v", *req.PullRequest.HTMLURL)
+	}
+
+	reportBranchesNotUpdated(cfg.Reporter(), res.BranchesNotUpdated)
+	cfg.Reporter().Result(landResult{
+		Landed:             newPullRequestResult(req.PullRequest),
+		Rebased:            newRebasedPullRequestResults(res.RebasedPullRequests),
+		BranchesNotUpdated: res.BranchesNotUpdated,
+	})
+	return nil
+}
+
+// getPullRequest retrieves the pull request given with --pr.
+func (l *landCmd) getPullRequest(cfg config) (*github.PullRequest, error) {
+	pr, err := cfg.GitHub().GetPullRequest(cfg.Context(), int(l.PullRequest))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case pr.GetState() != "open":
+		return nil, fmt.Errorf("%v is not open", pr.GetHTMLURL())
+	case pr.Head == nil || !cfg.GitHub().IsOwned(cfg.Context(), pr.Head):
+		return nil, fmt.Errorf("%v can't be landed: its branch is in a fork", pr.GetHTMLURL())
+	}
+	return pr, nil
+}
+
+// findPullRequest finds the pull request for the branch given as an
+// argument or the current branch. The name of the current branch is returned
+// if it was used.
+func (l *landCmd) findPullRequest(cfg config) (_ *github.PullRequest, localBranch string, _ error) {
+	branch := string(l.Args.Branch)
 	if branch == "" {
 		out, err := cfg.Git().CurrentBranch()
 		if err != nil {
-			return err
+			return nil, "", err
 		}
 		branch = out
-		req.LocalBranch = out
+		localBranch = out
 	}
 
-	prs, err := cfg.GitHub().ListPullRequestsByHead(ctx, "", branch)
+	prs, err := cfg.GitHub().ListPullRequestsByHead(cfg.Context(), "", branch)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 	switch len(prs) {
 	case 0:
-		return fmt.Errorf("Could not find PRs with head %q", branch)
+		return nil, "", errNoPRsWithHead{Head: branch}
 	case 1:
-		req.PullRequest = prs[0]
+		return prs[0], localBranch, nil
 	default:
-		return errTooManyPRsWithHead{Head: branch, Pulls: prs}
+		return nil, "", errTooManyPRsWithHead{Head: branch, Pulls: prs}
 	}
+}
 
-	log.Println("Landing", *req.PullRequest.HTMLURL)
-	res, err := cfg.Service.Land(ctx, &req)
-	if err != nil {
-		return fmt.Errorf("failed to land %v: %v", *req.PullRequest.HTMLURL, err)
+func landTrailers(s *settings.Settings) service.LandTrailers {
+	return service.LandTrailers{
+		ReviewedBy:   s.Bool("trailers.reviewedBy"),
+		CoAuthoredBy: s.Bool("trailers.coAuthoredBy"),
+		PullRequest:  s.Bool("trailers.pullRequest"),
+	}
+}
+
+func lintRules(s *settings.Settings) service.LintRules {
+	return service.LintRules{
+		MaxTitleLength:      s.Int("lint.maxTitleLength"),
+		ConventionalCommits: s.Bool("lint.conventionalCommits"),
+		BodyWidth:           s.Int("lint.bodyWidth"),
+		RequireIssue:        s.Bool("lint.requireIssue"),
 	}
+}
+
+// Repositories without a messageTemplate setting may commit a template to
+// this file at their root.
+const _messageTemplateFile = ".git-pr-message.tmpl"
 
-	if len(res.BranchesNotUpdated) > 0 {
-		log.Println("The following local branches were not updated because " +
-			"they did not match the corresponding remotes")
-		for _, br := range res.BranchesNotUpdated {
-			log.Println(" -", br)
+// loadMessageTemplate loads the commit message template for the repository.
+// nil is returned if the repository doesn't have one. Relative paths are
+// relative to the root of the repository.
+func loadMessageTemplate(g gateway.Git, s *settings.Settings) (*template.Template, error) {
+	path := s.String("messageTemplate")
+	optional := path == ""
+	if optional {
+		path = _messageTemplateFile
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.RootDir(), path)
+	}
+
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		if optional && os.IsNotExist(err) {
+			return nil, nil
 		}
+		return nil, fmt.Errorf("failed to read commit message template: %v", err)
 	}
-	return nil
+
+	return pr.ParseMessageTemplate(path, string(text))
+}
+
+type landResult struct {
+	Landed             pullRequestResult          `json:"landed"`
+	Rebased            []rebasedPullRequestResult `json:"rebased"`
+	BranchesNotUpdated []string                   `json:"branches_not_updated"`
 }
 
+type errNoPRsWithHead struct {
+	Head string
+
+	// Whether local branches that depend on Head were also looked for.
+	CheckedLocal bool
+}
+
+func (e errNoPRsWithHead) Error() string {
+	if e.CheckedLocal {
+		return fmt.Sprintf(
+			"Could not find PRs with head %q or local branches that depend on it", e.Head)
+	}
+	return fmt.Sprintf("Could not find PRs with head %q", e.Head)
+}
+
+func (errNoPRsWithHead) Code() string { return "pull_request_not_found" }
+
 type errTooManyPRsWithHead struct {
 	Head  string
 	Pulls []*github.PullRequest
@@ -99,3 +266,5 @@ func (e errTooManyPRsWithHead) Error() string {
 	// msg += fmt.Sprintf("\nPlease provide the PR number instead.")
 	return msg
 }
+
+func (errTooManyPRsWithHead) Code() string { return "too_many_pull_requests" }
@@ -1,40 +1,70 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/abhinav/git-pr/cli"
 	"github.com/abhinav/git-pr/cli/clitest"
 	"github.com/abhinav/git-pr/editor"
 	"github.com/abhinav/git-pr/editor/editortest"
+	"github.com/abhinav/git-pr/gateway"
 	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/git"
 	"github.com/abhinav/git-pr/ptr"
 	"github.com/abhinav/git-pr/repo"
 	"github.com/abhinav/git-pr/service"
 	"github.com/abhinav/git-pr/service/servicetest"
+	"github.com/abhinav/git-pr/settings"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-github/github"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLandCmd(t *testing.T) {
+	root, err := ioutil.TempDir("", "git-pr")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
 	type prMap map[string][]*github.PullRequest
 
 	tests := []struct {
 		Desc string
 
 		Head          string
+		PullRequest   int
 		CurrentBranch string
 
 		// Map of branch name to pull requests with that head.
 		PullRequestsByHead prMap
 
+		// Pull request returned for PullRequest and whether it's from a
+		// fork.
+		GetPullRequest *github.PullRequest
+		Fork           bool
+
+		// Settings that differ from the defaults.
+		Settings map[string]string
+
 		ExpectLandRequest  *service.LandRequest
 		ReturnLandResponse *service.LandResponse
+		ReturnLandError    error
 
 		// If non-empty, an error with a message matching this will be
 		// expected
 		WantError string
+
+		// Machine-readable code expected for the error, if any.
+		WantErrorCode string
 	}{
 		{
 			Desc:               "no PRs",
@@ -82,6 +112,107 @@ func TestLandCmd(t *testing.T) {
 			},
 			ReturnLandResponse: &service.LandResponse{},
 		},
+		{
+			Desc:          "pull request number",
+			PullRequest:   6,
+			CurrentBranch: "master",
+			GetPullRequest: &github.PullRequest{
+				HTMLURL: ptr.String("feature6"),
+				State:   ptr.String("open"),
+				Head:    &github.PullRequestBranch{Ref: ptr.String("feature6")},
+			},
+			ExpectLandRequest: &service.LandRequest{
+				PullRequest: &github.PullRequest{
+					HTMLURL: ptr.String("feature6"),
+					State:   ptr.String("open"),
+					Head:    &github.PullRequestBranch{Ref: ptr.String("feature6")},
+				},
+			},
+			ReturnLandResponse: &service.LandResponse{},
+		},
+		{
+			Desc:        "closed pull request",
+			PullRequest: 6,
+			GetPullRequest: &github.PullRequest{
+				HTMLURL: ptr.String("feature6"),
+				State:   ptr.String("closed"),
+				Head:    &github.PullRequestBranch{Ref: ptr.String("feature6")},
+			},
+			WantError: "feature6 is not open",
+		},
+		{
+			Desc:        "pull request from fork",
+			PullRequest: 6,
+			GetPullRequest: &github.PullRequest{
+				HTMLURL: ptr.String("feature6"),
+				State:   ptr.String("open"),
+				Head:    &github.PullRequestBranch{Ref: ptr.String("feature6")},
+			},
+			Fork:      true,
+			WantError: "feature6 can't be landed: its branch is in a fork",
+		},
+		{
+			Desc:        "branch and pull request number",
+			Head:        "feature6",
+			PullRequest: 6,
+			WantError:   "only one of BRANCH and --pr may be used",
+		},
+		{
+			Desc:          "trailers",
+			CurrentBranch: "feature5",
+			PullRequestsByHead: prMap{
+				"feature5": {{HTMLURL: ptr.String("feature5")}},
+			},
+			Settings: map[string]string{
+				"trailers.reviewedBy":  "true",
+				"trailers.pullRequest": "yes",
+			},
+			ExpectLandRequest: &service.LandRequest{
+				LocalBranch: "feature5",
+				PullRequest: &github.PullRequest{
+					HTMLURL: ptr.String("feature5"),
+				},
+				Trailers: service.LandTrailers{ReviewedBy: true, PullRequest: true},
+			},
+			ReturnLandResponse: &service.LandResponse{},
+		},
+		{
+			Desc:          "lint rules",
+			CurrentBranch: "feature7",
+			PullRequestsByHead: prMap{
+				"feature7": {{HTMLURL: ptr.String("feature7")}},
+			},
+			Settings: map[string]string{
+				"lint.maxTitleLength":      "72",
+				"lint.conventionalCommits": "true",
+				"mergeMethod":              "merge",
+			},
+			ExpectLandRequest: &service.LandRequest{
+				LocalBranch: "feature7",
+				PullRequest: &github.PullRequest{
+					HTMLURL: ptr.String("feature7"),
+				},
+				Lint:        service.LintRules{MaxTitleLength: 72, ConventionalCommits: true},
+				MergeMethod: gateway.MergeCommit,
+			},
+			ReturnLandResponse: &service.LandResponse{},
+		},
+		{
+			Desc:          "land interrupted",
+			CurrentBranch: "feature8",
+			PullRequestsByHead: prMap{
+				"feature8": {{HTMLURL: ptr.String("feature8")}},
+			},
+			ExpectLandRequest: &service.LandRequest{
+				LocalBranch: "feature8",
+				PullRequest: &github.PullRequest{
+					HTMLURL: ptr.String("feature8"),
+				},
+			},
+			ReturnLandError: context.Canceled,
+			WantError:       "failed to land feature8: context canceled",
+			WantErrorCode:   "interrupted",
+		},
 	}
 
 	for _, tt := range tests {
@@ -94,11 +225,17 @@ func TestLandCmd(t *testing.T) {
 			svc := servicetest.NewMockPR(mockCtrl)
 			ed := editortest.NewMockEditor(mockCtrl)
 
+			s := settings.New()
+			for name, value := range tt.Settings {
+				require.NoError(t, s.Set(name, value, "test"))
+			}
+
 			cb := &fakeConfigBuilder{
 				ConfigBuilder: clitest.ConfigBuilder{
-					Git:    git,
-					GitHub: github,
-					Repo:   &repo.Repo{Owner: "foo", Name: "bar"},
+					Git:      git,
+					GitHub:   github,
+					Repo:     &repo.Repo{Owner: "foo", Name: "bar"},
+					Settings: s,
 				},
 				Service: svc,
 			}
@@ -106,7 +243,8 @@ func TestLandCmd(t *testing.T) {
 				getConfig: cb.Build,
 				getEditor: func(string) (editor.Editor, error) { return ed, nil },
 			}
-			cmd.Args.Branch = tt.Head
+			cmd.Args.Branch = branchName(tt.Head)
+			cmd.PullRequest = pullRequestNumber(tt.PullRequest)
 			if cmd.Editor == "" {
 				cmd.Editor = "vi"
 			}
@@ -114,24 +252,312 @@ func TestLandCmd(t *testing.T) {
 			// Always return the current branch if requested.
 			git.EXPECT().CurrentBranch().Return(tt.CurrentBranch, nil).AnyTimes()
 
+			// The repository doesn't have a message template.
+			git.EXPECT().RootDir().Return(root).AnyTimes()
+
 			for head, prs := range tt.PullRequestsByHead {
 				github.EXPECT().ListPullRequestsByHead(gomock.Any(), "", head).Return(prs, nil)
 			}
+			if pr := tt.GetPullRequest; pr != nil {
+				github.EXPECT().GetPullRequest(gomock.Any(), tt.PullRequest).Return(pr, nil)
+				github.EXPECT().IsOwned(gomock.Any(), pr.Head).Return(!tt.Fork).AnyTimes()
+			}
 
 			if tt.ExpectLandRequest != nil {
 				if tt.ExpectLandRequest.Editor == nil {
 					tt.ExpectLandRequest.Editor = ed
 				}
-				svc.EXPECT().Land(gomock.Any(), tt.ExpectLandRequest).Return(tt.ReturnLandResponse, nil)
+				if tt.ExpectLandRequest.MergeMethod == "" {
+					tt.ExpectLandRequest.MergeMethod = gateway.MergeSquash
+				}
+				svc.EXPECT().Land(gomock.Any(), tt.ExpectLandRequest).
+					Return(tt.ReturnLandResponse, tt.ReturnLandError)
 			}
 
 			err := cmd.Execute(nil)
 			if tt.WantError != "" {
 				assert.Error(t, err, "expected failure")
 				assert.Contains(t, err.Error(), tt.WantError)
+				if tt.WantErrorCode != "" {
+					assert.Equal(t, tt.WantErrorCode, cli.ErrorCode(err))
+				}
 			} else {
 				assert.NoError(t, err, "command rebase failed")
 			}
 		})
 	}
 }
+
+func TestLoadMessageTemplate(t *testing.T) {
+	root, err := ioutil.TempDir("", "git-pr")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	write := func(name, contents string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(root, name), []byte(contents), 0644))
+	}
+
+	load := func(configured string) (string, error) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		git := gatewaytest.NewMockGit(mockCtrl)
+		git.EXPECT().RootDir().Return(root).AnyTimes()
+
+		s := settings.New()
+		if configured != "" {
+			require.NoError(t, s.Set("messageTemplate", configured, "test"))
+		}
+
+		tmpl, err := loadMessageTemplate(git, s)
+		if err != nil || tmpl == nil {
+			return "", err
+		}
+		return tmpl.Name(), nil
+	}
+
+	t.Run("none", func(t *testing.T) {
+		name, err := load("")
+		require.NoError(t, err)
+		assert.Empty(t, name)
+	})
+
+	t.Run("configured file missing", func(t *testing.T) {
+		_, err := load("missing.tmpl")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read commit message template")
+	})
+
+	t.Run("repository file", func(t *testing.T) {
+		write(".git-pr-message.tmpl", "{{.Title}}\n\n{{.Body | wrap 72}}")
+		name, err := load("")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, ".git-pr-message.tmpl"), name)
+	})
+
+	t.Run("configured file", func(t *testing.T) {
+		write("custom.tmpl", "{{.Title}}")
+		name, err := load("custom.tmpl")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, "custom.tmpl"), name)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		write("invalid.tmpl", "{{.Titel}}")
+		_, err := load(filepath.Join(root, "invalid.tmpl"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid commit message template")
+		assert.Contains(t, err.Error(), "Titel")
+	})
+}
+
+func TestLandCmdPickEditor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-pr")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	messageFile := filepath.Join(dir, "message")
+	require.NoError(t, ioutil.WriteFile(messageFile, []byte("From file\n\nBody."), 0644))
+
+	interactive := editor.NewStatic("from the interactive editor")
+
+	tests := []struct {
+		Desc string
+		Cmd  landCmd
+
+		// Message produced by the editor.
+		Want      string
+		WantError string
+	}{
+		{
+			Desc: "interactive",
+			Cmd:  landCmd{Editor: "vi"},
+			Want: "from the interactive editor",
+		},
+		{
+			Desc: "message",
+			Cmd:  landCmd{Message: "From flag"},
+			Want: "From flag",
+		},
+		{
+			Desc: "message file",
+			Cmd:  landCmd{MessageFile: messageFile},
+			Want: "From file\n\nBody.",
+		},
+		{
+			Desc: "stdin",
+			Cmd:  landCmd{MessageFile: "-"},
+			Want: "From stdin",
+		},
+		{
+			Desc: "no edit",
+			Cmd:  landCmd{NoEdit: true},
+			Want: "template",
+		},
+		{
+			Desc:      "missing message file",
+			Cmd:       landCmd{MessageFile: filepath.Join(dir, "missing")},
+			WantError: "failed to read commit message",
+		},
+		{
+			Desc:      "conflicting options",
+			Cmd:       landCmd{Message: "foo", NoEdit: true},
+			WantError: "only one of --message, --message-file, and --no-edit may be used",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			cmd := tt.Cmd
+			cmd.getEditor = func(string) (editor.Editor, error) { return interactive, nil }
+			cmd.stdin = strings.NewReader("From stdin")
+
+			ed, err := cmd.pickEditor(nil)
+			if tt.WantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.WantError)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := ed.EditString("template")
+			require.NoError(t, err)
+			assert.Equal(t, tt.Want, got)
+		})
+	}
+}
+
+func TestLandCmdResolveEditor(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	git := gatewaytest.NewMockGit(mockCtrl)
+	git.EXPECT().GetConfig("core.editor").Return("code --wait", nil)
+
+	var gotEditor string
+	cmd := landCmd{
+		getEditor: func(name string) (editor.Editor, error) {
+			gotEditor = name
+			return editor.Noop{}, nil
+		},
+		getenv: func(k string) string {
+			return map[string]string{"EDITOR": "nano"}[k]
+		},
+	}
+
+	_, err := cmd.pickEditor(git)
+	require.NoError(t, err)
+	assert.Equal(t, "code --wait", gotEditor)
+}
+
+func TestLandCmdJSONOutput(t *testing.T) {
+	root, err := ioutil.TempDir("", "git-pr")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	remote := filepath.Join(root, "remote.git")
+	work := filepath.Join(root, "work")
+	runGit := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	writeFile := func(name, contents string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(work, name), []byte(contents), 0644))
+	}
+
+	runGit(root, "init", "-q", "--bare", remote)
+	runGit(root, "init", "-q", work)
+	runGit(work, "remote", "add", "origin", remote)
+	runGit(work, "checkout", "-q", "-b", "master")
+	writeFile("foo", "foo\n")
+	runGit(work, "add", "foo")
+	runGit(work, "commit", "-q", "-m", "foo")
+	runGit(work, "push", "-q", "origin", "master")
+
+	runGit(work, "checkout", "-q", "-b", "feature1")
+	writeFile("bar", "bar\n")
+	runGit(work, "add", "bar")
+	runGit(work, "commit", "-q", "-m", "bar")
+	runGit(work, "push", "-q", "origin", "feature1")
+	head := runGit(work, "rev-parse", "HEAD")
+
+	// Stand in for GitHub merging the pull request. Pulling master will
+	// fast-forward it, which git reports on stdout.
+	runGit(work, "push", "-q", "origin", "feature1:master")
+
+	stdout, err := ioutil.TempFile(root, "stdout")
+	require.NoError(t, err)
+	defer stdout.Close()
+
+	oldStdout := os.Stdout
+	os.Stdout = stdout
+	defer func() { os.Stdout = oldStdout }()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pr := &github.PullRequest{
+		Number:  github.Int(1),
+		HTMLURL: ptr.String("http://github.com/foo/bar/pull/1"),
+		Head: &github.PullRequestBranch{
+			Ref: ptr.String("feature1"),
+			SHA: ptr.String(head),
+		},
+		Base: &github.PullRequestBranch{Ref: ptr.String("master")},
+	}
+
+	gh := gatewaytest.NewMockGitHub(mockCtrl)
+	gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", "feature1").
+		Return([]*github.PullRequest{pr}, nil)
+	gh.EXPECT().MergePullRequest(gomock.Any(), &gateway.MergeRequest{
+		PullRequest: pr,
+		Method:      gateway.MergeRebase,
+	}).Return(nil)
+	gh.EXPECT().IsOwned(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature1").Return(nil, nil)
+	gh.EXPECT().DeleteBranch(gomock.Any(), "feature1").Return(nil)
+
+	gw, err := git.NewGateway(work, nil)
+	require.NoError(t, err)
+
+	s := settings.New()
+	require.NoError(t, s.Set("mergeMethod", "rebase", "test"))
+
+	reporter := cli.NewJSONReporter(os.Stdout)
+	cb := &clitest.ConfigBuilder{
+		Git:      gw,
+		GitHub:   gh,
+		Repo:     &repo.Repo{Owner: "foo", Name: "bar"},
+		Settings: s,
+		Reporter: reporter,
+	}
+	cmd := landCmd{getConfig: newConfigBuilder(cb.Build), NoEdit: true}
+
+	require.NoError(t, reporter.Finish(cmd.Execute(nil)))
+	assert.Equal(t, "master", runGit(work, "rev-parse", "--abbrev-ref", "HEAD"))
+
+	_, err = stdout.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	var out struct {
+		OK     bool `json:"ok"`
+		Result struct {
+			Landed struct {
+				Number int `json:"number"`
+			} `json:"landed"`
+		} `json:"result"`
+	}
+	dec := json.NewDecoder(stdout)
+	require.NoError(t, dec.Decode(&out), "stdout must be a JSON object")
+	assert.True(t, out.OK)
+	assert.Equal(t, 1, out.Result.Landed.Number)
+
+	_, err = dec.Token()
+	assert.Equal(t, io.EOF, err, "stdout must not have anything after the JSON object")
+}
@@ -10,10 +10,62 @@ func main() {
 			ShortDesc: "Lands a GitHub PR.",
 			Build:     newLandCommand,
 		},
+		&cli.Command{
+			Name:      "move",
+			ShortDesc: "Moves a branch onto a different parent.",
+			Build:     newMoveCommand,
+		},
 		&cli.Command{
 			Name:      "rebase",
 			ShortDesc: "Rebases a PR branch.",
 			Build:     newRebaseCommand,
 		},
+		&cli.Command{
+			Name:      "sync",
+			ShortDesc: "Deletes merged branches and rebases their dependents.",
+			Build:     newSyncCommand,
+		},
+		&cli.Command{
+			Name:      "create-branch",
+			ShortDesc: "Creates a new branch on top of the current branch.",
+			Build:     newCreateBranchCommand,
+		},
+		&cli.Command{
+			Name:      "graph",
+			ShortDesc: "Prints the graph of stacked pull requests.",
+			Build:     newGraphCommand,
+		},
+		&cli.Command{
+			Name:      "config",
+			ShortDesc: "Shows settings and where they came from.",
+			Build:     newConfigCommand,
+			Local:     true,
+		},
+		&cli.Command{
+			Name:      "auth",
+			ShortDesc: "Manages stored GitHub tokens.",
+			Build:     newAuthCommand,
+			Global:    true,
+		},
+		&cli.Command{
+			Name:      "up",
+			ShortDesc: "Checks out a branch that depends on the current branch.",
+			Build:     newNavCommand(navUp),
+		},
+		&cli.Command{
+			Name:      "down",
+			ShortDesc: "Checks out the parent of the current branch.",
+			Build:     newNavCommand(navDown),
+		},
+		&cli.Command{
+			Name:      "top",
+			ShortDesc: "Checks out the topmost branch of the current stack.",
+			Build:     newNavCommand(navTop),
+		},
+		&cli.Command{
+			Name:      "bottom",
+			ShortDesc: "Checks out the bottommost branch of the current stack.",
+			Build:     newNavCommand(navBottom),
+		},
 	)
 }
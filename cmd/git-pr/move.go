@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/pr"
+	"github.com/abhinav/git-pr/service"
+
+	"github.com/google/go-github/github"
+	"github.com/jessevdk/go-flags"
+)
+
+type moveCmd struct {
+	Onto          branchName `long:"onto" value-name:"PARENT" required:"yes" description:"Name of the new parent branch."`
+	LeaveChildren bool       `long:"leave-children" description:"If set, branches that depend on BRANCH will be moved onto its old parent instead of moving with it."`
+	Args          struct {
+		Branch branchName `positional-arg-name:"BRANCH" description:"Name of the branch to move. Defaults to the branch in the current directory."`
+	} `positional-args:"yes"`
+
+	getConfig configBuilder
+}
+
+func newMoveCommand(cbuild cli.ConfigBuilder) flags.Commander {
+	return &moveCmd{getConfig: newConfigBuilder(cbuild)}
+}
+
+func (m *moveCmd) Execute([]string) error {
+	cfg, err := m.getConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := cfg.Context()
+
+	branch := string(m.Args.Branch)
+	if branch == "" {
+		out, err := cfg.Git().CurrentBranch()
+		if err != nil {
+			return err
+		}
+		branch = out
+	}
+
+	if branch == string(m.Onto) {
+		return fmt.Errorf("cannot move %q onto itself", branch)
+	}
+
+	graph, err := loadStack(cfg, branch, nil)
+	if err != nil {
+		return err
+	}
+
+	// Moving a branch onto one of its dependents would create a cycle.
+	if !m.LeaveChildren {
+		for _, br := range graph.Subtree(branch) {
+			if br == string(m.Onto) {
+				return fmt.Errorf("cannot move %q onto %q because %q depends on it",
+					branch, m.Onto, m.Onto)
+			}
+		}
+	}
+
+	if pr := graph.PullRequest(branch); pr != nil {
+		err = m.movePullRequest(ctx, cfg, graph, pr)
+	} else {
+		// The branch hasn't been published yet.
+		err = m.moveLocal(ctx, cfg, graph, branch)
+	}
+	if err != nil {
+		return err
+	}
+
+	cfg.Reporter().Printf("Moved %q onto %q", branch, m.Onto)
+	cfg.Reporter().Result(moveResult{Branch: branch, Onto: string(m.Onto)})
+	return nil
+}
+
+type moveResult struct {
+	Branch string `json:"branch"`
+	Onto   string `json:"onto"`
+}
+
+func (m *moveCmd) movePullRequest(ctx context.Context, cfg config, graph *pr.Graph, pr *github.PullRequest) error {
+	branch := pr.Head.GetRef()
+	if m.LeaveChildren {
+		children := graph.PullRequests(graph.Children(branch))
+		if len(children) > 0 {
+			if err := m.rebase(ctx, cfg, &service.RebaseRequest{
+				PullRequests: children,
+				Base:         pr.Base.GetRef(),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return m.rebase(ctx, cfg, &service.RebaseRequest{
+		PullRequests: []*github.PullRequest{pr},
+		Base:         string(m.Onto),
+	})
+}
+
+func (m *moveCmd) rebase(ctx context.Context, cfg config, req *service.RebaseRequest) error {
+	res, err := cfg.Service.Rebase(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	reportBranchesNotUpdated(cfg.Reporter(), res.BranchesNotUpdated)
+	return nil
+}
+
+func (m *moveCmd) moveLocal(ctx context.Context, cfg config, graph *pr.Graph, branch string) error {
+	if m.LeaveChildren {
+		parent := graph.Parent(branch)
+		children := graph.Children(branch)
+		if len(children) > 0 && parent == "" {
+			return fmt.Errorf(
+				"cannot leave dependents of %q behind: its parent is not known", branch)
+		}
+
+		for _, child := range children {
+			if _, err := cfg.Service.RebaseLocal(ctx, &service.RebaseLocalRequest{
+				Branch: child,
+				Base:   parent,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := cfg.Service.RebaseLocal(ctx, &service.RebaseLocalRequest{
+		Branch: branch,
+		Base:   string(m.Onto),
+	})
+	return err
+}
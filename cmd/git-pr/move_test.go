@@ -0,0 +1,221 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/abhinav/git-pr/cli/clitest"
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/ptr"
+	"github.com/abhinav/git-pr/repo"
+	"github.com/abhinav/git-pr/service"
+	"github.com/abhinav/git-pr/service/servicetest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveCmd(t *testing.T) {
+	// Builds a pull request from head to base with the given number.
+	pull := func(num int, head, base string) *github.PullRequest {
+		return &github.PullRequest{
+			Number:  github.Int(num),
+			HTMLURL: ptr.String(head),
+			Head:    &github.PullRequestBranch{Ref: ptr.String(head)},
+			Base:    &github.PullRequestBranch{Ref: ptr.String(base)},
+		}
+	}
+
+	// master -> feature1 -> feature2 -> feature3
+	//                     \
+	//                      -> feature4
+	stack := []*github.PullRequest{
+		pull(1, "feature1", "master"),
+		pull(2, "feature2", "feature1"),
+		pull(3, "feature3", "feature2"),
+		pull(4, "feature4", "feature1"),
+	}
+
+	tests := []struct {
+		Desc string
+
+		Branch        string
+		Onto          string
+		LeaveChildren bool
+		CurrentBranch string
+
+		// Pull requests on GitHub.
+		PullRequests []*github.PullRequest
+
+		// Locally recorded parents of branches.
+		LocalParents map[string]string
+
+		ExpectRebaseRequests      []*service.RebaseRequest
+		ExpectRebaseLocalRequests []*service.RebaseLocalRequest
+
+		WantError string
+	}{
+		{
+			Desc:          "pull request",
+			CurrentBranch: "feature2",
+			Onto:          "feature4",
+			PullRequests:  stack,
+			ExpectRebaseRequests: []*service.RebaseRequest{
+				{
+					PullRequests: []*github.PullRequest{pull(2, "feature2", "feature1")},
+					Base:         "feature4",
+				},
+			},
+		},
+		{
+			Desc:          "pull request leave children",
+			Branch:        "feature2",
+			Onto:          "master",
+			LeaveChildren: true,
+			CurrentBranch: "feature1",
+			PullRequests:  stack,
+			ExpectRebaseRequests: []*service.RebaseRequest{
+				{
+					PullRequests: []*github.PullRequest{pull(3, "feature3", "feature2")},
+					Base:         "feature1",
+				},
+				{
+					PullRequests: []*github.PullRequest{pull(2, "feature2", "feature1")},
+					Base:         "master",
+				},
+			},
+		},
+		{
+			Desc:          "onto itself",
+			CurrentBranch: "feature1",
+			Onto:          "feature1",
+			PullRequests:  stack,
+			WantError:     `cannot move "feature1" onto itself`,
+		},
+		{
+			Desc:          "onto dependent",
+			CurrentBranch: "feature1",
+			Onto:          "feature3",
+			PullRequests:  stack,
+			WantError:     `cannot move "feature1" onto "feature3" because "feature3" depends on it`,
+		},
+		{
+			Desc:          "local",
+			CurrentBranch: "feature6",
+			Onto:          "feature1",
+			PullRequests:  stack,
+			LocalParents: map[string]string{
+				"feature6": "feature5",
+				"feature5": "master",
+				"feature7": "feature6",
+			},
+			ExpectRebaseLocalRequests: []*service.RebaseLocalRequest{
+				{Branch: "feature6", Base: "feature1"},
+			},
+		},
+		{
+			Desc:          "local leave children",
+			CurrentBranch: "feature6",
+			Onto:          "feature1",
+			LeaveChildren: true,
+			PullRequests:  stack,
+			LocalParents: map[string]string{
+				"feature6": "feature5",
+				"feature5": "master",
+				"feature7": "feature6",
+			},
+			ExpectRebaseLocalRequests: []*service.RebaseLocalRequest{
+				{Branch: "feature7", Base: "feature5"},
+				{Branch: "feature6", Base: "feature1"},
+			},
+		},
+		{
+			Desc:          "local leave children without parent",
+			CurrentBranch: "feature6",
+			Onto:          "feature1",
+			LeaveChildren: true,
+			PullRequests:  stack,
+			LocalParents:  map[string]string{"feature7": "feature6"},
+			WantError:     `cannot leave dependents of "feature6" behind: its parent is not known`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			git := gatewaytest.NewMockGit(mockCtrl)
+			gh := gatewaytest.NewMockGitHub(mockCtrl)
+			svc := servicetest.NewMockPR(mockCtrl)
+
+			cb := &fakeConfigBuilder{
+				ConfigBuilder: clitest.ConfigBuilder{
+					Git:    git,
+					GitHub: gh,
+					Repo:   &repo.Repo{Owner: "foo", Name: "bar"},
+				},
+				Service: svc,
+			}
+			cmd := moveCmd{
+				getConfig:     cb.Build,
+				Onto:          branchName(tt.Onto),
+				LeaveChildren: tt.LeaveChildren,
+			}
+			cmd.Args.Branch = branchName(tt.Branch)
+
+			git.EXPECT().CurrentBranch().Return(tt.CurrentBranch, nil).AnyTimes()
+
+			byHead := make(map[string][]*github.PullRequest)
+			byBase := make(map[string][]*github.PullRequest)
+			for _, pr := range tt.PullRequests {
+				head, base := pr.Head.GetRef(), pr.Base.GetRef()
+				byHead[head] = append(byHead[head], pr)
+				byBase[base] = append(byBase[base], pr)
+			}
+			for head, prs := range byHead {
+				gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", head).
+					Return(prs, nil).AnyTimes()
+			}
+			for base, prs := range byBase {
+				gh.EXPECT().ListPullRequestsByBase(gomock.Any(), base).
+					Return(prs, nil).AnyTimes()
+			}
+			gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", gomock.Any()).
+				Return(nil, nil).AnyTimes()
+			gh.EXPECT().ListPullRequestsByBase(gomock.Any(), gomock.Any()).
+				Return(nil, nil).AnyTimes()
+			gh.EXPECT().IsOwned(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+
+			localConfig := make(map[string]string)
+			for branch, parent := range tt.LocalParents {
+				key := "branch." + branch + ".git-pr-parent"
+				localConfig[key] = parent
+				git.EXPECT().GetConfig(key).Return(parent, nil).AnyTimes()
+			}
+			git.EXPECT().GetConfig(gomock.Any()).Return("", nil).AnyTimes()
+			git.EXPECT().ListConfig(gomock.Any()).Return(localConfig, nil).AnyTimes()
+			git.EXPECT().SetConfig(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			git.EXPECT().DoesBranchExist(gomock.Any()).Return(true).AnyTimes()
+
+			var calls []*gomock.Call
+			for _, req := range tt.ExpectRebaseRequests {
+				calls = append(calls, svc.EXPECT().Rebase(gomock.Any(), req).
+					Return(&service.RebaseResponse{}, nil))
+			}
+			for _, req := range tt.ExpectRebaseLocalRequests {
+				calls = append(calls, svc.EXPECT().RebaseLocal(gomock.Any(), req).
+					Return(&service.RebaseLocalResponse{}, nil))
+			}
+			gomock.InOrder(calls...)
+
+			err := cmd.Execute(nil)
+			if tt.WantError != "" {
+				assert.Error(t, err, "expected failure")
+				assert.Contains(t, err.Error(), tt.WantError)
+			} else {
+				assert.NoError(t, err, "command failed")
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/git"
+	"github.com/abhinav/git-pr/pr"
+
+	"github.com/jessevdk/go-flags"
+)
+
+// navDirection specifies where in a stack a navCmd moves.
+type navDirection int
+
+const (
+	// Move to a branch that depends on the current branch.
+	navUp navDirection = iota + 1
+
+	// Move to the parent of the current branch.
+	navDown
+
+	// Move to the topmost branch of the stack.
+	navTop
+
+	// Move to the branch at the bottom of the stack, right above the base
+	// branch.
+	navBottom
+)
+
+type navCmd struct {
+	direction navDirection
+
+	getConfig configBuilder
+
+	// Asks the user to pick one of the given branches.
+	chooseBranch func(prompt string, branches []string) (string, error)
+}
+
+func newNavCommand(dir navDirection) func(cli.ConfigBuilder) flags.Commander {
+	return func(cbuild cli.ConfigBuilder) flags.Commander {
+		return &navCmd{
+			direction:    dir,
+			getConfig:    newConfigBuilder(cbuild),
+			chooseBranch: chooseBranch,
+		}
+	}
+}
+
+func (n *navCmd) Execute([]string) error {
+	cfg, err := n.getConfig()
+	if err != nil {
+		return err
+	}
+
+	current, err := cfg.Git().CurrentBranch()
+	if err != nil {
+		return err
+	}
+
+	var offline bool
+	graph, err := loadStack(cfg, current, func(err error) {
+		cfg.Reporter().Printf("Could not reach GitHub. Using locally recorded branches: %v", err)
+		offline = true
+	})
+	if err != nil {
+		return err
+	}
+
+	// Cache the relationships between branches locally so that they're
+	// available offline.
+	if !offline {
+		if err := recordParents(cfg.Git(), graph); err != nil {
+			return err
+		}
+	}
+
+	var target string
+	switch n.direction {
+	case navUp:
+		target, err = n.up(graph, current)
+	case navDown:
+		target, err = n.down(graph, current)
+	case navTop:
+		target, err = n.top(graph, current)
+	case navBottom:
+		target, err = n.bottom(graph, current)
+	default:
+		panic(fmt.Sprintf("unknown direction %v", n.direction))
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Git().Checkout(target); err != nil {
+		return err
+	}
+
+	cfg.Reporter().Result(navResult{Branch: target})
+	return nil
+}
+
+type navResult struct {
+	Branch string `json:"branch"`
+}
+
+func (n *navCmd) up(graph *pr.Graph, branch string) (string, error) {
+	children := graph.Children(branch)
+	switch len(children) {
+	case 0:
+		return "", fmt.Errorf("branch %q does not have any dependent branches", branch)
+	case 1:
+		return children[0], nil
+	default:
+		return n.chooseBranch(
+			fmt.Sprintf("Multiple branches depend on %q:", branch), children)
+	}
+}
+
+func (n *navCmd) down(graph *pr.Graph, branch string) (string, error) {
+	parent := graph.Parent(branch)
+	if parent == "" {
+		return "", fmt.Errorf("branch %q does not have a parent branch", branch)
+	}
+	return parent, nil
+}
+
+func (n *navCmd) top(graph *pr.Graph, branch string) (string, error) {
+	for {
+		children := graph.Children(branch)
+		switch len(children) {
+		case 0:
+			return branch, nil
+		case 1:
+			branch = children[0]
+		default:
+			var err error
+			branch, err = n.chooseBranch(
+				fmt.Sprintf("Multiple branches depend on %q:", branch), children)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+func (n *navCmd) bottom(graph *pr.Graph, branch string) (string, error) {
+	path := graph.PathToRoot(branch)
+	if len(path) == 0 {
+		return "", fmt.Errorf("branch %q is not part of a stack", branch)
+	}
+	return path[len(path)-1], nil
+}
+
+// loadStack loads the stack of the given branch from GitHub and the
+// relationships recorded locally. Pull requests from forks are ignored
+// because their branches can't be checked out. If GitHub cannot be reached,
+// offline is called and only local information is used.
+func loadStack(cfg config, branch string, offline func(error)) (*pr.Graph, error) {
+	ctx := cfg.Context()
+	graph, err := pr.LoadStack(pr.StackConfig{
+		GraphConfig: pr.GraphConfig{
+			Context: ctx,
+			GitHub:  cfg.GitHub(),
+			Include: pr.OwnedPullRequests(ctx, cfg.GitHub()),
+		},
+		Git:     cfg.Git(),
+		Offline: offline,
+	}, branch)
+	if e, ok := err.(pr.MultiplePullRequestsError); ok {
+		err = errTooManyPRsWithHead{Head: e.Branch, Pulls: e.Pulls}
+	}
+	return graph, err
+}
+
+// recordParents records the parents of local branches with pull requests in
+// the graph.
+func recordParents(gw gateway.Git, graph *pr.Graph) error {
+	for _, branch := range graph.TopologicalOrder() {
+		if graph.PullRequest(branch) == nil || !gw.DoesBranchExist(branch) {
+			continue
+		}
+		if err := git.SetParent(gw, branch, graph.Parent(branch)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
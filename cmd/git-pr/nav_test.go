@@ -0,0 +1,325 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/cli/clitest"
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/ptr"
+	"github.com/abhinav/git-pr/repo"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNavCmd(t *testing.T) {
+	// Builds a pull request from head to base with the given number.
+	pull := func(num int, head, base string) *github.PullRequest {
+		return &github.PullRequest{
+			Number: github.Int(num),
+			Head:   &github.PullRequestBranch{Ref: ptr.String(head)},
+			Base:   &github.PullRequestBranch{Ref: ptr.String(base)},
+		}
+	}
+
+	// Builds a pull request from a fork of the repository.
+	fork := func(num int, head, base string) *github.PullRequest {
+		pr := pull(num, head, base)
+		pr.Head.Label = ptr.String("someone:" + head)
+		return pr
+	}
+
+	// master -> feature1 -> feature2 -> feature3
+	//                     \
+	//                      -> feature4
+	stack := []*github.PullRequest{
+		pull(1, "feature1", "master"),
+		pull(2, "feature2", "feature1"),
+		pull(3, "feature3", "feature2"),
+		pull(4, "feature4", "feature1"),
+	}
+
+	tests := []struct {
+		Desc string
+
+		Direction     navDirection
+		CurrentBranch string
+
+		// Pull requests on GitHub.
+		PullRequests []*github.PullRequest
+
+		// Pull requests from forks of the repository.
+		Forks []*github.PullRequest
+
+		// If set, all requests to GitHub fail.
+		Offline bool
+
+		// Locally recorded parents of branches.
+		LocalParents map[string]string
+
+		// Branch returned when the user is asked to choose a branch.
+		Choose string
+
+		// If set, checking WantCheckout out fails with this error.
+		CheckoutError string
+
+		WantCheckout string
+		WantError    string
+	}{
+		{
+			Desc:          "up",
+			Direction:     navUp,
+			CurrentBranch: "feature2",
+			PullRequests:  stack,
+			WantCheckout:  "feature3",
+		},
+		{
+			Desc:          "up checkout fails",
+			Direction:     navUp,
+			CurrentBranch: "feature2",
+			PullRequests:  stack,
+			CheckoutError: "your local changes would be overwritten",
+			WantCheckout:  "feature3",
+			WantError:     "your local changes would be overwritten",
+		},
+		{
+			Desc:          "up multiple children",
+			Direction:     navUp,
+			CurrentBranch: "feature1",
+			PullRequests:  stack,
+			Choose:        "feature4",
+			WantCheckout:  "feature4",
+		},
+		{
+			Desc:          "up no children",
+			Direction:     navUp,
+			CurrentBranch: "feature3",
+			PullRequests:  stack,
+			WantError:     `branch "feature3" does not have any dependent branches`,
+		},
+		{
+			Desc:          "up ignores forks",
+			Direction:     navUp,
+			CurrentBranch: "feature2",
+			PullRequests:  stack,
+			Forks:         []*github.PullRequest{fork(5, "feature3", "feature2")},
+			WantCheckout:  "feature3",
+		},
+		{
+			Desc:          "down ignores forks",
+			Direction:     navDown,
+			CurrentBranch: "master",
+			PullRequests:  stack,
+			Forks:         []*github.PullRequest{fork(5, "master", "master")},
+			WantError:     `branch "master" does not have a parent branch`,
+		},
+		{
+			Desc:          "down",
+			Direction:     navDown,
+			CurrentBranch: "feature3",
+			PullRequests:  stack,
+			WantCheckout:  "feature2",
+		},
+		{
+			Desc:          "down to base",
+			Direction:     navDown,
+			CurrentBranch: "feature1",
+			PullRequests:  stack,
+			WantCheckout:  "master",
+		},
+		{
+			Desc:          "down no parent",
+			Direction:     navDown,
+			CurrentBranch: "master",
+			PullRequests:  stack,
+			WantError:     `branch "master" does not have a parent branch`,
+		},
+		{
+			Desc:          "top",
+			Direction:     navTop,
+			CurrentBranch: "feature2",
+			PullRequests:  stack,
+			WantCheckout:  "feature3",
+		},
+		{
+			Desc:          "top multiple children",
+			Direction:     navTop,
+			CurrentBranch: "master",
+			PullRequests:  stack,
+			Choose:        "feature2",
+			WantCheckout:  "feature3",
+		},
+		{
+			Desc:          "bottom",
+			Direction:     navBottom,
+			CurrentBranch: "feature3",
+			PullRequests:  stack,
+			WantCheckout:  "feature1",
+		},
+		{
+			Desc:          "bottom not in stack",
+			Direction:     navBottom,
+			CurrentBranch: "master",
+			PullRequests:  stack,
+			WantError:     `branch "master" is not part of a stack`,
+		},
+		{
+			Desc:          "bottom cycle",
+			Direction:     navBottom,
+			CurrentBranch: "feature1",
+			PullRequests: []*github.PullRequest{
+				pull(1, "feature1", "feature2"),
+				pull(2, "feature2", "feature3"),
+				pull(3, "feature3", "feature2"),
+			},
+			WantError: `branch "feature2" depends on itself`,
+		},
+		{
+			Desc:          "offline down",
+			Direction:     navDown,
+			CurrentBranch: "feature2",
+			Offline:       true,
+			LocalParents:  map[string]string{"feature2": "feature1"},
+			WantCheckout:  "feature1",
+		},
+		{
+			Desc:          "offline up",
+			Direction:     navUp,
+			CurrentBranch: "feature1",
+			Offline:       true,
+			LocalParents: map[string]string{
+				"feature1": "master",
+				"feature2": "feature1",
+			},
+			WantCheckout: "feature2",
+		},
+		{
+			Desc:          "offline bottom",
+			Direction:     navBottom,
+			CurrentBranch: "feature3",
+			Offline:       true,
+			LocalParents: map[string]string{
+				"feature1": "master",
+				"feature2": "feature1",
+				"feature3": "feature2",
+			},
+			WantCheckout: "feature1",
+		},
+		{
+			Desc:          "local branch without pull request",
+			Direction:     navDown,
+			CurrentBranch: "feature5",
+			PullRequests:  stack,
+			LocalParents:  map[string]string{"feature5": "feature3"},
+			WantCheckout:  "feature3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			git := gatewaytest.NewMockGit(mockCtrl)
+			gh := gatewaytest.NewMockGitHub(mockCtrl)
+
+			rep := resultReporter{Reporter: cli.NewTextReporter(log.New(ioutil.Discard, "", 0), ioutil.Discard)}
+			cb := &fakeConfigBuilder{
+				ConfigBuilder: clitest.ConfigBuilder{
+					Git:      git,
+					GitHub:   gh,
+					Repo:     &repo.Repo{Owner: "foo", Name: "bar"},
+					Reporter: &rep,
+				},
+			}
+			cmd := navCmd{
+				direction: tt.Direction,
+				getConfig: cb.Build,
+				chooseBranch: func(string, []string) (string, error) {
+					if tt.Choose == "" {
+						t.Fatalf("unexpected prompt")
+					}
+					return tt.Choose, nil
+				},
+			}
+
+			git.EXPECT().CurrentBranch().Return(tt.CurrentBranch, nil).AnyTimes()
+
+			if tt.Offline {
+				gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", gomock.Any()).
+					Return(nil, errors.New("great sadness")).AnyTimes()
+				gh.EXPECT().ListPullRequestsByBase(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("great sadness")).AnyTimes()
+			} else {
+				byHead := make(map[string][]*github.PullRequest)
+				byBase := make(map[string][]*github.PullRequest)
+				for _, pr := range append(tt.PullRequests, tt.Forks...) {
+					head, base := pr.Head.GetRef(), pr.Base.GetRef()
+					byHead[head] = append(byHead[head], pr)
+					byBase[base] = append(byBase[base], pr)
+				}
+				for head, prs := range byHead {
+					gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", head).
+						Return(prs, nil).AnyTimes()
+				}
+				for base, prs := range byBase {
+					gh.EXPECT().ListPullRequestsByBase(gomock.Any(), base).
+						Return(prs, nil).AnyTimes()
+				}
+				gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", gomock.Any()).
+					Return(nil, nil).AnyTimes()
+				gh.EXPECT().ListPullRequestsByBase(gomock.Any(), gomock.Any()).
+					Return(nil, nil).AnyTimes()
+				for _, pr := range tt.Forks {
+					gh.EXPECT().IsOwned(gomock.Any(), pr.Head).Return(false).AnyTimes()
+				}
+				gh.EXPECT().IsOwned(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+			}
+
+			localConfig := make(map[string]string)
+			for branch, parent := range tt.LocalParents {
+				key := "branch." + branch + ".git-pr-parent"
+				localConfig[key] = parent
+				git.EXPECT().GetConfig(key).Return(parent, nil).AnyTimes()
+			}
+			git.EXPECT().GetConfig(gomock.Any()).Return("", nil).AnyTimes()
+			git.EXPECT().ListConfig(gomock.Any()).Return(localConfig, nil).AnyTimes()
+			git.EXPECT().SetConfig(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			git.EXPECT().DoesBranchExist(gomock.Any()).Return(true).AnyTimes()
+
+			if tt.WantCheckout != "" {
+				var err error
+				if tt.CheckoutError != "" {
+					err = errors.New(tt.CheckoutError)
+				}
+				git.EXPECT().Checkout(tt.WantCheckout).Return(err)
+			}
+
+			err := cmd.Execute(nil)
+			if tt.WantError != "" {
+				assert.Error(t, err, "expected failure")
+				assert.Contains(t, err.Error(), tt.WantError)
+				assert.Empty(t, rep.Results, "result must not be reported")
+			} else {
+				assert.NoError(t, err, "command failed")
+				assert.Equal(t, []interface{}{navResult{Branch: tt.WantCheckout}}, rep.Results)
+			}
+		})
+	}
+}
+
+// resultReporter is a Reporter which keeps the results reported to it.
+type resultReporter struct {
+	cli.Reporter
+
+	Results []interface{}
+}
+
+func (r *resultReporter) Result(v interface{}) {
+	r.Results = append(r.Results, v)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// chooseBranch asks the user to pick one of the given branches, either by
+// name or by its position in the list.
+func chooseBranch(prompt string, branches []string) (string, error) {
+	// The prompt goes to stderr so that it doesn't mix with the output of
+	// the command.
+	fmt.Fprintln(os.Stderr, prompt)
+	for i, br := range branches {
+		fmt.Fprintf(os.Stderr, "  %d. %v\n", i+1, br)
+	}
+	fmt.Fprint(os.Stderr, "Branch: ")
+
+	var input string
+	if _, err := fmt.Scanln(&input); err != nil {
+		return "", err
+	}
+
+	input = strings.TrimSpace(input)
+	if i, err := strconv.Atoi(input); err == nil && i > 0 && i <= len(branches) {
+		return branches[i-1], nil
+	}
+
+	for _, br := range branches {
+		if br == input {
+			return br, nil
+		}
+	}
+
+	return "", fmt.Errorf("%q is not one of the listed branches", input)
+}
@@ -2,20 +2,19 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"log"
 
 	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/git"
 	"github.com/abhinav/git-pr/service"
 
 	"github.com/jessevdk/go-flags"
 )
 
 type rebaseCmd struct {
-	OnlyMine bool   `long:"only-mine" description:"IF set, only PRs owned by the current user will be rebased."`
-	Base     string `long:"onto" value-name:"BASE" description:"Name of the base branch. If unspecified, only the dependents of the current branch will be rebased onto it."`
+	OnlyMine bool       `long:"only-mine" description:"IF set, only PRs owned by the current user will be rebased."`
+	Base     branchName `long:"onto" value-name:"BASE" description:"Name of the base branch. If unspecified, only the dependents of the current branch will be rebased onto it."`
 	Args     struct {
-		Branch string `positional-arg-name:"BRANCH" description:"Name of the branch to rebase. Defaults to the branch in the current directory."`
+		Branch branchName `positional-arg-name:"BRANCH" description:"Name of the branch to rebase. Defaults to the branch in the current directory."`
 	} `positional-args:"yes"`
 
 	getConfig configBuilder
@@ -26,15 +25,15 @@ func newRebaseCommand(cbuild cli.ConfigBuilder) flags.Commander {
 }
 
 func (r *rebaseCmd) Execute([]string) error {
-	ctx := context.Background()
-
 	cfg, err := r.getConfig()
 	if err != nil {
 		return err
 	}
 
+	ctx := cfg.Context()
+
 	// TODO: accept other inputs for the PR to land
-	branch := r.Args.Branch
+	branch := string(r.Args.Branch)
 	if branch == "" {
 		out, err := cfg.Git().CurrentBranch()
 		if err != nil {
@@ -49,7 +48,8 @@ func (r *rebaseCmd) Execute([]string) error {
 	}
 
 	if len(prs) == 0 {
-		return fmt.Errorf("Could not find PRs with head %q", branch)
+		// The branch may be part of a stack that hasn't been published yet.
+		return r.rebaseLocal(ctx, cfg, branch)
 	}
 
 	var req service.RebaseRequest
@@ -65,16 +65,16 @@ func (r *rebaseCmd) Execute([]string) error {
 		}
 		req = service.RebaseRequest{PullRequests: dependents, Base: head}
 	} else {
-		req = service.RebaseRequest{PullRequests: prs, Base: r.Base}
+		req = service.RebaseRequest{PullRequests: prs, Base: string(r.Base)}
 	}
 
 	if r.OnlyMine {
 		req.Author = cfg.CurrentGitHubUser()
 	}
 
-	log.Println("Rebasing:")
+	cfg.Reporter().Println("Rebasing:")
 	for _, pr := range req.PullRequests {
-		log.Printf(" - %v", *pr.HTMLURL)
+		cfg.Reporter().Printf(" - %v", *pr.HTMLURL)
 	}
 
 	res, err := cfg.Service.Rebase(ctx, &req)
@@ -82,12 +82,47 @@ func (r *rebaseCmd) Execute([]string) error {
 		return err
 	}
 
-	if len(res.BranchesNotUpdated) > 0 {
-		log.Println("The following local branches were not updated because " +
-			"they did not match the corresponding remotes")
-		for _, br := range res.BranchesNotUpdated {
-			log.Println(" -", br)
+	reportBranchesNotUpdated(cfg.Reporter(), res.BranchesNotUpdated)
+	cfg.Reporter().Result(rebaseResult{
+		Rebased:            newRebasedPullRequestResults(res.RebasedPullRequests),
+		BranchesNotUpdated: res.BranchesNotUpdated,
+	})
+	return nil
+}
+
+type rebaseResult struct {
+	Rebased            []rebasedPullRequestResult `json:"rebased"`
+	BranchesNotUpdated []string                   `json:"branches_not_updated"`
+}
+
+type rebaseLocalResult struct {
+	RebasedBranches []string `json:"rebased_branches"`
+}
+
+func (r *rebaseCmd) rebaseLocal(ctx context.Context, cfg config, branch string) error {
+	if r.Base == "" {
+		children, err := git.Children(cfg.Git(), branch)
+		if err != nil {
+			return err
+		}
+
+		if len(children) == 0 {
+			return errNoPRsWithHead{Head: branch, CheckedLocal: true}
 		}
 	}
+
+	res, err := cfg.Service.RebaseLocal(ctx, &service.RebaseLocalRequest{
+		Branch: branch,
+		Base:   string(r.Base),
+	})
+	if err != nil {
+		return err
+	}
+
+	cfg.Reporter().Println("Rebased local branches:")
+	for _, br := range res.RebasedBranches {
+		cfg.Reporter().Println(" -", br)
+	}
+	cfg.Reporter().Result(rebaseLocalResult{RebasedBranches: res.RebasedBranches})
 	return nil
 }
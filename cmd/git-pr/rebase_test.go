@@ -36,9 +36,15 @@ func TestRebaseCmd(t *testing.T) {
 		PullRequestsByHead prMap
 		PullRequestsByBase prMap
 
+		// Locally recorded parents of branches.
+		LocalParents map[string]string
+
 		ExpectRebaseRequest  *service.RebaseRequest
 		ReturnRebaseResponse *service.RebaseResponse
 
+		ExpectRebaseLocalRequest  *service.RebaseLocalRequest
+		ReturnRebaseLocalResponse *service.RebaseLocalResponse
+
 		// If non-empty, an error with a message matching this will be
 		// expected
 		WantError string
@@ -183,6 +189,32 @@ func TestRebaseCmd(t *testing.T) {
 			},
 			ReturnRebaseResponse: &service.RebaseResponse{},
 		},
+		{
+			Desc:               "local stack",
+			CurrentBranch:      "feature7",
+			PullRequestsByHead: prMap{"feature7": nil},
+			LocalParents: map[string]string{
+				"feature7": "master",
+				"feature8": "feature7",
+			},
+			ExpectRebaseLocalRequest: &service.RebaseLocalRequest{Branch: "feature7"},
+			ReturnRebaseLocalResponse: &service.RebaseLocalResponse{
+				RebasedBranches: []string{"feature8"},
+			},
+		},
+		{
+			Desc:               "local branch onto base",
+			CurrentBranch:      "feature9",
+			Base:               "dev",
+			PullRequestsByHead: prMap{"feature9": nil},
+			ExpectRebaseLocalRequest: &service.RebaseLocalRequest{
+				Branch: "feature9",
+				Base:   "dev",
+			},
+			ReturnRebaseLocalResponse: &service.RebaseLocalResponse{
+				RebasedBranches: []string{"feature9"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -205,10 +237,10 @@ func TestRebaseCmd(t *testing.T) {
 			}
 			cmd := rebaseCmd{
 				getConfig: cb.Build,
-				Base:      tt.Base,
+				Base:      branchName(tt.Base),
 				OnlyMine:  tt.OnlyMine,
 			}
-			cmd.Args.Branch = tt.Head
+			cmd.Args.Branch = branchName(tt.Head)
 
 			// Always return the current branch if requested.
 			git.EXPECT().CurrentBranch().Return(tt.CurrentBranch, nil).AnyTimes()
@@ -221,10 +253,21 @@ func TestRebaseCmd(t *testing.T) {
 				github.EXPECT().ListPullRequestsByBase(gomock.Any(), base).Return(prs, nil)
 			}
 
+			localConfig := make(map[string]string)
+			for branch, parent := range tt.LocalParents {
+				localConfig["branch."+branch+".git-pr-parent"] = parent
+			}
+			git.EXPECT().ListConfig(gomock.Any()).Return(localConfig, nil).AnyTimes()
+
 			if tt.ExpectRebaseRequest != nil {
 				svc.EXPECT().Rebase(gomock.Any(), tt.ExpectRebaseRequest).Return(tt.ReturnRebaseResponse, nil)
 			}
 
+			if tt.ExpectRebaseLocalRequest != nil {
+				svc.EXPECT().RebaseLocal(gomock.Any(), tt.ExpectRebaseLocalRequest).
+					Return(tt.ReturnRebaseLocalResponse, nil)
+			}
+
 			err := cmd.Execute(nil)
 			if tt.WantError != "" {
 				assert.Error(t, err, "expected failure")
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/service"
+
+	"github.com/google/go-github/github"
+)
+
+// Types in this file describe the results of commands for --output=json.
+
+type pullRequestResult struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+	Branch string `json:"branch"`
+}
+
+func newPullRequestResult(pr *github.PullRequest) pullRequestResult {
+	return pullRequestResult{
+		Number: pr.GetNumber(),
+		URL:    pr.GetHTMLURL(),
+		Branch: pr.Head.GetRef(),
+	}
+}
+
+type rebasedPullRequestResult struct {
+	pullRequestResult
+
+	OldSHA string `json:"old_sha"`
+	NewSHA string `json:"new_sha"`
+}
+
+func newRebasedPullRequestResults(rebased []service.RebasedPullRequest) []rebasedPullRequestResult {
+	results := make([]rebasedPullRequestResult, len(rebased))
+	for i, r := range rebased {
+		results[i] = rebasedPullRequestResult{
+			pullRequestResult: newPullRequestResult(r.PullRequest),
+			OldSHA:            r.OldSHA,
+			NewSHA:            r.NewSHA,
+		}
+	}
+	return results
+}
+
+// reportBranchesNotUpdated tells the user about local branches that were
+// not updated because they did not match their remotes.
+func reportBranchesNotUpdated(r cli.Reporter, branches []string) {
+	if len(branches) == 0 {
+		return
+	}
+
+	r.Println("The following local branches were not updated because " +
+		"they did not match the corresponding remotes")
+	for _, br := range branches {
+		r.Println(" -", br)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/abhinav/git-pr/cli"
+
+	"github.com/jessevdk/go-flags"
+)
+
+type configCmd struct {
+	Args struct {
+		Name string `positional-arg-name:"NAME" description:"Name of the setting to show. Defaults to showing all settings."`
+	} `positional-args:"yes"`
+
+	getConfig configBuilder
+}
+
+func newConfigCommand(cbuild cli.ConfigBuilder) flags.Commander {
+	return &configCmd{getConfig: newConfigBuilder(cbuild)}
+}
+
+func (c *configCmd) Execute([]string) error {
+	cfg, err := c.getConfig()
+	if err != nil {
+		return err
+	}
+
+	values := cfg.Settings().All()
+	if c.Args.Name != "" {
+		values = nil
+		for _, v := range cfg.Settings().All() {
+			if v.Setting.Name == c.Args.Name {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("unknown setting %q", c.Args.Name)
+		}
+	}
+
+	w := tabwriter.NewWriter(cfg.Reporter().Writer(), 0, 4, 2, ' ', 0)
+	results := make([]settingResult, len(values))
+	for i, v := range values {
+		fmt.Fprintf(w, "%v\t%v\t(%v)\n", v.Setting.Name, v.Value, v.Source)
+		results[i] = settingResult{Name: v.Setting.Name, Value: v.Value, Source: v.Source}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	cfg.Reporter().Result(results)
+	return nil
+}
+
+type settingResult struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/cli/clitest"
+	"github.com/abhinav/git-pr/settings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCmd(t *testing.T) {
+	s := settings.New()
+	require.NoError(t, s.Set("remote", "upstream", "/repo/.git-pr.yaml"))
+	require.NoError(t, s.Set("lint.bodyWidth", "80", "git-config"))
+
+	run := func(name string) (string, error) {
+		var out bytes.Buffer
+		cb := &fakeConfigBuilder{
+			ConfigBuilder: clitest.ConfigBuilder{
+				Settings: s,
+				Reporter: cli.NewTextReporter(log.New(ioutil.Discard, "", 0), &out),
+			},
+		}
+
+		cmd := configCmd{getConfig: cb.Build}
+		cmd.Args.Name = name
+		err := cmd.Execute(nil)
+		return out.String(), err
+	}
+
+	t.Run("all", func(t *testing.T) {
+		out, err := run("")
+		require.NoError(t, err)
+		assert.Contains(t, out, "remote                    upstream  (/repo/.git-pr.yaml)\n")
+		assert.Contains(t, out, "lint.bodyWidth            80        (git-config)\n")
+		assert.Contains(t, out, "base                      master    (default)\n")
+	})
+
+	t.Run("one", func(t *testing.T) {
+		out, err := run("remote")
+		require.NoError(t, err)
+		assert.Equal(t, "remote  upstream  (/repo/.git-pr.yaml)\n", out)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := run("remotes")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown setting "remotes"`)
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"github.com/abhinav/git-pr/cli"
+	"github.com/abhinav/git-pr/service"
+
+	"github.com/jessevdk/go-flags"
+)
+
+type syncCmd struct {
+	Base branchName `long:"base" value-name:"BASE" description:"Name of the branch into which pull requests are merged. Defaults to the base setting."`
+
+	getConfig configBuilder
+}
+
+func newSyncCommand(cbuild cli.ConfigBuilder) flags.Commander {
+	return &syncCmd{getConfig: newConfigBuilder(cbuild)}
+}
+
+func (s *syncCmd) Execute([]string) error {
+	cfg, err := s.getConfig()
+	if err != nil {
+		return err
+	}
+
+	ctx := cfg.Context()
+	if s.Base == "" {
+		s.Base = branchName(cfg.Settings().String("base"))
+	}
+
+	res, err := cfg.Service.Sync(ctx, &service.SyncRequest{
+		Base:   string(s.Base),
+		Author: cfg.CurrentGitHubUser(),
+	})
+	if err != nil {
+		return err
+	}
+
+	r := cfg.Reporter()
+	if len(res.DeletedBranches) > 0 {
+		r.Println("Deleted the following branches because their PRs were " +
+			"merged or closed:")
+		for _, br := range res.DeletedBranches {
+			r.Println(" -", br)
+		}
+	}
+
+	if len(res.BranchesNotDeleted) > 0 {
+		r.Println("The following local branches were not deleted because " +
+			"they have changes that are not part of their closed PRs:")
+		for _, br := range res.BranchesNotDeleted {
+			r.Println(" -", br)
+		}
+	}
+
+	if len(res.RebasedPullRequests) > 0 {
+		r.Println("Rebased:")
+		for _, pr := range res.RebasedPullRequests {
+			r.Println(" -", pr.PullRequest.GetHTMLURL())
+		}
+	}
+
+	reportBranchesNotUpdated(r, res.BranchesNotUpdated)
+
+	if len(res.DeletedBranches) == 0 && len(res.RebasedPullRequests) == 0 {
+		r.Println("Everything is up to date.")
+	}
+
+	r.Result(syncResult{
+		DeletedBranches:    res.DeletedBranches,
+		BranchesNotDeleted: res.BranchesNotDeleted,
+		Rebased:            newRebasedPullRequestResults(res.RebasedPullRequests),
+		BranchesNotUpdated: res.BranchesNotUpdated,
+	})
+	return nil
+}
+
+type syncResult struct {
+	DeletedBranches    []string                   `json:"deleted_branches"`
+	BranchesNotDeleted []string                   `json:"branches_not_deleted"`
+	Rebased            []rebasedPullRequestResult `json:"rebased"`
+	BranchesNotUpdated []string                   `json:"branches_not_updated"`
+}
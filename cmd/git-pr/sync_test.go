@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abhinav/git-pr/cli/clitest"
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/repo"
+	"github.com/abhinav/git-pr/service"
+	"github.com/abhinav/git-pr/service/servicetest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncCmd(t *testing.T) {
+	tests := []struct {
+		Desc string
+
+		Base       string
+		GitHubUser string
+
+		ExpectSyncRequest  *service.SyncRequest
+		ReturnSyncResponse *service.SyncResponse
+		ReturnSyncError    error
+
+		// If non-empty, an error with a message matching this will be
+		// expected
+		WantError string
+	}{
+		{
+			Desc:               "default",
+			Base:               "master",
+			GitHubUser:         "foo",
+			ExpectSyncRequest:  &service.SyncRequest{Base: "master", Author: "foo"},
+			ReturnSyncResponse: &service.SyncResponse{},
+		},
+		{
+			Desc:              "custom base",
+			Base:              "dev",
+			GitHubUser:        "bar",
+			ExpectSyncRequest: &service.SyncRequest{Base: "dev", Author: "bar"},
+			ReturnSyncResponse: &service.SyncResponse{
+				DeletedBranches:    []string{"feature1"},
+				BranchesNotDeleted: []string{"feature2"},
+				BranchesNotUpdated: []string{"feature3"},
+			},
+		},
+		{
+			Desc:              "sync error",
+			Base:              "master",
+			ExpectSyncRequest: &service.SyncRequest{Base: "master"},
+			ReturnSyncError:   errors.New("great sadness"),
+			WantError:         "great sadness",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			svc := servicetest.NewMockPR(mockCtrl)
+			cb := &fakeConfigBuilder{
+				ConfigBuilder: clitest.ConfigBuilder{
+					Git:        gatewaytest.NewMockGit(mockCtrl),
+					GitHub:     gatewaytest.NewMockGitHub(mockCtrl),
+					Repo:       &repo.Repo{Owner: "foo", Name: "bar"},
+					GitHubUser: tt.GitHubUser,
+				},
+				Service: svc,
+			}
+			cmd := syncCmd{getConfig: cb.Build, Base: branchName(tt.Base)}
+
+			svc.EXPECT().Sync(gomock.Any(), tt.ExpectSyncRequest).
+				Return(tt.ReturnSyncResponse, tt.ReturnSyncError)
+
+			err := cmd.Execute(nil)
+			if tt.WantError != "" {
+				assert.Error(t, err, "expected failure")
+				assert.Contains(t, err.Error(), tt.WantError)
+			} else {
+				assert.NoError(t, err, "command sync failed")
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package credentials
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const _defaultAPIURL = "https://api.github.com/"
+
+// AppConfig identifies an installation of a GitHub App.
+type AppConfig struct {
+	AppID          int64
+	InstallationID int64
+	PrivateKey     *rsa.PrivateKey
+
+	// Base URL of the GitHub API. Defaults to https://api.github.com/.
+	BaseURL string
+
+	// HTTP client used to request installation tokens. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (cfg *AppConfig) now() time.Time {
+	if cfg.Now == nil {
+		return time.Now()
+	}
+	return cfg.Now()
+}
+
+func (cfg *AppConfig) url(path string) string {
+	base := cfg.BaseURL
+	if base == "" {
+		base = _defaultAPIURL
+	}
+	return strings.TrimSuffix(base, "/") + path
+}
+
+// ParsePrivateKey parses a PEM-encoded RSA private key like the ones GitHub
+// generates for GitHub Apps.
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("private key is not PEM-encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// ReadPrivateKey reads a PEM-encoded RSA private key from a file.
+func ReadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %v", err)
+	}
+
+	key, err := ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return key, nil
+}
+
+// JWT builds a JSON Web Token that authenticates as the GitHub App. GitHub
+// accepts these for up to ten minutes.
+func (cfg *AppConfig) JWT() (string, error) {
+	// Backdate the token in case our clock is ahead of GitHub's.
+	now := cfg.now().Add(-time.Minute)
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": cfg.AppID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, cfg.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %v", err)
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+// do makes a request to GitHub authenticated as the GitHub App and decodes
+// the JSON response into v.
+func (cfg *AppConfig) do(method, path string, v interface{}) error {
+	jwt, err := cfg.JWT()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(method, cfg.url(path), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.machine-man-preview+json")
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode/100 != 2 {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &msg) == nil && msg.Message != "" {
+			return fmt.Errorf("%v %v: %v %v", method, req.URL, res.StatusCode, msg.Message)
+		}
+		return fmt.Errorf("%v %v: %v", method, req.URL, res.StatusCode)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// AppLogin returns the login used by GitHub for actions taken by the GitHub
+// App.
+func AppLogin(cfg AppConfig) (string, error) {
+	var app struct {
+		Slug string `json:"slug"`
+	}
+	if err := cfg.do("GET", "/app", &app); err != nil {
+		return "", fmt.Errorf("failed to look up GitHub App %v: %v", cfg.AppID, err)
+	}
+	return app.Slug + "[bot]", nil
+}
+
+// NewAppTokenSource builds an oauth2.TokenSource which provides installation
+// access tokens for a GitHub App. New tokens are requested when the old ones
+// expire.
+func NewAppTokenSource(cfg AppConfig) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &appTokenSource{cfg: cfg})
+}
+
+type appTokenSource struct{ cfg AppConfig }
+
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	var res struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	path := fmt.Sprintf("/app/installations/%v/access_tokens", s.cfg.InstallationID)
+	if err := s.cfg.do("POST", path, &res); err != nil {
+		return nil, fmt.Errorf(
+			"failed to get token for installation %v of GitHub App %v: %v",
+			s.cfg.InstallationID, s.cfg.AppID, err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: res.Token,
+		Expiry:      res.ExpiresAt,
+	}, nil
+}
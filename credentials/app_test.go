@@ -0,0 +1,177 @@
+package credentials
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	return key
+}
+
+// verifyJWT checks the signature of a JWT and returns its claims.
+func verifyJWT(t *testing.T, key *rsa.PublicKey, jwt string) map[string]int64 {
+	parts := strings.Split(jwt, ".")
+	require.Len(t, parts, 3)
+
+	enc := base64.RawURLEncoding
+	sig, err := enc.DecodeString(parts[2])
+	require.NoError(t, err)
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(t, rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig))
+
+	var header map[string]string
+	data, err := enc.DecodeString(parts[0])
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &header))
+	assert.Equal(t, map[string]string{"alg": "RS256", "typ": "JWT"}, header)
+
+	var claims map[string]int64
+	data, err = enc.DecodeString(parts[1])
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &claims))
+	return claims
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key := newTestKey(t)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	tests := []struct {
+		Desc      string
+		Give      []byte
+		WantError string
+	}{
+		{
+			Desc: "PKCS1",
+			Give: pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(key),
+			}),
+		},
+		{
+			Desc: "PKCS8",
+			Give: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+		},
+		{
+			Desc:      "not PEM",
+			Give:      []byte("foo"),
+			WantError: "private key is not PEM-encoded",
+		},
+		{
+			Desc:      "not a key",
+			Give:      pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("foo")}),
+			WantError: "failed to parse private key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			got, err := ParsePrivateKey(tt.Give)
+			if tt.WantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.WantError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, key.D, got.D)
+		})
+	}
+}
+
+func TestAppJWT(t *testing.T) {
+	key := newTestKey(t)
+	now := time.Date(2017, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := AppConfig{
+		AppID:      42,
+		PrivateKey: key,
+		Now:        func() time.Time { return now },
+	}
+	jwt, err := cfg.JWT()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(8 * time.Minute).Unix(),
+		"iss": 42,
+	}, verifyJWT(t, &key.PublicKey, jwt))
+}
+
+func TestAppTokenSource(t *testing.T) {
+	key := newTestKey(t)
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Bearer "))
+		claims := verifyJWT(t, &key.PublicKey, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		assert.Equal(t, int64(42), claims["iss"])
+
+		switch r.URL.Path {
+		case "/app":
+			assert.Equal(t, "GET", r.Method)
+			fmt.Fprint(w, `{"id": 42, "slug": "stackbot"}`)
+		case "/app/installations/123/access_tokens":
+			assert.Equal(t, "POST", r.Method)
+			// Tokens that expire right away are requested again each time.
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "ghs_%v", "expires_at": %q}`,
+				requests, time.Now().Add(time.Second).UTC().Format(time.RFC3339))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	}))
+	defer server.Close()
+
+	cfg := AppConfig{
+		AppID:          42,
+		InstallationID: 123,
+		PrivateKey:     key,
+		BaseURL:        server.URL + "/",
+	}
+
+	login, err := AppLogin(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "stackbot[bot]", login)
+
+	ts := NewAppTokenSource(cfg)
+	token, err := ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "ghs_2", token.AccessToken)
+
+	token, err = ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "ghs_3", token.AccessToken, "expired token must be refreshed")
+
+	t.Run("bad installation", func(t *testing.T) {
+		cfg := cfg
+		cfg.InstallationID = 456
+		_, err := NewAppTokenSource(cfg).Token()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get token for installation 456 of GitHub App 42")
+		assert.Contains(t, err.Error(), "404 Not Found")
+	})
+}
@@ -0,0 +1,77 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// RequiredScopes are the scopes a classic GitHub token needs to manage pull
+// requests and delete branches.
+var RequiredScopes = []string{"repo"}
+
+// UsersService is a subset of the GitHub Users API.
+type UsersService interface {
+	Get(ctx context.Context, user string) (*github.User, *github.Response, error)
+}
+
+var _ UsersService = (*github.UsersService)(nil)
+
+// Info describes the owner of a GitHub token.
+type Info struct {
+	Login string
+
+	// OAuth scopes granted to the token. This is nil for tokens that don't
+	// use OAuth scopes, like fine-grained personal access tokens.
+	Scopes []string
+}
+
+// Check verifies that the token used by the given client is valid and
+// has the scopes we need.
+func Check(ctx context.Context, users UsersService) (*Info, error) {
+	user, res, err := users.Get(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify GitHub token: %v", err)
+	}
+
+	info := Info{Login: user.GetLogin()}
+	if res != nil && res.Response != nil {
+		if header, ok := res.Header["X-Oauth-Scopes"]; ok {
+			info.Scopes = parseScopes(strings.Join(header, ","))
+			if missing := missingScopes(info.Scopes); len(missing) > 0 {
+				return nil, fmt.Errorf(
+					"GitHub token for %v is missing required scopes: %v",
+					info.Login, strings.Join(missing, ", "))
+			}
+		}
+	}
+
+	return &info, nil
+}
+
+func parseScopes(s string) []string {
+	scopes := make([]string, 0)
+	for _, scope := range strings.Split(s, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func missingScopes(scopes []string) []string {
+	has := make(map[string]struct{}, len(scopes))
+	for _, s := range scopes {
+		has[s] = struct{}{}
+	}
+
+	var missing []string
+	for _, s := range RequiredScopes {
+		if _, ok := has[s]; !ok {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,87 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		Desc   string
+		Status int
+		Scopes *string // X-OAuth-Scopes header; omitted if nil
+
+		Want      *Info
+		WantError string
+	}{
+		{
+			Desc:   "classic token",
+			Status: http.StatusOK,
+			Scopes: github.String("repo, read:org"),
+			Want:   &Info{Login: "abhinav", Scopes: []string{"repo", "read:org"}},
+		},
+		{
+			Desc:   "fine-grained token",
+			Status: http.StatusOK,
+			Want:   &Info{Login: "abhinav"},
+		},
+		{
+			Desc:      "missing scopes",
+			Status:    http.StatusOK,
+			Scopes:    github.String("public_repo"),
+			WantError: "GitHub token for abhinav is missing required scopes: repo",
+		},
+		{
+			Desc:      "no scopes",
+			Status:    http.StatusOK,
+			Scopes:    github.String(""),
+			WantError: "missing required scopes: repo",
+		},
+		{
+			Desc:      "bad token",
+			Status:    http.StatusUnauthorized,
+			WantError: "failed to verify GitHub token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/user", r.URL.Path)
+				if tt.Scopes != nil {
+					w.Header().Set("X-OAuth-Scopes", *tt.Scopes)
+				}
+				w.WriteHeader(tt.Status)
+				if tt.Status == http.StatusOK {
+					fmt.Fprint(w, `{"login": "abhinav"}`)
+				} else {
+					fmt.Fprint(w, `{"message": "Bad credentials"}`)
+				}
+			}))
+			defer server.Close()
+
+			client := github.NewClient(nil)
+			baseURL, err := url.Parse(server.URL + "/")
+			require.NoError(t, err)
+			client.BaseURL = baseURL
+
+			got, err := Check(context.Background(), client.Users)
+			if tt.WantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.WantError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.Want, got)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package credentials
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	_saltSize  = 16
+	_nonceSize = 24
+	_keySize   = 32
+)
+
+// PassphraseFunc returns the passphrase used to encrypt the credentials
+// file.
+type PassphraseFunc func() (string, error)
+
+// DefaultFile returns the path to the encrypted credentials file for the
+// current user.
+func DefaultFile() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(dir, "git-pr", "credentials")
+}
+
+// NewFile builds a Store which keeps tokens in a file encrypted with a key
+// derived from a passphrase. The passphrase is requested only when the file
+// is first read or written.
+func NewFile(path string, passphrase PassphraseFunc) Store {
+	f := fileStore{path: path, getPassphrase: passphrase}
+	return &tokenStore{name: path, load: f.load, save: f.save}
+}
+
+type fileStore struct {
+	path          string
+	getPassphrase PassphraseFunc
+	passphrase    *string
+}
+
+func (f *fileStore) key(salt []byte) (*[_keySize]byte, error) {
+	if f.passphrase == nil {
+		p, err := f.getPassphrase()
+		if err != nil {
+			return nil, err
+		}
+		if p == "" {
+			return nil, errors.New("passphrase cannot be blank")
+		}
+		f.passphrase = &p
+	}
+
+	k, err := scrypt.Key([]byte(*f.passphrase), salt, 1<<15, 8, 1, _keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	var key [_keySize]byte
+	copy(key[:], k)
+	return &key, nil
+}
+
+// The file holds the salt used to derive the key, followed by the nonce and
+// the encrypted JSON.
+func (f *fileStore) load() (tokens, error) {
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(tokens), nil
+		}
+		return nil, fmt.Errorf("failed to read %v: %v", f.path, err)
+	}
+
+	if len(data) < _saltSize+_nonceSize {
+		return nil, fmt.Errorf("failed to read %v: file is too short", f.path)
+	}
+
+	salt, data := data[:_saltSize], data[_saltSize:]
+	var nonce [_nonceSize]byte
+	copy(nonce[:], data[:_nonceSize])
+	data = data[_nonceSize:]
+
+	key, err := f.key(salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %v: %v", f.path, err)
+	}
+
+	plain, ok := secretbox.Open(nil, data, &nonce, key)
+	if !ok {
+		return nil, fmt.Errorf("failed to decrypt %v: wrong passphrase", f.path)
+	}
+
+	ts, err := decodeTokens(plain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode tokens in %v: %v", f.path, err)
+	}
+	return ts, nil
+}
+
+func (f *fileStore) save(ts tokens) error {
+	plain, err := json.Marshal(ts)
+	if err != nil {
+		return err
+	}
+
+	out := make([]byte, _saltSize+_nonceSize)
+	if _, err := io.ReadFull(rand.Reader, out); err != nil {
+		return fmt.Errorf("failed to encrypt %v: %v", f.path, err)
+	}
+
+	var nonce [_nonceSize]byte
+	copy(nonce[:], out[_saltSize:])
+
+	key, err := f.key(out[:_saltSize])
+	if err != nil {
+		return fmt.Errorf("failed to encrypt %v: %v", f.path, err)
+	}
+	out = secretbox.Seal(out, plain, &nonce, key)
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return fmt.Errorf("failed to write %v: %v", f.path, err)
+	}
+	if err := ioutil.WriteFile(f.path, out, 0600); err != nil {
+		return fmt.Errorf("failed to write %v: %v", f.path, err)
+	}
+	return nil
+}
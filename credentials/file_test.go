@@ -0,0 +1,69 @@
+package credentials
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-pr")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "git-pr", "credentials")
+	passphrase := func(p string) PassphraseFunc {
+		return func() (string, error) { return p, nil }
+	}
+
+	s := NewFile(path, func() (string, error) {
+		return "", errors.New("passphrase should not be needed if the file doesn't exist")
+	})
+	hosts, err := s.Hosts()
+	require.NoError(t, err)
+	assert.Empty(t, hosts)
+
+	s = NewFile(path, passphrase("hunter2"))
+	assert.Equal(t, path, s.String())
+	require.NoError(t, s.Set("github.com", "gho_1234"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(contents), "gho_1234")
+
+	t.Run("same passphrase", func(t *testing.T) {
+		token, err := NewFile(path, passphrase("hunter2")).Get("github.com")
+		require.NoError(t, err)
+		assert.Equal(t, "gho_1234", token)
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		_, err := NewFile(path, passphrase("hunter3")).Get("github.com")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "wrong passphrase")
+	})
+
+	t.Run("blank passphrase", func(t *testing.T) {
+		_, err := NewFile(path, passphrase("")).Get("github.com")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "passphrase cannot be blank")
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		corrupt := filepath.Join(dir, "corrupt")
+		require.NoError(t, ioutil.WriteFile(corrupt, []byte("foo"), 0600))
+
+		_, err := NewFile(corrupt, passphrase("hunter2")).Get("github.com")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file is too short")
+	})
+}
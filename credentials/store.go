@@ -0,0 +1,202 @@
+// Package credentials stores GitHub tokens for one or more hosts.
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/zalando/go-keyring"
+)
+
+// ErrNotFound is returned if no token is stored for a host.
+var ErrNotFound = errors.New("no token stored for host")
+
+// Store stores GitHub tokens by hostname.
+type Store interface {
+	// Get retrieves the token for the given host or ErrNotFound.
+	Get(host string) (string, error)
+
+	// Set stores the token for the given host, replacing the old token if
+	// any.
+	Set(host, token string) error
+
+	// Delete deletes the token for the given host or returns ErrNotFound.
+	Delete(host string) error
+
+	// Hosts lists hosts with stored tokens in sorted order.
+	Hosts() ([]string, error)
+
+	// String describes where tokens are stored.
+	String() string
+}
+
+// tokens maps hostnames to tokens. Both stores save all tokens together so
+// that hosts can be listed.
+type tokens map[string]string
+
+func decodeTokens(data []byte) (tokens, error) {
+	ts := make(tokens)
+	if len(data) == 0 {
+		return ts, nil
+	}
+	if err := json.Unmarshal(data, &ts); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+func (ts tokens) Hosts() []string {
+	hosts := make([]string, 0, len(ts))
+	for h := range ts {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// tokenStore implements Store on top of functions to load and save all
+// tokens at once.
+type tokenStore struct {
+	name string
+	load func() (tokens, error)
+	save func(tokens) error
+}
+
+func (s *tokenStore) String() string { return s.name }
+
+func (s *tokenStore) Get(host string) (string, error) {
+	ts, err := s.load()
+	if err != nil {
+		return "", err
+	}
+
+	token, ok := ts[host]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return token, nil
+}
+
+func (s *tokenStore) Set(host, token string) error {
+	ts, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	ts[host] = token
+	return s.save(ts)
+}
+
+func (s *tokenStore) Delete(host string) error {
+	ts, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ts[host]; !ok {
+		return ErrNotFound
+	}
+	delete(ts, host)
+	return s.save(ts)
+}
+
+func (s *tokenStore) Hosts() ([]string, error) {
+	ts, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return ts.Hosts(), nil
+}
+
+// Name of the keyring item holding tokens for all hosts.
+const _keyringUser = "hosts"
+
+// NewKeyring builds a Store which keeps tokens in the OS keyring under the
+// given service name.
+func NewKeyring(service string) Store {
+	return &tokenStore{
+		name: "keyring",
+		load: func() (tokens, error) {
+			data, err := keyring.Get(service, _keyringUser)
+			switch err {
+			case nil:
+			case keyring.ErrNotFound:
+				return make(tokens), nil
+			default:
+				return nil, fmt.Errorf("failed to read keyring: %v", err)
+			}
+
+			ts, err := decodeTokens([]byte(data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode tokens in keyring: %v", err)
+			}
+			return ts, nil
+		},
+		save: func(ts tokens) error {
+			var err error
+			if len(ts) == 0 {
+				err = keyring.Delete(service, _keyringUser)
+			} else {
+				var data []byte
+				data, err = json.Marshal(ts)
+				if err == nil {
+					err = keyring.Set(service, _keyringUser, string(data))
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write keyring: %v", err)
+			}
+			return nil
+		},
+	}
+}
+
+// NewFallback builds a Store which uses primary if it's available and
+// secondary otherwise. This lets tokens be stored in a file on machines
+// without a keyring daemon.
+func NewFallback(primary, secondary Store) Store {
+	return &fallbackStore{primary: primary, secondary: secondary}
+}
+
+type fallbackStore struct {
+	primary, secondary Store
+	active             Store
+}
+
+func (s *fallbackStore) store() Store {
+	if s.active == nil {
+		s.active = s.primary
+		if _, err := s.primary.Hosts(); err != nil {
+			s.active = s.secondary
+		}
+	}
+	return s.active
+}
+
+func (s *fallbackStore) Get(host string) (string, error) { return s.store().Get(host) }
+func (s *fallbackStore) Set(host, token string) error    { return s.store().Set(host, token) }
+func (s *fallbackStore) Delete(host string) error        { return s.store().Delete(host) }
+func (s *fallbackStore) Hosts() ([]string, error)        { return s.store().Hosts() }
+func (s *fallbackStore) String() string                  { return s.store().String() }
+
+// NewMemory builds a Store which keeps tokens in memory. Tokens are lost when
+// the program exits.
+func NewMemory() Store {
+	ts := make(tokens)
+	return &tokenStore{
+		name: "memory",
+		load: func() (tokens, error) {
+			copied := make(tokens, len(ts))
+			for h, t := range ts {
+				copied[h] = t
+			}
+			return copied, nil
+		},
+		save: func(newTokens tokens) error {
+			ts = newTokens
+			return nil
+		},
+	}
+}
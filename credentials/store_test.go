@@ -0,0 +1,71 @@
+package credentials
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	s := NewMemory()
+	assert.Equal(t, "memory", s.String())
+
+	hosts, err := s.Hosts()
+	require.NoError(t, err)
+	assert.Empty(t, hosts)
+
+	_, err = s.Get("github.com")
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, ErrNotFound, s.Delete("github.com"))
+
+	require.NoError(t, s.Set("github.com", "foo"))
+	require.NoError(t, s.Set("github.example.com", "bar"))
+	require.NoError(t, s.Set("github.com", "baz"))
+
+	hosts, err = s.Hosts()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github.com", "github.example.com"}, hosts)
+
+	token, err := s.Get("github.com")
+	require.NoError(t, err)
+	assert.Equal(t, "baz", token)
+
+	require.NoError(t, s.Delete("github.com"))
+	hosts, err = s.Hosts()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github.example.com"}, hosts)
+}
+
+func TestFallback(t *testing.T) {
+	unavailable := &tokenStore{
+		name: "broken",
+		load: func() (tokens, error) { return nil, errors.New("no keyring daemon") },
+		save: func(tokens) error { return errors.New("no keyring daemon") },
+	}
+
+	t.Run("primary available", func(t *testing.T) {
+		primary, secondary := NewMemory(), NewMemory()
+		s := NewFallback(primary, secondary)
+		require.NoError(t, s.Set("github.com", "foo"))
+
+		token, err := primary.Get("github.com")
+		require.NoError(t, err)
+		assert.Equal(t, "foo", token)
+
+		_, err = secondary.Get("github.com")
+		assert.Equal(t, ErrNotFound, err)
+	})
+
+	t.Run("primary unavailable", func(t *testing.T) {
+		secondary := NewMemory()
+		s := NewFallback(unavailable, secondary)
+		require.NoError(t, s.Set("github.com", "foo"))
+		assert.Equal(t, "memory", s.String())
+
+		token, err := secondary.Get("github.com")
+		require.NoError(t, err)
+		assert.Equal(t, "foo", token)
+	})
+}
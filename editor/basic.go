@@ -12,16 +12,18 @@ import (
 type Basic struct {
 	name string
 	path string
+	args []string
 }
 
-// NewBasic builds a new basic editor.
-func NewBasic(name string) (*Basic, error) {
+// NewBasic builds a new basic editor. The given arguments are passed to the
+// editor before the name of the file.
+func NewBasic(name string, args ...string) (*Basic, error) {
 	path, err := exec.LookPath(name)
 	if err != nil {
 		return nil, fmt.Errorf("could not resolve editor %q: %v", name, err)
 	}
 
-	return &Basic{name: name, path: path}, nil
+	return &Basic{name: name, path: path, args: args}, nil
 }
 
 // Name returns the name of the editor.
@@ -37,7 +39,7 @@ func (e *Basic) EditString(s string) (string, error) {
 	}
 	defer os.Remove(file)
 
-	cmd := exec.Command(e.path, file)
+	cmd := exec.Command(e.path, append(append([]string(nil), e.args...), file)...)
 	cmd.Stdout = os.Stdout
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
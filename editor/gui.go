@@ -0,0 +1,117 @@
+package editor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// GUI is an editor like VS Code, Sublime Text, or Emacs that edits files in
+// a window or buffer which may be closed without exiting the editor.
+//
+// The editor is told to wait until the file is closed. If the user closes the
+// file without saving it, ErrFileUnsaved is returned like it is for vi-like
+// editors.
+type GUI struct {
+	name string
+	path string
+	args []string
+}
+
+// NewGUI builds a new GUI editor. The given arguments are passed to the
+// editor before the name of the file. Arguments needed to make the editor
+// wait for the file to be closed are added if they're missing.
+func NewGUI(name string, args ...string) (*GUI, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve editor %q: %v", name, err)
+	}
+
+	return &GUI{name: name, path: path, args: waitArgs(name, args)}, nil
+}
+
+// waitArgs returns the arguments for the given editor with the flags needed
+// to make it wait for the file to be closed.
+func waitArgs(name string, args []string) []string {
+	has := func(flags ...string) bool {
+		for _, arg := range args {
+			for _, f := range flags {
+				if arg == f {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	switch filepath.Base(name) {
+	case "code", "code-insiders", "codium", "subl", "sublime_text":
+		if !has("-w", "--wait") {
+			args = append(append([]string(nil), args...), "--wait")
+		}
+	case "gvim", "mvim":
+		// GUI versions of vim fork into the background unless told not to.
+		if !has("-f", "--nofork") {
+			args = append(append([]string(nil), args...), "-f")
+		}
+	case "emacsclient":
+		// emacsclient returns immediately with --no-wait.
+		var newArgs []string
+		for _, arg := range args {
+			if arg != "-n" && arg != "--no-wait" {
+				newArgs = append(newArgs, arg)
+			}
+		}
+		args = newArgs
+	}
+	return args
+}
+
+// Name of the editor.
+func (e *GUI) Name() string {
+	return e.name
+}
+
+// EditString asks the user to edit the given string inside the editor.
+// ErrFileUnsaved is returned if the user closes the file without saving it.
+func (e *GUI) EditString(s string) (string, error) {
+	file, err := tempFileWithContents(s)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file)
+
+	// Saving the file will change its modification time to the current
+	// time. Move it into the past so that saves can't be missed, even if
+	// they happen right away.
+	before := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(file, before, before); err != nil {
+		return "", fmt.Errorf("could not prepare temporary file: %v", err)
+	}
+
+	cmd := exec.Command(e.path, append(append([]string(nil), e.args...), file)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %v", e.name, err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", fmt.Errorf("could not read temporary file: %v", err)
+	}
+	if info.ModTime().Equal(before) {
+		return "", ErrFileUnsaved
+	}
+
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("could not read temporary file: %v", err)
+	}
+
+	return string(contents), nil
+}
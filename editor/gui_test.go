@@ -0,0 +1,62 @@
+package editor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGUI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-pr")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Fake editors. The file to edit is the last argument.
+	script := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755))
+		return path
+	}
+
+	t.Run("saved", func(t *testing.T) {
+		ed, err := NewGUI(script("save", `for f; do :; done; echo "edited" > "$f"`))
+		require.NoError(t, err)
+
+		got, err := ed.EditString("original")
+		require.NoError(t, err)
+		assert.Equal(t, "edited\n", got)
+	})
+
+	t.Run("saved unchanged", func(t *testing.T) {
+		ed, err := NewGUI(script("touch", `for f; do :; done; touch "$f"`))
+		require.NoError(t, err)
+
+		got, err := ed.EditString("original")
+		require.NoError(t, err)
+		assert.Equal(t, "original", got)
+	})
+
+	t.Run("closed without saving", func(t *testing.T) {
+		ed, err := NewGUI(script("close", `exit 0`))
+		require.NoError(t, err)
+
+		_, err = ed.EditString("original")
+		assert.Equal(t, ErrFileUnsaved, err)
+	})
+
+	t.Run("arguments", func(t *testing.T) {
+		ed, err := Pick(script("args", `echo "$@" > "`+filepath.Join(dir, "args.out")+`"; for f; do :; done; touch "$f"`) + " -a 'b c'")
+		require.NoError(t, err)
+
+		_, err = ed.EditString("original")
+		require.NoError(t, err)
+
+		args, err := ioutil.ReadFile(filepath.Join(dir, "args.out"))
+		require.NoError(t, err)
+		assert.Contains(t, string(args), "-a b c ")
+	})
+}
@@ -1,11 +1,96 @@
 package editor
 
-// Pick an editor based on the given name.
-func Pick(name string) (Editor, error) {
-	switch name {
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"unicode"
+)
+
+// Pick an editor based on the given command. The command may include
+// arguments for the editor, for example, "code --wait" or "emacsclient -t".
+func Pick(command string) (Editor, error) {
+	args, err := splitCommand(command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid editor %q: %v", command, err)
+	}
+	if len(args) == 0 {
+		return nil, errors.New("no editor specified")
+	}
+
+	name, args := args[0], args[1:]
+	switch filepath.Base(name) {
 	case "vi", "vim", "nvim", "mvim", "gvim", "elvis":
-		return NewViLike(name)
+		return NewViLike(name, args...)
+	case "code", "code-insiders", "codium", "subl", "sublime_text", "emacs", "emacsclient":
+		return NewGUI(name, args...)
 	default:
-		return NewBasic(name)
+		return NewBasic(name, args...)
+	}
+}
+
+// Resolve determines the editor command to use the same way git does. The
+// first one of the following that is set is used.
+//
+// 	$GIT_EDITOR
+// 	core.editor in git-config, given as coreEditor
+// 	$VISUAL, unless the terminal is dumb
+// 	$EDITOR
+// 	vi
+func Resolve(getenv func(string) string, coreEditor string) string {
+	if e := getenv("GIT_EDITOR"); e != "" {
+		return e
+	}
+	if coreEditor != "" {
+		return coreEditor
+	}
+	if e := getenv("VISUAL"); e != "" && getenv("TERM") != "dumb" {
+		return e
+	}
+	if e := getenv("EDITOR"); e != "" {
+		return e
+	}
+	return "vi"
+}
+
+// splitCommand splits a command into its arguments. Arguments are separated
+// by whitespace and may be quoted with single or double quotes.
+func splitCommand(command string) ([]string, error) {
+	var (
+		args    []string
+		current bytes.Buffer
+		inArg   bool
+		quote   rune
+	)
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
 	}
+	return args, nil
 }
@@ -0,0 +1,113 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		Give      string
+		Want      []string
+		WantError string
+	}{
+		{Give: "", Want: nil},
+		{Give: "vim", Want: []string{"vim"}},
+		{Give: "  code   --wait ", Want: []string{"code", "--wait"}},
+		{Give: `emacsclient -t -a ""`, Want: []string{"emacsclient", "-t", "-a", ""}},
+		{
+			Give: `"/Applications/Sublime Text.app/subl" -w`,
+			Want: []string{"/Applications/Sublime Text.app/subl", "-w"},
+		},
+		{Give: `vim -c 'set tw=72'`, Want: []string{"vim", "-c", "set tw=72"}},
+		{Give: `vim "foo`, WantError: "unterminated \" quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Give, func(t *testing.T) {
+			got, err := splitCommand(tt.Give)
+			if tt.WantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.WantError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.Want, got)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		Desc       string
+		Env        map[string]string
+		CoreEditor string
+		Want       string
+	}{
+		{Desc: "default", Want: "vi"},
+		{
+			Desc: "GIT_EDITOR",
+			Env: map[string]string{
+				"GIT_EDITOR": "nano",
+				"VISUAL":     "code --wait",
+				"EDITOR":     "vim",
+			},
+			CoreEditor: "emacs",
+			Want:       "nano",
+		},
+		{
+			Desc:       "core.editor",
+			Env:        map[string]string{"VISUAL": "code --wait", "EDITOR": "vim"},
+			CoreEditor: "emacs",
+			Want:       "emacs",
+		},
+		{
+			Desc: "VISUAL",
+			Env:  map[string]string{"VISUAL": "code --wait", "EDITOR": "vim"},
+			Want: "code --wait",
+		},
+		{
+			Desc: "VISUAL on dumb terminal",
+			Env:  map[string]string{"VISUAL": "code --wait", "EDITOR": "vim", "TERM": "dumb"},
+			Want: "vim",
+		},
+		{
+			Desc: "EDITOR",
+			Env:  map[string]string{"EDITOR": "vim"},
+			Want: "vim",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			getenv := func(k string) string { return tt.Env[k] }
+			assert.Equal(t, tt.Want, Resolve(getenv, tt.CoreEditor))
+		})
+	}
+}
+
+func TestWaitArgs(t *testing.T) {
+	tests := []struct {
+		Name string
+		Args []string
+		Want []string
+	}{
+		{Name: "code", Want: []string{"--wait"}},
+		{Name: "/usr/local/bin/code", Args: []string{"-n"}, Want: []string{"-n", "--wait"}},
+		{Name: "code", Args: []string{"--wait"}, Want: []string{"--wait"}},
+		{Name: "subl", Args: []string{"-w"}, Want: []string{"-w"}},
+		{Name: "subl", Want: []string{"--wait"}},
+		{Name: "emacsclient", Args: []string{"-n", "-c"}, Want: []string{"-c"}},
+		{Name: "emacs", Args: []string{"-nw"}, Want: []string{"-nw"}},
+		{Name: "gvim", Want: []string{"-f"}},
+		{Name: "/usr/local/bin/mvim", Args: []string{"-p"}, Want: []string{"-p", "-f"}},
+		{Name: "gvim", Args: []string{"--nofork"}, Want: []string{"--nofork"}},
+		{Name: "vim", Args: []string{"-u", "NONE"}, Want: []string{"-u", "NONE"}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.Want, waitArgs(tt.Name, tt.Args), "%v %v", tt.Name, tt.Args)
+	}
+}
@@ -0,0 +1,63 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// Static is an editor that always produces the same message regardless of
+// what it was asked to edit. Use this to supply a message without asking the
+// user.
+type Static struct {
+	message string
+}
+
+// NewStatic builds a new editor that always produces the given message.
+func NewStatic(message string) *Static {
+	return &Static{message: message}
+}
+
+// EditString returns the message of this editor.
+func (e *Static) EditString(string) (string, error) {
+	return e.message, nil
+}
+
+// Noop is an editor that leaves strings unchanged.
+type Noop struct{}
+
+// EditString returns the given string unchanged.
+func (Noop) EditString(s string) (string, error) {
+	return s, nil
+}
+
+// Reader is an editor that produces the contents of an io.Reader, such as
+// stdin, regardless of what it was asked to edit.
+//
+// The reader is read the first time a string is edited. Later edits produce
+// the same contents.
+type Reader struct {
+	once     sync.Once
+	r        io.Reader
+	contents string
+	err      error
+}
+
+// NewReader builds a new editor that produces the contents of the given
+// reader.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+// EditString returns the contents of the reader of this editor.
+func (e *Reader) EditString(string) (string, error) {
+	e.once.Do(func() {
+		b, err := ioutil.ReadAll(e.r)
+		if err != nil {
+			e.err = fmt.Errorf("could not read message: %v", err)
+		}
+		e.contents = string(b)
+	})
+	return e.contents, e.err
+}
@@ -21,16 +21,19 @@ const _viLikeplaceholder = "#### git-stack placeholder ####"
 type ViLike struct {
 	name string
 	path string
+	args []string
 }
 
-// NewViLike builds a new vi-like editor.
-func NewViLike(name string) (*ViLike, error) {
+// NewViLike builds a new vi-like editor. The given arguments are passed to
+// the editor before the arguments git-pr needs. GUI versions of vim are told
+// to stay in the foreground if the arguments don't already do so.
+func NewViLike(name string, args ...string) (*ViLike, error) {
 	path, err := exec.LookPath(name)
 	if err != nil {
 		return nil, fmt.Errorf("could not resolve editor %q: %v", name, err)
 	}
 
-	return &ViLike{name: name, path: path}, nil
+	return &ViLike{name: name, path: path, args: waitArgs(name, args)}, nil
 }
 
 // Name of the editor.
@@ -58,13 +61,13 @@ func (vi *ViLike) EditString(in string) (string, error) {
 	// it up in vim and replace the placeholder contents with the actual
 	// contents we want to edit. If the user doesn't save it, the placeholder
 	// will be retained.
-	cmd := exec.Command(
-		vi.path,
+	args := append(append([]string(nil), vi.args...),
 		"-c", "%d", // delete placeholder
 		"-c", "0read "+sourceFile, // read the source file
 		"-c", "$d", // delete trailing newline
 		"-c", "set ft=gitcommit | 0", // set filetype and go to start of file
 		destFile)
+	cmd := exec.Command(vi.path, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
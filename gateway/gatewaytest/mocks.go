@@ -102,6 +102,17 @@ func (_mr *_MockGitRecorder) DoesBranchExist(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "DoesBranchExist", arg0)
 }
 
+func (_m *MockGit) FastForward(_param0 string) (bool, error) {
+	ret := _m.ctrl.Call(_m, "FastForward", _param0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockGitRecorder) FastForward(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "FastForward", arg0)
+}
+
 func (_m *MockGit) Fetch(_param0 *gateway.FetchRequest) error {
 	ret := _m.ctrl.Call(_m, "Fetch", _param0)
 	ret0, _ := ret[0].(error)
@@ -112,6 +123,50 @@ func (_mr *_MockGitRecorder) Fetch(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Fetch", arg0)
 }
 
+func (_m *MockGit) ForkPoint(_param0 string, _param1 string) (string, error) {
+	ret := _m.ctrl.Call(_m, "ForkPoint", _param0, _param1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockGitRecorder) ForkPoint(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ForkPoint", arg0, arg1)
+}
+
+func (_m *MockGit) GetConfig(_param0 string) (string, error) {
+	ret := _m.ctrl.Call(_m, "GetConfig", _param0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockGitRecorder) GetConfig(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetConfig", arg0)
+}
+
+func (_m *MockGit) ListBranches() ([]string, error) {
+	ret := _m.ctrl.Call(_m, "ListBranches")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockGitRecorder) ListBranches() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListBranches")
+}
+
+func (_m *MockGit) ListConfig(_param0 string) (map[string]string, error) {
+	ret := _m.ctrl.Call(_m, "ListConfig", _param0)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockGitRecorder) ListConfig(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListConfig", arg0)
+}
+
 func (_m *MockGit) Pull(_param0 string, _param1 string) error {
 	ret := _m.ctrl.Call(_m, "Pull", _param0, _param1)
 	ret0, _ := ret[0].(error)
@@ -163,6 +218,16 @@ func (_mr *_MockGitRecorder) ResetBranch(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ResetBranch", arg0, arg1)
 }
 
+func (_m *MockGit) RootDir() string {
+	ret := _m.ctrl.Call(_m, "RootDir")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+func (_mr *_MockGitRecorder) RootDir() *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RootDir")
+}
+
 func (_m *MockGit) SHA1(_param0 string) (string, error) {
 	ret := _m.ctrl.Call(_m, "SHA1", _param0)
 	ret0, _ := ret[0].(string)
@@ -174,6 +239,16 @@ func (_mr *_MockGitRecorder) SHA1(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "SHA1", arg0)
 }
 
+func (_m *MockGit) SetConfig(_param0 string, _param1 string) error {
+	ret := _m.ctrl.Call(_m, "SetConfig", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockGitRecorder) SetConfig(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetConfig", arg0, arg1)
+}
+
 // Mock of GitHub interface
 type MockGitHub struct {
 	ctrl     *gomock.Controller
@@ -216,6 +291,17 @@ func (_mr *_MockGitHubRecorder) GetBuildStatus(arg0, arg1 interface{}) *gomock.C
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetBuildStatus", arg0, arg1)
 }
 
+func (_m *MockGitHub) GetPullRequest(_param0 context.Context, _param1 int) (*github.PullRequest, error) {
+	ret := _m.ctrl.Call(_m, "GetPullRequest", _param0, _param1)
+	ret0, _ := ret[0].(*github.PullRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockGitHubRecorder) GetPullRequest(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetPullRequest", arg0, arg1)
+}
+
 func (_m *MockGitHub) GetPullRequestPatch(_param0 context.Context, _param1 int) (string, error) {
 	ret := _m.ctrl.Call(_m, "GetPullRequestPatch", _param0, _param1)
 	ret0, _ := ret[0].(string)
@@ -227,6 +313,17 @@ func (_mr *_MockGitHubRecorder) GetPullRequestPatch(arg0, arg1 interface{}) *gom
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetPullRequestPatch", arg0, arg1)
 }
 
+func (_m *MockGitHub) GetUser(_param0 context.Context, _param1 string) (*gateway.User, error) {
+	ret := _m.ctrl.Call(_m, "GetUser", _param0, _param1)
+	ret0, _ := ret[0].(*gateway.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockGitHubRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "GetUser", arg0, arg1)
+}
+
 func (_m *MockGitHub) IsOwned(_param0 context.Context, _param1 *github.PullRequestBranch) bool {
 	ret := _m.ctrl.Call(_m, "IsOwned", _param0, _param1)
 	ret0, _ := ret[0].(bool)
@@ -237,6 +334,28 @@ func (_mr *_MockGitHubRecorder) IsOwned(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "IsOwned", arg0, arg1)
 }
 
+func (_m *MockGitHub) ListAllPullRequestsByHead(_param0 context.Context, _param1 string, _param2 string) ([]*github.PullRequest, error) {
+	ret := _m.ctrl.Call(_m, "ListAllPullRequestsByHead", _param0, _param1, _param2)
+	ret0, _ := ret[0].([]*github.PullRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockGitHubRecorder) ListAllPullRequestsByHead(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListAllPullRequestsByHead", arg0, arg1, arg2)
+}
+
+func (_m *MockGitHub) ListPullRequestCommits(_param0 context.Context, _param1 int) ([]*gateway.PullRequestCommit, error) {
+	ret := _m.ctrl.Call(_m, "ListPullRequestCommits", _param0, _param1)
+	ret0, _ := ret[0].([]*gateway.PullRequestCommit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockGitHubRecorder) ListPullRequestCommits(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListPullRequestCommits", arg0, arg1)
+}
+
 func (_m *MockGitHub) ListPullRequestReviews(_param0 context.Context, _param1 int) ([]*gateway.PullRequestReview, error) {
 	ret := _m.ctrl.Call(_m, "ListPullRequestReviews", _param0, _param1)
 	ret0, _ := ret[0].([]*gateway.PullRequestReview)
@@ -270,6 +389,16 @@ func (_mr *_MockGitHubRecorder) ListPullRequestsByHead(arg0, arg1, arg2 interfac
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListPullRequestsByHead", arg0, arg1, arg2)
 }
 
+func (_m *MockGitHub) MergePullRequest(_param0 context.Context, _param1 *gateway.MergeRequest) error {
+	ret := _m.ctrl.Call(_m, "MergePullRequest", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (_mr *_MockGitHubRecorder) MergePullRequest(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "MergePullRequest", arg0, arg1)
+}
+
 func (_m *MockGitHub) SetPullRequestBase(_param0 context.Context, _param1 int, _param2 string) error {
 	ret := _m.ctrl.Call(_m, "SetPullRequestBase", _param0, _param1, _param2)
 	ret0, _ := ret[0].(error)
@@ -280,12 +409,12 @@ func (_mr *_MockGitHubRecorder) SetPullRequestBase(arg0, arg1, arg2 interface{})
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetPullRequestBase", arg0, arg1, arg2)
 }
 
-func (_m *MockGitHub) SquashPullRequest(_param0 context.Context, _param1 *github.PullRequest) error {
-	ret := _m.ctrl.Call(_m, "SquashPullRequest", _param0, _param1)
+func (_m *MockGitHub) SetPullRequestBody(_param0 context.Context, _param1 int, _param2 string) error {
+	ret := _m.ctrl.Call(_m, "SetPullRequestBody", _param0, _param1, _param2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-func (_mr *_MockGitHubRecorder) SquashPullRequest(arg0, arg1 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCall(_mr.mock, "SquashPullRequest", arg0, arg1)
+func (_mr *_MockGitHubRecorder) SetPullRequestBody(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "SetPullRequestBody", arg0, arg1, arg2)
 }
@@ -27,12 +27,18 @@ type RebaseRequest struct {
 
 // Git is a gateway to access git locally.
 type Git interface {
+	// Returns the root directory of the working tree.
+	RootDir() string
+
 	// Determines the name of the current branch.
 	CurrentBranch() (string, error)
 
 	// Determines if a local branch with the given name exists.
 	DoesBranchExist(name string) bool
 
+	// Lists the names of all local branches.
+	ListBranches() ([]string, error)
+
 	// Deletes the given branch.
 	DeleteBranch(name string) error
 
@@ -67,9 +73,30 @@ type Git interface {
 	// Get the SHA1 hash for the given ref.
 	SHA1(ref string) (string, error)
 
+	// Determines the commit at which the given branch forked from upstream,
+	// taking into account that upstream may have been rewritten since.
+	ForkPoint(upstream, branch string) (string, error)
+
 	// Pulls a branch from a specific remote.
 	Pull(remote, name string) error
 
+	// Fast-forwards the current branch to the given ref. false is returned
+	// without changing anything if the branch has commits that aren't in
+	// ref.
+	FastForward(ref string) (bool, error)
+
 	// RemoteURL gets the URL for the given remote.
 	RemoteURL(name string) (string, error)
+
+	// Gets the value of the given git-config key. An empty string is
+	// returned if the key is not set.
+	GetConfig(key string) (string, error)
+
+	// Sets the value of the given git-config key in the local repository.
+	SetConfig(key, value string) error
+
+	// Lists git-config keys matching the given regular expression and their
+	// values. Keys without values, which git treats as true, are reported
+	// as "true".
+	ListConfig(pattern string) (map[string]string, error)
 }
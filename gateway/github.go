@@ -27,6 +27,17 @@ type PullRequestReview struct {
 	Status PullRequestReviewState
 }
 
+// User is a GitHub user.
+type User struct {
+	ID    int64
+	Login string
+
+	// Name and public email address of the user. These are empty if the
+	// user didn't set them.
+	Name  string
+	Email string
+}
+
 // BuildState indicates whether a build succeeded, failed or is pending.
 type BuildState string
 
@@ -51,6 +62,39 @@ type BuildStatus struct {
 	Statuses []*BuildContextStatus
 }
 
+// MergeMethod specifies how a pull request is merged.
+type MergeMethod string
+
+// All possible MergeMethods.
+const (
+	MergeSquash MergeMethod = "squash"
+	MergeCommit MergeMethod = "merge"
+	MergeRebase MergeMethod = "rebase"
+)
+
+// MergeRequest is a request to merge a pull request.
+type MergeRequest struct {
+	// Pull request to merge. Its title and body are used as the commit
+	// message for the squash and merge methods.
+	PullRequest *github.PullRequest
+
+	// Defaults to MergeSquash.
+	Method MergeMethod
+}
+
+// PullRequestCommit is a commit that is part of a pull request.
+type PullRequestCommit struct {
+	SHA string
+
+	// Author of the commit as recorded by git.
+	AuthorName  string
+	AuthorEmail string
+
+	// GitHub user that the author of the commit corresponds to. This is
+	// empty if GitHub doesn't know which user authored the commit.
+	AuthorLogin string
+}
+
 // GitHub is a gateway that provides access to GitHub operations on a specific
 // repository.
 type GitHub interface {
@@ -58,16 +102,30 @@ type GitHub interface {
 	// repository.
 	IsOwned(ctx context.Context, br *github.PullRequestBranch) bool
 
+	// Retrieve the profile of the user with the given login.
+	GetUser(ctx context.Context, login string) (*User, error)
+
 	// Lists reviews for a pull request.
 	ListPullRequestReviews(ctx context.Context, number int) ([]*PullRequestReview, error)
 
+	// Lists the commits that are part of a pull request.
+	ListPullRequestCommits(ctx context.Context, number int) ([]*PullRequestCommit, error)
+
 	// Get the build status of a specific ref.
 	GetBuildStatus(ctx context.Context, ref string) (*BuildStatus, error)
 
+	// Retrieve the pull request with the given number.
+	GetPullRequest(ctx context.Context, number int) (*github.PullRequest, error)
+
 	// List pull requests on this repository with the given head. If owner is
 	// empty, the current repository should be used.
 	ListPullRequestsByHead(ctx context.Context, owner, branch string) ([]*github.PullRequest, error)
 
+	// List pull requests on this repository with the given head regardless
+	// of whether they are open, closed or merged. If owner is empty, the
+	// current repository should be used.
+	ListAllPullRequestsByHead(ctx context.Context, owner, branch string) ([]*github.PullRequest, error)
+
 	// List pull requests on this repository with the given merge base.
 	ListPullRequestsByBase(ctx context.Context, branch string) ([]*github.PullRequest, error)
 
@@ -77,9 +135,11 @@ type GitHub interface {
 	// Change the merge base for the given pull request.
 	SetPullRequestBase(ctx context.Context, number int, base string) error
 
-	// Merges the given pull request.
-	SquashPullRequest(context.Context, *github.PullRequest) error
-	// TODO: SquashPullRequest should accept an explicit SquashRequest
+	// Change the description of the given pull request.
+	SetPullRequestBody(ctx context.Context, number int, body string) error
+
+	// Merges a pull request.
+	MergePullRequest(context.Context, *MergeRequest) error
 
 	// Delete the given branch.
 	DeleteBranch(ctx context.Context, name string) error
@@ -0,0 +1,254 @@
+package recording
+
+import "github.com/abhinav/git-pr/gateway"
+
+// Git wraps the given Git gateway to record all calls made to it.
+func (r *Recorder) Git(g gateway.Git) gateway.Git {
+	return &recordingGit{git: g, rec: r}
+}
+
+type recordingGit struct {
+	git gateway.Git
+	rec *Recorder
+}
+
+var _ gateway.Git = (*recordingGit)(nil)
+
+func (g *recordingGit) RootDir() string {
+	dir := g.git.RootDir()
+	g.rec.record(_git, "RootDir", nil, nil, dir)
+	return dir
+}
+
+func (g *recordingGit) CurrentBranch() (string, error) {
+	out, err := g.git.CurrentBranch()
+	g.rec.record(_git, "CurrentBranch", nil, err, out)
+	return out, err
+}
+
+func (g *recordingGit) DoesBranchExist(name string) bool {
+	ok := g.git.DoesBranchExist(name)
+	g.rec.record(_git, "DoesBranchExist", []interface{}{name}, nil, ok)
+	return ok
+}
+
+func (g *recordingGit) ListBranches() ([]string, error) {
+	out, err := g.git.ListBranches()
+	g.rec.record(_git, "ListBranches", nil, err, out)
+	return out, err
+}
+
+func (g *recordingGit) DeleteBranch(name string) error {
+	err := g.git.DeleteBranch(name)
+	g.rec.record(_git, "DeleteBranch", []interface{}{name}, err)
+	return err
+}
+
+func (g *recordingGit) DeleteRemoteTrackingBranch(remote, name string) error {
+	err := g.git.DeleteRemoteTrackingBranch(remote, name)
+	g.rec.record(_git, "DeleteRemoteTrackingBranch", []interface{}{remote, name}, err)
+	return err
+}
+
+func (g *recordingGit) CreateBranch(name, head string) error {
+	err := g.git.CreateBranch(name, head)
+	g.rec.record(_git, "CreateBranch", []interface{}{name, head}, err)
+	return err
+}
+
+func (g *recordingGit) CreateBranchAndCheckout(name, head string) error {
+	err := g.git.CreateBranchAndCheckout(name, head)
+	g.rec.record(_git, "CreateBranchAndCheckout", []interface{}{name, head}, err)
+	return err
+}
+
+func (g *recordingGit) Checkout(name string) error {
+	err := g.git.Checkout(name)
+	g.rec.record(_git, "Checkout", []interface{}{name}, err)
+	return err
+}
+
+func (g *recordingGit) Fetch(req *gateway.FetchRequest) error {
+	err := g.git.Fetch(req)
+	g.rec.record(_git, "Fetch", []interface{}{req}, err)
+	return err
+}
+
+func (g *recordingGit) Push(req *gateway.PushRequest) error {
+	err := g.git.Push(req)
+	g.rec.record(_git, "Push", []interface{}{req}, err)
+	return err
+}
+
+func (g *recordingGit) Rebase(req *gateway.RebaseRequest) error {
+	err := g.git.Rebase(req)
+	g.rec.record(_git, "Rebase", []interface{}{req}, err)
+	return err
+}
+
+func (g *recordingGit) ResetBranch(branch, head string) error {
+	err := g.git.ResetBranch(branch, head)
+	g.rec.record(_git, "ResetBranch", []interface{}{branch, head}, err)
+	return err
+}
+
+func (g *recordingGit) SHA1(ref string) (string, error) {
+	out, err := g.git.SHA1(ref)
+	g.rec.record(_git, "SHA1", []interface{}{ref}, err, out)
+	return out, err
+}
+
+func (g *recordingGit) ForkPoint(upstream, branch string) (string, error) {
+	out, err := g.git.ForkPoint(upstream, branch)
+	g.rec.record(_git, "ForkPoint", []interface{}{upstream, branch}, err, out)
+	return out, err
+}
+
+func (g *recordingGit) Pull(remote, name string) error {
+	err := g.git.Pull(remote, name)
+	g.rec.record(_git, "Pull", []interface{}{remote, name}, err)
+	return err
+}
+
+func (g *recordingGit) FastForward(ref string) (bool, error) {
+	ok, err := g.git.FastForward(ref)
+	g.rec.record(_git, "FastForward", []interface{}{ref}, err, ok)
+	return ok, err
+}
+
+func (g *recordingGit) RemoteURL(name string) (string, error) {
+	out, err := g.git.RemoteURL(name)
+	g.rec.record(_git, "RemoteURL", []interface{}{name}, err, out)
+	return out, err
+}
+
+func (g *recordingGit) GetConfig(key string) (string, error) {
+	out, err := g.git.GetConfig(key)
+	g.rec.record(_git, "GetConfig", []interface{}{key}, err, out)
+	return out, err
+}
+
+func (g *recordingGit) SetConfig(key, value string) error {
+	err := g.git.SetConfig(key, value)
+	g.rec.record(_git, "SetConfig", []interface{}{key, value}, err)
+	return err
+}
+
+func (g *recordingGit) ListConfig(pattern string) (map[string]string, error) {
+	out, err := g.git.ListConfig(pattern)
+	g.rec.record(_git, "ListConfig", []interface{}{pattern}, err, out)
+	return out, err
+}
+
+// Git returns a Git gateway which replays recorded calls.
+func (p *Player) Git() gateway.Git {
+	return &replayGit{p: p}
+}
+
+type replayGit struct{ p *Player }
+
+var _ gateway.Git = (*replayGit)(nil)
+
+func (g *replayGit) RootDir() string {
+	var dir string
+	g.p.replay(_git, "RootDir", nil, &dir)
+	return dir
+}
+
+func (g *replayGit) CurrentBranch() (string, error) {
+	var out string
+	err := g.p.replay(_git, "CurrentBranch", nil, &out)
+	return out, err
+}
+
+func (g *replayGit) DoesBranchExist(name string) bool {
+	var ok bool
+	g.p.replay(_git, "DoesBranchExist", []interface{}{name}, &ok)
+	return ok
+}
+
+func (g *replayGit) ListBranches() ([]string, error) {
+	var out []string
+	err := g.p.replay(_git, "ListBranches", nil, &out)
+	return out, err
+}
+
+func (g *replayGit) DeleteBranch(name string) error {
+	return g.p.replay(_git, "DeleteBranch", []interface{}{name})
+}
+
+func (g *replayGit) DeleteRemoteTrackingBranch(remote, name string) error {
+	return g.p.replay(_git, "DeleteRemoteTrackingBranch", []interface{}{remote, name})
+}
+
+func (g *replayGit) CreateBranch(name, head string) error {
+	return g.p.replay(_git, "CreateBranch", []interface{}{name, head})
+}
+
+func (g *replayGit) CreateBranchAndCheckout(name, head string) error {
+	return g.p.replay(_git, "CreateBranchAndCheckout", []interface{}{name, head})
+}
+
+func (g *replayGit) Checkout(name string) error {
+	return g.p.replay(_git, "Checkout", []interface{}{name})
+}
+
+func (g *replayGit) Fetch(req *gateway.FetchRequest) error {
+	return g.p.replay(_git, "Fetch", []interface{}{req})
+}
+
+func (g *replayGit) Push(req *gateway.PushRequest) error {
+	return g.p.replay(_git, "Push", []interface{}{req})
+}
+
+func (g *replayGit) Rebase(req *gateway.RebaseRequest) error {
+	return g.p.replay(_git, "Rebase", []interface{}{req})
+}
+
+func (g *replayGit) ResetBranch(branch, head string) error {
+	return g.p.replay(_git, "ResetBranch", []interface{}{branch, head})
+}
+
+func (g *replayGit) SHA1(ref string) (string, error) {
+	var out string
+	err := g.p.replay(_git, "SHA1", []interface{}{ref}, &out)
+	return out, err
+}
+
+func (g *replayGit) ForkPoint(upstream, branch string) (string, error) {
+	var out string
+	err := g.p.replay(_git, "ForkPoint", []interface{}{upstream, branch}, &out)
+	return out, err
+}
+
+func (g *replayGit) Pull(remote, name string) error {
+	return g.p.replay(_git, "Pull", []interface{}{remote, name})
+}
+
+func (g *replayGit) FastForward(ref string) (bool, error) {
+	var ok bool
+	err := g.p.replay(_git, "FastForward", []interface{}{ref}, &ok)
+	return ok, err
+}
+
+func (g *replayGit) RemoteURL(name string) (string, error) {
+	var out string
+	err := g.p.replay(_git, "RemoteURL", []interface{}{name}, &out)
+	return out, err
+}
+
+func (g *replayGit) GetConfig(key string) (string, error) {
+	var out string
+	err := g.p.replay(_git, "GetConfig", []interface{}{key}, &out)
+	return out, err
+}
+
+func (g *replayGit) SetConfig(key, value string) error {
+	return g.p.replay(_git, "SetConfig", []interface{}{key, value})
+}
+
+func (g *replayGit) ListConfig(pattern string) (map[string]string, error) {
+	var out map[string]string
+	err := g.p.replay(_git, "ListConfig", []interface{}{pattern}, &out)
+	return out, err
+}
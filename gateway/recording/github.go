@@ -0,0 +1,190 @@
+package recording
+
+import (
+	"context"
+
+	"github.com/abhinav/git-pr/gateway"
+
+	"github.com/google/go-github/github"
+)
+
+// GitHub wraps the given GitHub gateway to record all calls made to it.
+func (r *Recorder) GitHub(gh gateway.GitHub) gateway.GitHub {
+	return &recordingGitHub{gh: gh, rec: r}
+}
+
+type recordingGitHub struct {
+	gh  gateway.GitHub
+	rec *Recorder
+}
+
+var _ gateway.GitHub = (*recordingGitHub)(nil)
+
+func (g *recordingGitHub) IsOwned(ctx context.Context, br *github.PullRequestBranch) bool {
+	ok := g.gh.IsOwned(ctx, br)
+	g.rec.record(_github, "IsOwned", []interface{}{br}, nil, ok)
+	return ok
+}
+
+func (g *recordingGitHub) GetUser(ctx context.Context, login string) (*gateway.User, error) {
+	out, err := g.gh.GetUser(ctx, login)
+	g.rec.record(_github, "GetUser", []interface{}{login}, err, out)
+	return out, err
+}
+
+func (g *recordingGitHub) ListPullRequestReviews(ctx context.Context, number int) ([]*gateway.PullRequestReview, error) {
+	out, err := g.gh.ListPullRequestReviews(ctx, number)
+	g.rec.record(_github, "ListPullRequestReviews", []interface{}{number}, err, out)
+	return out, err
+}
+
+func (g *recordingGitHub) ListPullRequestCommits(ctx context.Context, number int) ([]*gateway.PullRequestCommit, error) {
+	out, err := g.gh.ListPullRequestCommits(ctx, number)
+	g.rec.record(_github, "ListPullRequestCommits", []interface{}{number}, err, out)
+	return out, err
+}
+
+func (g *recordingGitHub) GetBuildStatus(ctx context.Context, ref string) (*gateway.BuildStatus, error) {
+	out, err := g.gh.GetBuildStatus(ctx, ref)
+	g.rec.record(_github, "GetBuildStatus", []interface{}{ref}, err, out)
+	return out, err
+}
+
+func (g *recordingGitHub) GetPullRequest(ctx context.Context, number int) (*github.PullRequest, error) {
+	out, err := g.gh.GetPullRequest(ctx, number)
+	g.rec.record(_github, "GetPullRequest", []interface{}{number}, err, out)
+	return out, err
+}
+
+func (g *recordingGitHub) ListPullRequestsByHead(ctx context.Context, owner, branch string) ([]*github.PullRequest, error) {
+	out, err := g.gh.ListPullRequestsByHead(ctx, owner, branch)
+	g.rec.record(_github, "ListPullRequestsByHead", []interface{}{owner, branch}, err, out)
+	return out, err
+}
+
+func (g *recordingGitHub) ListAllPullRequestsByHead(ctx context.Context, owner, branch string) ([]*github.PullRequest, error) {
+	out, err := g.gh.ListAllPullRequestsByHead(ctx, owner, branch)
+	g.rec.record(_github, "ListAllPullRequestsByHead", []interface{}{owner, branch}, err, out)
+	return out, err
+}
+
+func (g *recordingGitHub) ListPullRequestsByBase(ctx context.Context, branch string) ([]*github.PullRequest, error) {
+	out, err := g.gh.ListPullRequestsByBase(ctx, branch)
+	g.rec.record(_github, "ListPullRequestsByBase", []interface{}{branch}, err, out)
+	return out, err
+}
+
+func (g *recordingGitHub) GetPullRequestPatch(ctx context.Context, number int) (string, error) {
+	out, err := g.gh.GetPullRequestPatch(ctx, number)
+	g.rec.record(_github, "GetPullRequestPatch", []interface{}{number}, err, out)
+	return out, err
+}
+
+func (g *recordingGitHub) SetPullRequestBase(ctx context.Context, number int, base string) error {
+	err := g.gh.SetPullRequestBase(ctx, number, base)
+	g.rec.record(_github, "SetPullRequestBase", []interface{}{number, base}, err)
+	return err
+}
+
+func (g *recordingGitHub) SetPullRequestBody(ctx context.Context, number int, body string) error {
+	err := g.gh.SetPullRequestBody(ctx, number, body)
+	g.rec.record(_github, "SetPullRequestBody", []interface{}{number, body}, err)
+	return err
+}
+
+func (g *recordingGitHub) MergePullRequest(ctx context.Context, req *gateway.MergeRequest) error {
+	err := g.gh.MergePullRequest(ctx, req)
+	g.rec.record(_github, "MergePullRequest", []interface{}{req}, err)
+	return err
+}
+
+func (g *recordingGitHub) DeleteBranch(ctx context.Context, name string) error {
+	err := g.gh.DeleteBranch(ctx, name)
+	g.rec.record(_github, "DeleteBranch", []interface{}{name}, err)
+	return err
+}
+
+// GitHub returns a GitHub gateway which replays recorded calls.
+func (p *Player) GitHub() gateway.GitHub {
+	return &replayGitHub{p: p}
+}
+
+type replayGitHub struct{ p *Player }
+
+var _ gateway.GitHub = (*replayGitHub)(nil)
+
+func (g *replayGitHub) IsOwned(ctx context.Context, br *github.PullRequestBranch) bool {
+	var ok bool
+	g.p.replay(_github, "IsOwned", []interface{}{br}, &ok)
+	return ok
+}
+
+func (g *replayGitHub) GetUser(ctx context.Context, login string) (*gateway.User, error) {
+	var out *gateway.User
+	err := g.p.replay(_github, "GetUser", []interface{}{login}, &out)
+	return out, err
+}
+
+func (g *replayGitHub) ListPullRequestReviews(ctx context.Context, number int) ([]*gateway.PullRequestReview, error) {
+	var out []*gateway.PullRequestReview
+	err := g.p.replay(_github, "ListPullRequestReviews", []interface{}{number}, &out)
+	return out, err
+}
+
+func (g *replayGitHub) ListPullRequestCommits(ctx context.Context, number int) ([]*gateway.PullRequestCommit, error) {
+	var out []*gateway.PullRequestCommit
+	err := g.p.replay(_github, "ListPullRequestCommits", []interface{}{number}, &out)
+	return out, err
+}
+
+func (g *replayGitHub) GetBuildStatus(ctx context.Context, ref string) (*gateway.BuildStatus, error) {
+	var out *gateway.BuildStatus
+	err := g.p.replay(_github, "GetBuildStatus", []interface{}{ref}, &out)
+	return out, err
+}
+
+func (g *replayGitHub) GetPullRequest(ctx context.Context, number int) (*github.PullRequest, error) {
+	var out *github.PullRequest
+	err := g.p.replay(_github, "GetPullRequest", []interface{}{number}, &out)
+	return out, err
+}
+
+func (g *replayGitHub) ListPullRequestsByHead(ctx context.Context, owner, branch string) ([]*github.PullRequest, error) {
+	var out []*github.PullRequest
+	err := g.p.replay(_github, "ListPullRequestsByHead", []interface{}{owner, branch}, &out)
+	return out, err
+}
+
+func (g *replayGitHub) ListAllPullRequestsByHead(ctx context.Context, owner, branch string) ([]*github.PullRequest, error) {
+	var out []*github.PullRequest
+	err := g.p.replay(_github, "ListAllPullRequestsByHead", []interface{}{owner, branch}, &out)
+	return out, err
+}
+
+func (g *replayGitHub) ListPullRequestsByBase(ctx context.Context, branch string) ([]*github.PullRequest, error) {
+	var out []*github.PullRequest
+	err := g.p.replay(_github, "ListPullRequestsByBase", []interface{}{branch}, &out)
+	return out, err
+}
+
+func (g *replayGitHub) GetPullRequestPatch(ctx context.Context, number int) (string, error) {
+	var out string
+	err := g.p.replay(_github, "GetPullRequestPatch", []interface{}{number}, &out)
+	return out, err
+}
+
+func (g *replayGitHub) SetPullRequestBase(ctx context.Context, number int, base string) error {
+	return g.p.replay(_github, "SetPullRequestBase", []interface{}{number, base})
+}
+
+func (g *replayGitHub) SetPullRequestBody(ctx context.Context, number int, body string) error {
+	return g.p.replay(_github, "SetPullRequestBody", []interface{}{number, body})
+}
+
+func (g *replayGitHub) MergePullRequest(ctx context.Context, req *gateway.MergeRequest) error {
+	return g.p.replay(_github, "MergePullRequest", []interface{}{req})
+}
+
+func (g *replayGitHub) DeleteBranch(ctx context.Context, name string) error {
+	return g.p.replay(_github, "DeleteBranch", []interface{}{name})
+}
@@ -0,0 +1,228 @@
+// Package recording records calls made to gateways during a real run of
+// git-pr so that they can be replayed later.
+//
+// A Recorder wraps real gateways and captures every call and its results.
+//
+// 	rec := recording.NewRecorder()
+// 	git = rec.Git(git)
+// 	gh = rec.GitHub(gh)
+// 	// ...
+// 	err := rec.Save(f)
+//
+// A Player serves the recorded results without talking to git or GitHub.
+// This makes it possible to reproduce a failure from someone else's
+// repository in a test.
+//
+// 	p, err := recording.NewPlayer(f)
+// 	svc := pr.NewService(pr.ServiceConfig{Git: p.Git(), GitHub: p.GitHub()})
+//
+// Calls are matched by gateway, method, and arguments. Contexts are not
+// recorded.
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Version of the recording format.
+const _version = 1
+
+// Names of gateways in recordings.
+const (
+	_git    = "git"
+	_github = "github"
+)
+
+// Call is a recorded call to a gateway.
+type Call struct {
+	Gateway string `json:"gateway"`
+	Method  string `json:"method"`
+
+	// JSON-encoded list of arguments and non-error results.
+	Args    json.RawMessage `json:"args"`
+	Results json.RawMessage `json:"results"`
+
+	// Message of the error returned by the call, if any.
+	Error string `json:"error,omitempty"`
+}
+
+type file struct {
+	Version int     `json:"version"`
+	Calls   []*Call `json:"calls"`
+}
+
+// Recorder records calls made to the gateways wrapped by it.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []*Call
+}
+
+// NewRecorder builds a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Calls returns the calls recorded so far.
+func (r *Recorder) Calls() []*Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Call(nil), r.calls...)
+}
+
+// Save writes the recorded calls to w.
+func (r *Recorder) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(file{Version: _version, Calls: r.Calls()}); err != nil {
+		return fmt.Errorf("failed to save recording: %v", err)
+	}
+	return nil
+}
+
+func (r *Recorder) record(gateway, method string, args []interface{}, err error, results ...interface{}) {
+	call := Call{Gateway: gateway, Method: method}
+	call.Args, _ = encode(args)
+	call.Results, _ = encode(results)
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	r.calls = append(r.calls, &call)
+	r.mu.Unlock()
+}
+
+// Player replays calls saved by a Recorder.
+type Player struct {
+	mu     sync.Mutex
+	calls  []*Call
+	used   []bool
+	errors []error
+}
+
+// NewPlayer loads a recording saved by Recorder.Save.
+func NewPlayer(r io.Reader) (*Player, error) {
+	var f file
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to load recording: %v", err)
+	}
+	if f.Version != _version {
+		return nil, fmt.Errorf("unsupported recording version %v", f.Version)
+	}
+
+	// Arguments are compared byte-for-byte so they must be in the same form
+	// as the ones produced by encode.
+	for _, c := range f.Calls {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, c.Args); err != nil {
+			return nil, fmt.Errorf("failed to load recording: %v", err)
+		}
+		c.Args = buf.Bytes()
+	}
+
+	return &Player{calls: f.Calls, used: make([]bool, len(f.Calls))}, nil
+}
+
+// Err returns an error if any of the calls made to the Player's gateways
+// did not match a recorded call.
+func (p *Player) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.errors) == 0 {
+		return nil
+	}
+
+	msg := "calls did not match the recording:"
+	for _, err := range p.errors {
+		msg += "\n - " + err.Error()
+	}
+	return errors.New(msg)
+}
+
+// Unused returns the recorded calls that have not been replayed yet.
+func (p *Player) Unused() []*Call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var calls []*Call
+	for i, c := range p.calls {
+		if !p.used[i] {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// replay finds the first unused recorded call matching the given call and
+// decodes its results into the given pointers. The recorded error, if any,
+// is returned.
+//
+// Calls made concurrently may be replayed in a different order than they
+// were recorded in so calls are matched by their arguments rather than their
+// position in the recording.
+func (p *Player) replay(gateway, method string, args []interface{}, results ...interface{}) error {
+	encodedArgs, err := encode(args)
+	if err != nil {
+		return p.mismatch(fmt.Errorf("%v.%v: %v", gateway, method, err))
+	}
+
+	p.mu.Lock()
+	var call *Call
+	for i, c := range p.calls {
+		if p.used[i] || c.Gateway != gateway || c.Method != method {
+			continue
+		}
+		if bytes.Equal(c.Args, encodedArgs) {
+			p.used[i] = true
+			call = c
+			break
+		}
+	}
+	p.mu.Unlock()
+
+	if call == nil {
+		return p.mismatch(fmt.Errorf(
+			"%v.%v%s was not recorded or was already replayed", gateway, method, encodedArgs))
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(call.Results, &raw); err != nil {
+		return p.mismatch(fmt.Errorf("%v.%v: invalid results: %v", gateway, method, err))
+	}
+	if len(raw) != len(results) {
+		return p.mismatch(fmt.Errorf("%v.%v: expected %v results, got %v",
+			gateway, method, len(results), len(raw)))
+	}
+	for i, r := range raw {
+		if err := json.Unmarshal(r, results[i]); err != nil {
+			return p.mismatch(fmt.Errorf("%v.%v: invalid result: %v", gateway, method, err))
+		}
+	}
+
+	if call.Error != "" {
+		return errors.New(call.Error)
+	}
+	return nil
+}
+
+func (p *Player) mismatch(err error) error {
+	p.mu.Lock()
+	p.errors = append(p.errors, err)
+	p.mu.Unlock()
+	return err
+}
+
+// encode encodes the given values as a JSON list. Encoding is deterministic
+// because encoding/json sorts map keys.
+func encode(values []interface{}) (json.RawMessage, error) {
+	if values == nil {
+		values = []interface{}{}
+	}
+	return json.Marshal(values)
+}
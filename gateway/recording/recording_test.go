@@ -0,0 +1,126 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	pr := &github.PullRequest{
+		Number: github.Int(1),
+		Head:   &github.PullRequestBranch{Ref: github.String("feature1")},
+	}
+
+	git := gatewaytest.NewMockGit(mockCtrl)
+	git.EXPECT().CurrentBranch().Return("feature1", nil)
+	git.EXPECT().DoesBranchExist("feature2").Return(true)
+	git.EXPECT().SHA1("feature1").Return("sha1", nil)
+	git.EXPECT().SHA1("feature2").Return("", errors.New("unknown ref"))
+	git.EXPECT().ListConfig("^branch").Return(map[string]string{"branch.foo": "bar"}, nil)
+	git.EXPECT().Push(&gateway.PushRequest{
+		Remote: "origin",
+		Refs:   map[string]string{"feature1": "feature1"},
+	}).Return(nil)
+
+	gh := gatewaytest.NewMockGitHub(mockCtrl)
+	gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", "feature1").
+		Return([]*github.PullRequest{pr}, nil)
+	gh.EXPECT().GetBuildStatus(gomock.Any(), "sha1").
+		Return(&gateway.BuildStatus{State: gateway.BuildSuccess}, nil)
+	gh.EXPECT().SetPullRequestBase(gomock.Any(), 1, "master").
+		Return(errors.New("great sadness"))
+
+	// run exercises the gateways and returns everything it saw.
+	run := func(git gateway.Git, gh gateway.GitHub) []interface{} {
+		var results []interface{}
+		add := func(vs ...interface{}) { results = append(results, vs...) }
+
+		// Order of calls to the same method with different arguments
+		// doesn't matter during replay.
+		add(git.SHA1("feature2"))
+		add(git.CurrentBranch())
+		add(git.DoesBranchExist("feature2"))
+		add(git.SHA1("feature1"))
+		add(git.ListConfig("^branch"))
+		add(git.Push(&gateway.PushRequest{
+			Remote: "origin",
+			Refs:   map[string]string{"feature1": "feature1"},
+		}))
+		add(gh.ListPullRequestsByHead(ctx, "", "feature1"))
+		add(gh.GetBuildStatus(ctx, "sha1"))
+		add(gh.SetPullRequestBase(ctx, 1, "master"))
+		return results
+	}
+
+	rec := NewRecorder()
+	want := run(rec.Git(git), rec.GitHub(gh))
+
+	var buf bytes.Buffer
+	require.NoError(t, rec.Save(&buf))
+
+	p, err := NewPlayer(&buf)
+	require.NoError(t, err)
+
+	got := run(p.Git(), p.GitHub())
+	require.NoError(t, p.Err())
+	assert.Empty(t, p.Unused())
+	assert.Equal(t, want, got)
+}
+
+func TestReplayMismatch(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	git := gatewaytest.NewMockGit(mockCtrl)
+	git.EXPECT().SHA1("feature1").Return("sha1", nil)
+
+	rec := NewRecorder()
+	_, err := rec.Git(git).SHA1("feature1")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, rec.Save(&buf))
+
+	p, err := NewPlayer(&buf)
+	require.NoError(t, err)
+
+	_, err = p.Git().SHA1("feature2")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `git.SHA1["feature2"] was not recorded`)
+
+	sha, err := p.Git().SHA1("feature1")
+	require.NoError(t, err)
+	assert.Equal(t, "sha1", sha)
+
+	// Calls are replayed only once.
+	_, err = p.Git().SHA1("feature1")
+	require.Error(t, err)
+
+	err = p.Err()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "calls did not match the recording")
+}
+
+func TestNewPlayerErrors(t *testing.T) {
+	_, err := NewPlayer(bytes.NewBufferString("{"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load recording")
+
+	_, err = NewPlayer(bytes.NewBufferString(`{"version": 42}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported recording version 42")
+}
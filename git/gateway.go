@@ -8,8 +8,11 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/logging"
 
 	"go.uber.org/multierr"
 )
@@ -19,12 +22,19 @@ type Gateway struct {
 	mu sync.RWMutex
 
 	dir string
+	log *logging.Logger
+
+	// If set, commands that talk to remotes on httpsHost over HTTPS
+	// authenticate with the token returned by httpsToken.
+	httpsHost  string
+	httpsToken func() (string, error)
 }
 
 var _ gateway.Git = (*Gateway)(nil)
 
-// NewGateway builds a new Git gateway.
-func NewGateway(startDir string) (*Gateway, error) {
+// NewGateway builds a new Git gateway. Every git command run by the gateway
+// is traced to log at the Debug level. log may be nil.
+func NewGateway(startDir string, log *logging.Logger) (*Gateway, error) {
 	if startDir == "" {
 		dir, err := os.Getwd()
 		if err != nil {
@@ -55,7 +65,30 @@ func NewGateway(startDir string) (*Gateway, error) {
 		dir = newDir
 	}
 
-	return &Gateway{dir: dir}, nil
+	return &Gateway{dir: dir, log: log}, nil
+}
+
+// RootDir returns the root directory of the working tree.
+func (g *Gateway) RootDir() string {
+	return g.dir
+}
+
+// CommonDir returns the path to the .git directory shared by all working
+// trees of the repository.
+func (g *Gateway) CommonDir() (string, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	out, err := g.output("rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("could not determine git directory: %v", err)
+	}
+
+	dir := strings.TrimSpace(out)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(g.dir, dir)
+	}
+	return dir, nil
 }
 
 // CurrentBranch determines the current branch name.
@@ -79,6 +112,23 @@ func (g *Gateway) DoesBranchExist(name string) bool {
 	return err == nil
 }
 
+// ListBranches lists the names of all local branches.
+func (g *Gateway) ListBranches() ([]string, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	out, err := g.output("for-each-ref", "--format=%(refname)", "refs/heads/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %v", err)
+	}
+
+	var branches []string
+	for _, ref := range strings.Fields(out) {
+		branches = append(branches, strings.TrimPrefix(ref, "refs/heads/"))
+	}
+	return branches, nil
+}
+
 // CreateBranchAndCheckout creates a branch with the given name and head and
 // switches to it.
 func (g *Gateway) CreateBranchAndCheckout(name, head string) error {
@@ -116,6 +166,26 @@ func (g *Gateway) SHA1(ref string) (string, error) {
 	return strings.TrimSpace(out), nil
 }
 
+// ForkPoint determines the commit at which branch forked from upstream. If
+// upstream was rewritten after branch was created from it, the reflog of
+// upstream is consulted to find the original fork point.
+func (g *Gateway) ForkPoint(upstream, branch string) (string, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	out, err := g.output("merge-base", "--fork-point", upstream, branch)
+	if err != nil {
+		// --fork-point fails if the reflog of upstream doesn't have the
+		// information we need.
+		out, err = g.output("merge-base", upstream, branch)
+	}
+	if err != nil {
+		return "", fmt.Errorf(
+			"could not determine where %q forked from %q: %v", branch, upstream, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
 // DeleteBranch deletes the given branch.
 func (g *Gateway) DeleteBranch(name string) error {
 	g.mu.Lock()
@@ -145,7 +215,7 @@ func (g *Gateway) Checkout(name string) error {
 	defer g.mu.Unlock()
 
 	if err := g.cmd("checkout", name).Run(); err != nil {
-		err = fmt.Errorf("failed to checkout branch %q: %v", name, err)
+		return fmt.Errorf("failed to checkout branch %q: %v", name, err)
 	}
 	return nil
 }
@@ -160,7 +230,11 @@ func (g *Gateway) Fetch(req *gateway.FetchRequest) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	if err := g.cmd("fetch", req.Remote, ref).Run(); err != nil {
+	cmd, err := g.remoteCmd("fetch", req.Remote, ref)
+	if err == nil {
+		err = cmd.Run()
+	}
+	if err != nil {
 		return fmt.Errorf("failed to fetch %q from %q: %v", ref, req.Remote, err)
 	}
 	return nil
@@ -188,7 +262,11 @@ func (g *Gateway) Push(req *gateway.PushRequest) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	if err := g.cmd(args...).Run(); err != nil {
+	cmd, err := g.remoteCmd(args...)
+	if err == nil {
+		err = cmd.Run()
+	}
+	if err != nil {
 		return fmt.Errorf("failed to push refs to %q: %v", req.Remote, err)
 	}
 	return nil
@@ -199,12 +277,36 @@ func (g *Gateway) Pull(remote, name string) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	if err := g.cmd("pull", remote, name).Run(); err != nil {
+	cmd, err := g.remoteCmd("pull", remote, name)
+	if err == nil {
+		err = cmd.Run()
+	}
+	if err != nil {
 		return fmt.Errorf("failed to pull %q from %q: %v", name, remote, err)
 	}
 	return nil
 }
 
+// FastForward fast-forwards the current branch to the given ref. false is
+// returned if the branch has commits that aren't in ref.
+func (g *Gateway) FastForward(ref string) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// merge-base exits with 1 if HEAD isn't an ancestor of ref.
+	if err := g.cmd("merge-base", "--is-ancestor", "HEAD", ref).Run(); err != nil {
+		if exitStatus(err) == 1 {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to fast-forward to %q: %v", ref, err)
+	}
+
+	if err := g.cmd("merge", "--ff-only", "-q", ref).Run(); err != nil {
+		return false, fmt.Errorf("failed to fast-forward to %q: %v", ref, err)
+	}
+	return true, nil
+}
+
 // Rebase a branch.
 func (g *Gateway) Rebase(req *gateway.RebaseRequest) error {
 	var _args [5]string
@@ -266,13 +368,145 @@ func (g *Gateway) RemoteURL(name string) (string, error) {
 	return strings.TrimSpace(out), nil
 }
 
+// GetConfig gets the value of the given git-config key. An empty string is
+// returned if the key is not set.
+func (g *Gateway) GetConfig(key string) (string, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	out, err := g.output("config", "--get", key)
+	if err != nil {
+		// git-config exits with 1 if the key was not set.
+		if exitStatus(err) == 1 {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read git-config %q: %v", key, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// SetConfig sets the value of the given git-config key.
+func (g *Gateway) SetConfig(key, value string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.cmd("config", key, value).Run(); err != nil {
+		return fmt.Errorf("failed to set git-config %q to %q: %v", key, value, err)
+	}
+	return nil
+}
+
+// ListConfig lists git-config keys matching the given regular expression.
+func (g *Gateway) ListConfig(pattern string) (map[string]string, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	out, err := g.output("config", "--null", "--get-regexp", pattern)
+	if err != nil {
+		// git-config exits with 1 if nothing matched.
+		if exitStatus(err) == 1 {
+			return make(map[string]string), nil
+		}
+		return nil, fmt.Errorf("failed to list git-config keys matching %q: %v", pattern, err)
+	}
+
+	// With --null, every item is terminated by a NUL byte and the key is
+	// separated from the value by a newline. Keys without values don't have
+	// a newline.
+	items := make(map[string]string)
+	for _, item := range strings.Split(out, "\x00") {
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, "\n", 2)
+		value := "true"
+		if len(parts) > 1 {
+			value = parts[1]
+		}
+		items[parts[0]] = value
+	}
+	return items, nil
+}
+
 // run the given git command.
-func (g *Gateway) cmd(args ...string) *exec.Cmd {
+func (g *Gateway) cmd(args ...string) command {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = g.dir
+	// Anything git prints is progress information for humans. Our stdout is
+	// reserved for the output of git-pr commands, like --output=json.
 	cmd.Stderr = os.Stderr
-	cmd.Stdout = os.Stdout
-	return cmd
+	cmd.Stdout = os.Stderr
+	return command{Cmd: cmd, log: g.log}
+}
+
+// _credentialHelper is a git credential helper which provides the token in
+// $GIT_PR_HTTPS_TOKEN. Passing the token through the environment keeps it out
+// of the arguments of git, which other users of the machine may see.
+const _credentialHelper = `!f() { test "$1" = get && echo username=x-access-token && echo "password=$GIT_PR_HTTPS_TOKEN"; }; f`
+
+// SetHTTPSToken makes fetches, pushes, and pulls from remotes on host over
+// HTTPS authenticate with the token returned by token. token is called for
+// each of these commands so that it may return a new token when the old one
+// expires. Credential helpers configured by the user aren't consulted for
+// host. Remotes accessed over SSH are not affected.
+func (g *Gateway) SetHTTPSToken(host string, token func() (string, error)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.httpsHost = host
+	g.httpsToken = token
+}
+
+// remoteCmd builds a git command which talks to a remote. It authenticates
+// with the token given to SetHTTPSToken, if any.
+//
+// The caller must hold g.mu.
+func (g *Gateway) remoteCmd(args ...string) (command, error) {
+	if g.httpsToken == nil {
+		return g.cmd(args...), nil
+	}
+
+	token, err := g.httpsToken()
+	if err != nil {
+		return command{}, fmt.Errorf("failed to get token for %v: %v", g.httpsHost, err)
+	}
+
+	// An empty helper clears the helpers configured so far so that ours is
+	// the only one asked.
+	section := "credential.https://" + g.httpsHost
+	cmd := g.cmd(append([]string{
+		"-c", section + ".helper=",
+		"-c", section + ".helper=" + _credentialHelper,
+	}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_PR_HTTPS_TOKEN="+token, "GIT_TERMINAL_PROMPT=0")
+	return cmd, nil
+}
+
+// command is a git command which traces its execution.
+type command struct {
+	*exec.Cmd
+
+	log *logging.Logger
+}
+
+// Run runs the command, logging its arguments, how long it took and its exit
+// status.
+func (c command) Run() error {
+	start := time.Now()
+	err := c.Cmd.Run()
+
+	fields := []logging.Field{
+		logging.Strings("args", c.Args[1:]),
+		logging.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		fields = append(fields, logging.Int("exit", exitStatus(err)), logging.Error(err))
+	} else {
+		fields = append(fields, logging.Int("exit", 0))
+	}
+	c.log.Debug("ran command", fields...)
+	return err
 }
 
 func (g *Gateway) output(args ...string) (string, error) {
@@ -282,3 +516,18 @@ func (g *Gateway) output(args ...string) (string, error) {
 	err := cmd.Run()
 	return stdout.String(), err
 }
+
+// exitStatus returns the exit status of the command that failed with the
+// given error, or -1 if the error wasn't caused by a non-zero exit.
+func exitStatus(err error) int {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return -1
+	}
+
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok {
+		return -1
+	}
+	return status.ExitStatus()
+}
@@ -1,16 +1,195 @@
 package git
 
 import (
+	"bytes"
 	"io/ioutil"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/logging"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// _commit runs git commit with an empty commit and the message that follows.
+var _commit = []string{"-c", "user.name=test", "-c", "user.email=test@example.com",
+	"commit", "--allow-empty", "-m"}
+
+// Ways to build git gateways. They must behave the same way.
+var _gateways = []struct {
+	desc string
+	new  func(dir string) (gateway.Git, error)
+}{
+	{
+		desc: "cli",
+		new: func(dir string) (gateway.Git, error) {
+			return NewGateway(dir, nil)
+		},
+	},
+	{
+		desc: "in-process",
+		new: func(dir string) (gateway.Git, error) {
+			return NewInProcessGateway(dir, nil)
+		},
+	},
+}
+
+// testGateways runs f with each kind of gateway. Every run gets a new
+// repository set up by running the given git commands in it.
+func testGateways(t *testing.T, setup [][]string, f func(*testing.T, gateway.Git)) {
+	for _, tt := range _gateways {
+		t.Run(tt.desc, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "git-pr")
+			require.NoError(t, err, "couldn't create a temporary directory")
+			defer os.RemoveAll(dir)
+
+			restore, err := chdir(dir)
+			require.NoError(t, err, "could not cd into %v", dir)
+			defer restore()
+
+			for _, args := range setup {
+				require.NoError(t, exec.Command("git", args...).Run(),
+					"failed to run git %v", args)
+			}
+
+			gw, err := tt.new(dir)
+			require.NoError(t, err, "could not set up gateway")
+			f(t, gw)
+		})
+	}
+}
+
 func TestPushNoRefs(t *testing.T) {
+	testGateways(t, [][]string{{"init"}}, func(t *testing.T, gw gateway.Git) {
+		// We don't have any remotes but that isn't a problem simply because
+		// this operation shouldn't do *anything* at all
+		err := gw.Push(&gateway.PushRequest{
+			Remote: "origin",
+			Refs:   make(map[string]string),
+			Force:  true,
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestListBranches(t *testing.T) {
+	setup := [][]string{
+		{"init"},
+		append(_commit, "initial commit"),
+		{"branch", "-m", "master"},
+		{"branch", "feature1"},
+		{"pack-refs", "--all"},
+		{"branch", "users/foo/feature2"},
+	}
+
+	testGateways(t, setup, func(t *testing.T, gw gateway.Git) {
+		branches, err := gw.ListBranches()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"feature1", "master", "users/foo/feature2"}, branches)
+
+		assert.True(t, gw.DoesBranchExist("feature1"), "packed branch must exist")
+		assert.True(t, gw.DoesBranchExist("users/foo/feature2"), "loose branch must exist")
+		assert.False(t, gw.DoesBranchExist("users/foo"), "branch must not exist")
+	})
+}
+
+func TestCurrentBranch(t *testing.T) {
+	tests := []struct {
+		desc  string
+		setup []string
+		want  string
+	}{
+		{desc: "branch", setup: []string{"checkout", "feature"}, want: "feature"},
+		{desc: "detached", setup: []string{"checkout", "--detach"}, want: "HEAD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			setup := [][]string{
+				{"init"},
+				append(_commit, "initial commit"),
+				{"branch", "feature"},
+				tt.setup,
+			}
+
+			testGateways(t, setup, func(t *testing.T, gw gateway.Git) {
+				branch, err := gw.CurrentBranch()
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, branch)
+			})
+		})
+	}
+}
+
+func TestSHA1(t *testing.T) {
+	setup := [][]string{
+		{"init"},
+		append(_commit, "initial commit"),
+		{"branch", "-m", "master"},
+		{"update-ref", "refs/remotes/origin/master", "HEAD"},
+		append(_commit, "second commit"),
+		{"branch", "feature"},
+	}
+
+	testGateways(t, setup, func(t *testing.T, gw gateway.Git) {
+		for _, ref := range []string{"master", "HEAD", "master~1", "feature", "origin/master"} {
+			out, err := exec.Command("git", "rev-parse", ref).Output()
+			require.NoError(t, err, "failed to resolve %q", ref)
+
+			sha, err := gw.SHA1(ref)
+			if assert.NoError(t, err, "failed to resolve %q", ref) {
+				assert.Equal(t, strings.TrimSpace(string(out)), sha, "SHA1(%q)", ref)
+			}
+		}
+
+		_, err := gw.SHA1("doesnotexist")
+		assert.Error(t, err)
+	})
+}
+
+func TestSHA1AfterFetch(t *testing.T) {
+	upstreamCommit := append([]string{"-C", "upstream"}, _commit...)
+	setup := [][]string{
+		{"init"},
+		append(_commit, "initial commit"),
+		{"branch", "-m", "master"},
+		// Pack local objects so that reading them indexes existing packs.
+		{"repack", "-a", "-d", "-q"},
+		{"init", "upstream"},
+		append(upstreamCommit, "upstream commit"),
+		{"-C", "upstream", "branch", "-m", "master"},
+		{"remote", "add", "origin", "upstream"},
+		// Keep fetched objects in a packfile instead of unpacking them.
+		{"config", "fetch.unpackLimit", "1"},
+	}
+
+	testGateways(t, setup, func(t *testing.T, gw gateway.Git) {
+		_, err := gw.SHA1("HEAD")
+		require.NoError(t, err)
+
+		require.NoError(t, gw.Fetch(&gateway.FetchRequest{
+			Remote:    "origin",
+			RemoteRef: "master",
+			LocalRef:  "refs/remotes/origin/master",
+		}))
+
+		out, err := exec.Command("git", "rev-parse", "origin/master").Output()
+		require.NoError(t, err, "failed to resolve origin/master")
+
+		sha, err := gw.SHA1("origin/master")
+		require.NoError(t, err, "failed to resolve fetched ref")
+		assert.Equal(t, strings.TrimSpace(string(out)), sha)
+	})
+}
+
+func TestSetHTTPSToken(t *testing.T) {
 	dir, err := ioutil.TempDir("", "git-pr")
 	require.NoError(t, err, "couldn't create a temporary directory")
 	defer os.RemoveAll(dir)
@@ -19,20 +198,278 @@ func TestPushNoRefs(t *testing.T) {
 	require.NoError(t, err, "could not cd into %v", dir)
 	defer restore()
 
-	require.NoError(t, exec.Command("git", "init").Run(),
-		"failed to set up git repo")
+	execPath, err := exec.Command("git", "--exec-path").Output()
+	require.NoError(t, err, "failed to find git-http-backend")
 
-	gw, err := NewGateway(dir)
+	// Serve the repositories in dir over HTTPS to requests which use the
+	// token "secret".
+	backend := &cgi.Handler{
+		Path: filepath.Join(strings.TrimSpace(string(execPath)), "git-http-backend"),
+		Env:  []string{"GIT_PROJECT_ROOT=" + dir, "GIT_HTTP_EXPORT_ALL=1"},
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "x-access-token" || pass != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			http.Error(w, "bad credentials", http.StatusUnauthorized)
+			return
+		}
+		backend.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	for key, value := range map[string]string{
+		"GIT_SSL_NO_VERIFY":   "1",
+		"GIT_TERMINAL_PROMPT": "0",
+	} {
+		restore, err := setenv(key, value)
+		require.NoError(t, err, "could not set $%v", key)
+		defer restore()
+	}
+
+	setup := [][]string{
+		{"init", "--bare", "upstream.git"},
+		{"-C", "upstream.git", "config", "http.receivepack", "true"},
+		{"init", "work"},
+		append([]string{"-C", "work"}, append(_commit, "initial commit")...),
+		{"-C", "work", "branch", "-m", "master"},
+		{"-C", "work", "remote", "add", "origin", server.URL + "/upstream.git"},
+		// Credential helpers configured by the user must not be asked.
+		{"-C", "work", "config", "credential.helper", "!echo username=x-access-token; echo password=wrong"},
+	}
+	for _, args := range setup {
+		require.NoError(t, exec.Command("git", args...).Run(),
+			"failed to run git %v", args)
+	}
+
+	gw, err := NewGateway(filepath.Join(dir, "work"), nil)
 	require.NoError(t, err, "could not set up gateway")
 
-	// We don't have any remotes but that isn't a problem simply because this
-	// operation shouldn't do *anything* at all
-	err = gw.Push(&gateway.PushRequest{
-		Remote: "origin",
-		Refs:   make(map[string]string),
-		Force:  true,
+	push := &gateway.PushRequest{Remote: "origin", Refs: map[string]string{"master": ""}}
+	require.Error(t, gw.Push(push), "push without a token must fail")
+
+	gw.SetHTTPSToken(strings.TrimPrefix(server.URL, "https://"), func() (string, error) {
+		return "secret", nil
 	})
+	require.NoError(t, gw.Push(push))
+	assert.NoError(t, gw.Fetch(&gateway.FetchRequest{
+		Remote:    "origin",
+		RemoteRef: "master",
+		LocalRef:  "refs/remotes/origin/master",
+	}))
+
+	want, err := gw.SHA1("master")
 	require.NoError(t, err)
+	got, err := gw.SHA1("origin/master")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestCheckout(t *testing.T) {
+	setup := [][]string{
+		{"init"},
+		append(_commit, "initial commit"),
+		{"branch", "-m", "master"},
+	}
+
+	testGateways(t, setup, func(t *testing.T, gw gateway.Git) {
+		err := gw.Checkout("missing")
+		require.Error(t, err, "checking out a missing branch must fail")
+		assert.Contains(t, err.Error(), `failed to checkout branch "missing"`)
+
+		// feature has a file which is untracked on master.
+		require.NoError(t, ioutil.WriteFile("file", []byte("feature"), 0644))
+		for _, args := range [][]string{
+			{"checkout", "-q", "-b", "feature"},
+			{"add", "file"},
+			append(_commit, "add file"),
+			{"checkout", "-q", "master"},
+		} {
+			require.NoError(t, exec.Command("git", args...).Run(),
+				"failed to run git %v", args)
+		}
+
+		require.NoError(t, ioutil.WriteFile("file", []byte("local changes"), 0644))
+		assert.Error(t, gw.Checkout("feature"),
+			"checkout must fail if it would overwrite local changes")
+
+		branch, err := gw.CurrentBranch()
+		require.NoError(t, err)
+		assert.Equal(t, "master", branch)
+	})
+}
+
+func TestFastForward(t *testing.T) {
+	setup := [][]string{
+		{"init"},
+		append(_commit, "initial commit"),
+		{"branch", "-m", "master"},
+		{"branch", "upstream"},
+		{"branch", "diverged"},
+		{"checkout", "-q", "upstream"},
+		append(_commit, "upstream commit"),
+		{"checkout", "-q", "diverged"},
+		append(_commit, "diverged commit"),
+		{"checkout", "-q", "master"},
+	}
+
+	testGateways(t, setup, func(t *testing.T, gw gateway.Git) {
+		want, err := gw.SHA1("upstream")
+		require.NoError(t, err)
+
+		ok, err := gw.FastForward("upstream")
+		require.NoError(t, err)
+		assert.True(t, ok, "master must be fast-forwarded")
+
+		got, err := gw.SHA1("master")
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+
+		require.NoError(t, gw.Checkout("diverged"))
+		ok, err = gw.FastForward("upstream")
+		require.NoError(t, err)
+		assert.False(t, ok, "diverged branch must not be fast-forwarded")
+	})
+}
+
+func TestRemoteURL(t *testing.T) {
+	home, err := ioutil.TempDir("", "git-pr-home")
+	require.NoError(t, err, "couldn't create a temporary directory")
+	defer os.RemoveAll(home)
+
+	restore, err := setenv("HOME", home)
+	require.NoError(t, err, "could not set $HOME")
+	defer restore()
+
+	restore, err = setenv("XDG_CONFIG_HOME", "")
+	require.NoError(t, err, "could not unset $XDG_CONFIG_HOME")
+	defer restore()
+
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(home, ".gitconfig"),
+		[]byte("[url \"https://github.com/\"]\n\tinsteadOf = gh:\n"),
+		0644,
+	), "failed to write global git-config")
+
+	setup := [][]string{
+		{"init"},
+		{"remote", "add", "origin", "git@github.com:abhinav/git-pr.git"},
+		{"remote", "add", "upstream", "gh:abhinav/git-pr.git"},
+		{"remote", "add", "zap", "gh:uber-go/zap.git"},
+		{"config", "url.https://mirror.example.com/.insteadOf", "gh:abhinav/"},
+	}
+
+	testGateways(t, setup, func(t *testing.T, gw gateway.Git) {
+		tests := []struct {
+			remote string
+			want   string
+		}{
+			{remote: "origin", want: "git@github.com:abhinav/git-pr.git"},
+			{remote: "upstream", want: "https://mirror.example.com/git-pr.git"},
+			{remote: "zap", want: "https://github.com/uber-go/zap.git"},
+		}
+
+		for _, tt := range tests {
+			url, err := gw.RemoteURL(tt.remote)
+			if assert.NoError(t, err, "RemoteURL(%q)", tt.remote) {
+				assert.Equal(t, tt.want, url, "RemoteURL(%q)", tt.remote)
+			}
+		}
+
+		_, err := gw.RemoteURL("doesnotexist")
+		assert.Error(t, err)
+	})
+}
+
+func TestCommonDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-pr")
+	require.NoError(t, err, "couldn't create a temporary directory")
+	defer os.RemoveAll(dir)
+
+	restore, err := chdir(dir)
+	require.NoError(t, err, "could not cd into %v", dir)
+	defer restore()
+
+	setup := [][]string{
+		{"init", "main"},
+		append([]string{"-C", "main"}, append(_commit, "initial commit")...),
+		{"-C", "main", "worktree", "add", "-q", "-b", "feature", "../linked"},
+	}
+	for _, args := range setup {
+		require.NoError(t, exec.Command("git", args...).Run(),
+			"failed to run git %v", args)
+	}
+
+	for _, worktree := range []string{"main", "linked"} {
+		t.Run(worktree, func(t *testing.T) {
+			gw, err := NewGateway(filepath.Join(dir, worktree), nil)
+			require.NoError(t, err, "could not set up gateway")
+
+			got, err := gw.CommonDir()
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(dir, "main", ".git"), filepath.Clean(got))
+		})
+	}
+}
+
+func TestForkPoint(t *testing.T) {
+	setup := [][]string{
+		{"init"},
+		append(_commit, "initial commit"),
+		{"branch", "-m", "master"},
+		{"checkout", "-b", "feature"},
+		append(_commit, "feature commit"),
+		{"checkout", "master"},
+		append(_commit, "master commit"),
+	}
+
+	testGateways(t, setup, func(t *testing.T, gw gateway.Git) {
+		want, err := gw.SHA1("master~1")
+		require.NoError(t, err)
+
+		got, err := gw.ForkPoint("master", "feature")
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+}
+
+func TestConfig(t *testing.T) {
+	testGateways(t, [][]string{{"init"}}, func(t *testing.T, gw gateway.Git) {
+		value, err := gw.GetConfig("branch.foo.git-pr-parent")
+		require.NoError(t, err, "unset keys must not fail")
+		assert.Empty(t, value)
+
+		items, err := gw.ListConfig(`^branch\..*\.git-pr-parent$`)
+		require.NoError(t, err, "unmatched patterns must not fail")
+		assert.Empty(t, items)
+
+		require.NoError(t, gw.SetConfig("branch.foo.git-pr-parent", "master"))
+		require.NoError(t, gw.SetConfig("branch.bar/baz.git-pr-parent", "foo"))
+
+		value, err = gw.GetConfig("branch.foo.git-pr-parent")
+		require.NoError(t, err)
+		assert.Equal(t, "master", value)
+
+		items, err = gw.ListConfig(`^branch\..*\.git-pr-parent$`)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"branch.foo.git-pr-parent":     "master",
+			"branch.bar/baz.git-pr-parent": "foo",
+		}, items)
+
+		f, err := os.OpenFile(filepath.Join(".git", "config"), os.O_APPEND|os.O_WRONLY, 0)
+		require.NoError(t, err)
+		_, err = f.WriteString("[git-pr]\n\tflag\n\tempty =\n\tmultiline = \"foo\\nbar\"\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		items, err = gw.ListConfig(`^git-pr\.`)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"git-pr.flag":      "true",
+			"git-pr.empty":     "",
+			"git-pr.multiline": "foo\nbar",
+		}, items, "keys without values must be true")
+	})
 }
 
 func chdir(dir string) (restore func(), _ error) {
@@ -47,3 +484,42 @@ func chdir(dir string) (restore func(), _ error) {
 
 	return func() { os.Chdir(oldDir) }, nil
 }
+
+func setenv(key, value string) (restore func(), _ error) {
+	oldValue, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if ok {
+			os.Setenv(key, oldValue)
+		} else {
+			os.Unsetenv(key)
+		}
+	}, nil
+}
+
+func TestGatewayTracesCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-pr")
+	require.NoError(t, err, "couldn't create a temporary directory")
+	defer os.RemoveAll(dir)
+
+	restore, err := chdir(dir)
+	require.NoError(t, err, "could not cd into %v", dir)
+	defer restore()
+
+	require.NoError(t, exec.Command("git", "init").Run(),
+		"failed to set up git repo")
+
+	var buf bytes.Buffer
+	gw, err := NewGateway(dir, logging.New(&buf, logging.Debug))
+	require.NoError(t, err, "could not set up gateway")
+
+	_, err = gw.SHA1("doesnotexist")
+	require.Error(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, `[debug] ran command args="rev-parse --verify -q doesnotexist"`)
+	assert.Contains(t, out, "exit=1")
+}
@@ -0,0 +1,311 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/logging"
+
+	gogit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	format "gopkg.in/src-d/go-git.v4/plumbing/format/config"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// InProcessGateway is a git gateway which looks up refs and remotes with a
+// pure-Go implementation of git instead of running git for each lookup.
+// Everything else, including rebases and pushes, runs git like Gateway does.
+//
+// go-git indexes packfiles only once so methods that change the repository
+// make it index them again after running git.
+//
+// Revisions passed to SHA1 are peeled to the commit they point to. Remote
+// URLs are rewritten with url.<base>.insteadOf from the repository's and the
+// user's git-config only; the system git-config and included files are not
+// consulted.
+type InProcessGateway struct {
+	*Gateway
+
+	// go-git repositories are not safe for concurrent use.
+	repoMu sync.Mutex
+	repo   *gogit.Repository
+}
+
+var _ gateway.Git = (*InProcessGateway)(nil)
+
+// NewInProcessGateway builds a new Git gateway which reads from the
+// repository in-process. Commands that still run git are traced to log at the
+// Debug level. log may be nil.
+//
+// An error is returned if the repository can't be read in-process. Use
+// NewGateway for such repositories.
+func NewInProcessGateway(startDir string, log *logging.Logger) (*InProcessGateway, error) {
+	gw, err := NewGateway(startDir, log)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := gogit.PlainOpen(gw.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository at %v: %v", gw.dir, err)
+	}
+
+	// Refs of linked worktrees live in the main repository, which go-git
+	// doesn't know how to find.
+	if s, ok := repo.Storer.(*filesystem.Storage); ok {
+		if _, err := s.Filesystem().Stat("commondir"); err == nil {
+			return nil, fmt.Errorf(
+				"failed to open git repository at %v: linked worktrees are not supported", gw.dir)
+		}
+	}
+
+	return &InProcessGateway{Gateway: gw, repo: repo}, nil
+}
+
+// lock prevents changes to the repository and concurrent use of go-git until
+// the returned function is called.
+func (g *InProcessGateway) lock() (unlock func()) {
+	g.mu.RLock()
+	g.repoMu.Lock()
+	return func() {
+		g.repoMu.Unlock()
+		g.mu.RUnlock()
+	}
+}
+
+// reindex makes go-git index packfiles again the next time an object is
+// read. git may have added or removed packfiles since they were indexed.
+func (g *InProcessGateway) reindex() {
+	g.repoMu.Lock()
+	defer g.repoMu.Unlock()
+
+	if s, ok := g.repo.Storer.(*filesystem.Storage); ok {
+		s.Reindex()
+	}
+}
+
+// CreateBranchAndCheckout creates a branch with the given name and head and
+// switches to it.
+func (g *InProcessGateway) CreateBranchAndCheckout(name, head string) error {
+	defer g.reindex()
+	return g.Gateway.CreateBranchAndCheckout(name, head)
+}
+
+// CreateBranch creates a branch with the given name and head but does not
+// check it out.
+func (g *InProcessGateway) CreateBranch(name, head string) error {
+	defer g.reindex()
+	return g.Gateway.CreateBranch(name, head)
+}
+
+// DeleteBranch deletes the given branch.
+func (g *InProcessGateway) DeleteBranch(name string) error {
+	defer g.reindex()
+	return g.Gateway.DeleteBranch(name)
+}
+
+// DeleteRemoteTrackingBranch deletes the remote tracking branch with the
+// given name.
+func (g *InProcessGateway) DeleteRemoteTrackingBranch(remote, name string) error {
+	defer g.reindex()
+	return g.Gateway.DeleteRemoteTrackingBranch(remote, name)
+}
+
+// Checkout checks the given branch out.
+func (g *InProcessGateway) Checkout(name string) error {
+	defer g.reindex()
+	return g.Gateway.Checkout(name)
+}
+
+// Fetch a git ref
+func (g *InProcessGateway) Fetch(req *gateway.FetchRequest) error {
+	defer g.reindex()
+	return g.Gateway.Fetch(req)
+}
+
+// Push pushes refs to a remote.
+func (g *InProcessGateway) Push(req *gateway.PushRequest) error {
+	defer g.reindex()
+	return g.Gateway.Push(req)
+}
+
+// Pull pulls the given branch.
+func (g *InProcessGateway) Pull(remote, name string) error {
+	defer g.reindex()
+	return g.Gateway.Pull(remote, name)
+}
+
+// FastForward fast-forwards the current branch to the given ref.
+func (g *InProcessGateway) FastForward(ref string) (bool, error) {
+	defer g.reindex()
+	return g.Gateway.FastForward(ref)
+}
+
+// Rebase a branch.
+func (g *InProcessGateway) Rebase(req *gateway.RebaseRequest) error {
+	defer g.reindex()
+	return g.Gateway.Rebase(req)
+}
+
+// ResetBranch resets the given branch to the given head.
+func (g *InProcessGateway) ResetBranch(branch, head string) error {
+	defer g.reindex()
+	return g.Gateway.ResetBranch(branch, head)
+}
+
+// CurrentBranch determines the current branch name. "HEAD" is returned if
+// HEAD is detached.
+func (g *InProcessGateway) CurrentBranch() (string, error) {
+	defer g.lock()()
+
+	head, err := g.repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return "", fmt.Errorf("could not determine current branch: %v", err)
+	}
+
+	if head.Type() != plumbing.SymbolicReference {
+		return "HEAD", nil
+	}
+	if !head.Target().IsBranch() {
+		return "", fmt.Errorf(
+			"could not determine current branch: HEAD points to %v", head.Target())
+	}
+	return strings.TrimPrefix(string(head.Target()), "refs/heads/"), nil
+}
+
+// DoesBranchExist checks if this branch exists locally.
+func (g *InProcessGateway) DoesBranchExist(name string) bool {
+	defer g.lock()()
+
+	_, err := g.repo.Reference(plumbing.NewBranchReferenceName(name), false)
+	return err == nil
+}
+
+// ListBranches lists the names of all local branches.
+func (g *InProcessGateway) ListBranches() ([]string, error) {
+	defer g.lock()()
+
+	refs, err := g.repo.Branches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %v", err)
+	}
+
+	var branches []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		branches = append(branches, strings.TrimPrefix(string(ref.Name()), "refs/heads/"))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %v", err)
+	}
+
+	sort.Strings(branches)
+	return branches, nil
+}
+
+// SHA1 gets the SHA1 hash for the given ref.
+func (g *InProcessGateway) SHA1(ref string) (string, error) {
+	defer g.lock()()
+
+	hash, err := g.repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return "", fmt.Errorf("could not resolve ref %q: %v", ref, err)
+	}
+	return hash.String(), nil
+}
+
+// RemoteURL gets the URL for the given remote.
+func (g *InProcessGateway) RemoteURL(name string) (string, error) {
+	defer g.lock()()
+
+	cfg, err := g.repo.Config()
+	if err != nil {
+		return "", fmt.Errorf("failed to get URL for remote %q: %v", name, err)
+	}
+
+	remote, ok := cfg.Remotes[name]
+	if !ok || len(remote.URLs) == 0 {
+		return "", fmt.Errorf("failed to get URL for remote %q: no such remote", name)
+	}
+
+	configs := []*format.Config{cfg.Raw}
+	for _, path := range globalConfigPaths() {
+		global, err := readConfig(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to get URL for remote %q: %v", name, err)
+		}
+		configs = append(configs, global)
+	}
+	return insteadOf(remote.URLs[0], configs...), nil
+}
+
+// globalConfigPaths returns the paths at which the user's git-config may be
+// found.
+func globalConfigPaths() []string {
+	home := os.Getenv("HOME")
+
+	xdgDir := os.Getenv("XDG_CONFIG_HOME")
+	if xdgDir == "" && home != "" {
+		xdgDir = filepath.Join(home, ".config")
+	}
+
+	var paths []string
+	if xdgDir != "" {
+		paths = append(paths, filepath.Join(xdgDir, "git", "config"))
+	}
+	if home != "" {
+		paths = append(paths, filepath.Join(home, ".gitconfig"))
+	}
+	return paths
+}
+
+// readConfig reads the git-config file at path. An empty configuration is
+// returned if the file doesn't exist.
+func readConfig(path string) (*format.Config, error) {
+	cfg := format.New()
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := format.NewDecoder(f).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %v: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// insteadOf rewrites url with the longest matching url.<base>.insteadOf
+// prefix in the given configurations.
+func insteadOf(url string, configs ...*format.Config) string {
+	var base, prefix string
+	for _, cfg := range configs {
+		for _, section := range cfg.Sections {
+			if !section.IsName("url") {
+				continue
+			}
+
+			for _, sub := range section.Subsections {
+				for _, p := range sub.Options.GetAll("insteadOf") {
+					if len(p) > len(prefix) && strings.HasPrefix(url, p) {
+						base, prefix = sub.Name, p
+					}
+				}
+			}
+		}
+	}
+
+	if prefix == "" {
+		return url
+	}
+	return base + strings.TrimPrefix(url, prefix)
+}
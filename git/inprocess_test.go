@@ -0,0 +1,37 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInProcessGatewayLinkedWorktree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-pr")
+	require.NoError(t, err, "couldn't create a temporary directory")
+	defer os.RemoveAll(dir)
+
+	repoDir := filepath.Join(dir, "repo")
+	worktreeDir := filepath.Join(dir, "worktree")
+	for _, args := range [][]string{
+		{"init", repoDir},
+		append([]string{"-C", repoDir}, append(_commit, "initial commit")...),
+		{"-C", repoDir, "worktree", "add", "-b", "feature", worktreeDir},
+	} {
+		require.NoError(t, exec.Command("git", args...).Run(),
+			"failed to run git %v", args)
+	}
+
+	_, err = NewInProcessGateway(repoDir, nil)
+	assert.NoError(t, err, "main working tree must be supported")
+
+	_, err = NewInProcessGateway(worktreeDir, nil)
+	if assert.Error(t, err, "linked worktrees must not be supported") {
+		assert.Contains(t, err.Error(), "linked worktrees are not supported")
+	}
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/abhinav/git-pr/gateway"
+)
+
+// Relationships between local branches are cached in the git-config of the
+// repository so that stacks may be navigated without access to GitHub.
+//
+// 	[branch "feature2"]
+// 		git-pr-parent = feature1
+
+const (
+	_parentKeySuffix  = ".git-pr-parent"
+	_parentKeyPattern = `^branch\..*\.git-pr-parent$`
+)
+
+func parentKey(branch string) string {
+	return "branch." + branch + _parentKeySuffix
+}
+
+// Parent returns the locally recorded parent of the given branch. An empty
+// string is returned if a parent was not recorded.
+func Parent(g gateway.Git, branch string) (string, error) {
+	parent, err := g.GetConfig(parentKey(branch))
+	if err != nil {
+		return "", fmt.Errorf("could not determine parent of %q: %v", branch, err)
+	}
+	return parent, nil
+}
+
+// SetParent records parent as the parent of the given branch.
+func SetParent(g gateway.Git, branch, parent string) error {
+	old, err := Parent(g, branch)
+	if err != nil {
+		return err
+	}
+
+	// Avoid rewriting the config if nothing changed.
+	if old == parent {
+		return nil
+	}
+
+	if err := g.SetConfig(parentKey(branch), parent); err != nil {
+		return fmt.Errorf("could not record %q as the parent of %q: %v", parent, branch, err)
+	}
+	return nil
+}
+
+// ReplaceParent records newParent as the parent of the branches whose
+// recorded parent is oldParent. Call this before deleting oldParent so that
+// its children stay in the stack.
+func ReplaceParent(g gateway.Git, oldParent, newParent string) error {
+	children, err := Children(g, oldParent)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		if err := SetParent(g, child, newParent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Children returns the names of branches whose locally recorded parent is
+// the given branch. The names are sorted.
+func Children(g gateway.Git, branch string) ([]string, error) {
+	parents, err := Parents(g)
+	if err != nil {
+		return nil, fmt.Errorf("could not determine children of %q: %v", branch, err)
+	}
+
+	var children []string
+	for name, parent := range parents {
+		if parent == branch {
+			children = append(children, name)
+		}
+	}
+	sort.Strings(children)
+	return children, nil
+}
+
+// Parents returns all locally recorded relationships between branches as a
+// map from branch name to the name of its parent.
+func Parents(g gateway.Git) (map[string]string, error) {
+	items, err := g.ListConfig(_parentKeyPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make(map[string]string, len(items))
+	for key, parent := range items {
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "branch."), _parentKeySuffix)
+		parents[name] = parent
+	}
+	return parents, nil
+}
@@ -0,0 +1,117 @@
+package git
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParent(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	git := gatewaytest.NewMockGit(mockCtrl)
+	git.EXPECT().GetConfig("branch.feature2.git-pr-parent").Return("feature1", nil)
+	git.EXPECT().GetConfig("branch.feature1.git-pr-parent").Return("", nil)
+	git.EXPECT().GetConfig("branch.feature3.git-pr-parent").
+		Return("", errors.New("great sadness"))
+
+	parent, err := Parent(git, "feature2")
+	require.NoError(t, err)
+	assert.Equal(t, "feature1", parent)
+
+	parent, err = Parent(git, "feature1")
+	require.NoError(t, err)
+	assert.Empty(t, parent)
+
+	_, err = Parent(git, "feature3")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "great sadness")
+}
+
+func TestSetParent(t *testing.T) {
+	t.Run("unchanged", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		git := gatewaytest.NewMockGit(mockCtrl)
+		git.EXPECT().GetConfig("branch.feature2.git-pr-parent").Return("feature1", nil)
+
+		require.NoError(t, SetParent(git, "feature2", "feature1"))
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		git := gatewaytest.NewMockGit(mockCtrl)
+		git.EXPECT().GetConfig("branch.feature2.git-pr-parent").Return("feature1", nil)
+		git.EXPECT().SetConfig("branch.feature2.git-pr-parent", "master").Return(nil)
+
+		require.NoError(t, SetParent(git, "feature2", "master"))
+	})
+}
+
+func TestReplaceParent(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	git := gatewaytest.NewMockGit(mockCtrl)
+	git.EXPECT().ListConfig(`^branch\..*\.git-pr-parent$`).Return(map[string]string{
+		"branch.feature1.git-pr-parent": "master",
+		"branch.feature2.git-pr-parent": "feature1",
+		"branch.feature3.git-pr-parent": "feature1",
+		"branch.feature4.git-pr-parent": "feature2",
+	}, nil)
+	git.EXPECT().GetConfig("branch.feature2.git-pr-parent").Return("feature1", nil)
+	git.EXPECT().SetConfig("branch.feature2.git-pr-parent", "master").Return(nil)
+	git.EXPECT().GetConfig("branch.feature3.git-pr-parent").Return("feature1", nil)
+	git.EXPECT().SetConfig("branch.feature3.git-pr-parent", "master").Return(nil)
+
+	require.NoError(t, ReplaceParent(git, "feature1", "master"))
+}
+
+func TestChildren(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	git := gatewaytest.NewMockGit(mockCtrl)
+	git.EXPECT().ListConfig(`^branch\..*\.git-pr-parent$`).Return(map[string]string{
+		"branch.feature1.git-pr-parent":      "master",
+		"branch.feature3.git-pr-parent":      "feature1",
+		"branch.users/foo/bar.git-pr-parent": "feature1",
+		"branch.feature2.git-pr-parent":      "feature1",
+		"branch.other-feature.git-pr-parent": "feature2",
+	}, nil).AnyTimes()
+
+	children, err := Children(git, "feature1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feature2", "feature3", "users/foo/bar"}, children)
+
+	children, err = Children(git, "feature3")
+	require.NoError(t, err)
+	assert.Empty(t, children)
+}
+
+func TestParents(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	git := gatewaytest.NewMockGit(mockCtrl)
+	git.EXPECT().ListConfig(`^branch\..*\.git-pr-parent$`).Return(map[string]string{
+		"branch.feature1.git-pr-parent":      "master",
+		"branch.users/foo/bar.git-pr-parent": "feature1",
+	}, nil)
+
+	parents, err := Parents(git)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"feature1":      "master",
+		"users/foo/bar": "feature1",
+	}, parents)
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/logging"
 
 	"go.uber.org/multierr"
 )
@@ -14,7 +15,7 @@ import (
 // changes are made to existing branches. Callers can commit changes by
 // retrieving information from RebaseHandles.
 //
-// 	r := NewBulkRebaser(g)
+// 	r := NewBulkRebaser(g, nil)
 // 	defer r.Cleanup()
 // 	h := r.Onto("origin/master").Rebase("master", "myfeature")
 // 	if err := r.Err(); err != nil {
@@ -23,6 +24,7 @@ import (
 // 	g.ResetBranch("myfeature", h.Base())
 type BulkRebaser struct {
 	git gateway.Git
+	log *logging.Logger
 
 	errorsMu sync.Mutex
 	errors   []error
@@ -36,9 +38,10 @@ type BulkRebaser struct {
 }
 
 // NewBulkRebaser builds a new Bulk Rebaser.
-func NewBulkRebaser(g gateway.Git) *BulkRebaser {
+func NewBulkRebaser(g gateway.Git, log *logging.Logger) *BulkRebaser {
 	return &BulkRebaser{
 		git:                  g,
+		log:                  log,
 		tempBranches:         list.New(),
 		checkoutUniqueBranch: CheckoutUniqueBranch,
 	}
@@ -76,6 +79,9 @@ func (br *BulkRebaser) checkoutTemporaryBranch(parent, ref string) (string, erro
 		Parent: parent,
 	})
 	br.tempBranchesMu.Unlock()
+
+	br.log.Debug("created temporary branch",
+		logging.String("branch", name), logging.String("ref", ref))
 	return name, nil
 }
 
@@ -88,6 +94,7 @@ func (br *BulkRebaser) Cleanup() (err error) {
 
 	for br.tempBranches.Len() > 0 {
 		b := br.tempBranches.Remove(br.tempBranches.Back()).(temporaryBranch)
+		br.log.Debug("deleting temporary branch", logging.String("branch", b.Name))
 		e := br.git.Checkout(b.Parent)
 		if e == nil {
 			e = br.git.DeleteBranch(b.Name)
@@ -174,7 +181,12 @@ func (h rebaseHandle) Rebase(fromRef, toRef string) RebaseHandle {
 	}
 
 	req := gateway.RebaseRequest{Onto: h.base, From: fromRef, Branch: branch}
+	br.log.Debug("rebasing",
+		logging.String("onto", req.Onto),
+		logging.String("from", req.From),
+		logging.String("branch", req.Branch))
 	if err := br.git.Rebase(&req); err != nil {
+		br.log.Debug("rebase failed", logging.String("branch", branch), logging.Error(err))
 		br.recordError(err)
 		return rebaseHandle{err: err}
 	}
@@ -296,7 +296,7 @@ func TestBulkRebaser(t *testing.T) {
 			}
 			gomock.InOrder(deletions...)
 
-			rebaser := NewBulkRebaser(gw)
+			rebaser := NewBulkRebaser(gw, nil)
 			rebaser.checkoutUniqueBranch = checkoutUniqueBranchAlwaysSuccessful
 
 			defer func() {
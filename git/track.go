@@ -0,0 +1,25 @@
+package git
+
+import (
+	"fmt"
+
+	"github.com/abhinav/git-pr/gateway"
+)
+
+// SetUpstream configures branch to track the local branch upstream. This
+// lets commands like "git status" and "git rebase" work against the branch
+// that the given branch was created from.
+//
+// 	[branch "feature2"]
+// 		remote = .
+// 		merge = refs/heads/feature1
+func SetUpstream(g gateway.Git, branch, upstream string) error {
+	prefix := "branch." + branch
+	if err := g.SetConfig(prefix+".remote", "."); err != nil {
+		return fmt.Errorf("could not set upstream of %q to %q: %v", branch, upstream, err)
+	}
+	if err := g.SetConfig(prefix+".merge", "refs/heads/"+upstream); err != nil {
+		return fmt.Errorf("could not set upstream of %q to %q: %v", branch, upstream, err)
+	}
+	return nil
+}
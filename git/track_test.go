@@ -0,0 +1,38 @@
+package git
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetUpstream(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		git := gatewaytest.NewMockGit(mockCtrl)
+		git.EXPECT().SetConfig("branch.feature2.remote", ".").Return(nil)
+		git.EXPECT().SetConfig("branch.feature2.merge", "refs/heads/feature1").Return(nil)
+
+		require.NoError(t, SetUpstream(git, "feature2", "feature1"))
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		git := gatewaytest.NewMockGit(mockCtrl)
+		git.EXPECT().SetConfig("branch.feature2.remote", ".").
+			Return(errors.New("great sadness"))
+
+		err := SetUpstream(git, "feature2", "feature1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "great sadness")
+	})
+}
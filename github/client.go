@@ -0,0 +1,44 @@
+package github
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/abhinav/git-pr/logging"
+
+	"github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+)
+
+// DefaultHost is the hostname of github.com.
+const DefaultHost = "github.com"
+
+// APIURL returns the base URL of the API for the given host. Hosts other than
+// DefaultHost are treated as GitHub Enterprise installations.
+func APIURL(host string) string {
+	if host == "" || host == DefaultHost {
+		return "https://api.github.com/"
+	}
+	return "https://" + host + "/api/v3/"
+}
+
+// NewClient builds a GitHub client for the given host which authenticates
+// with token. Requests are traced to log.
+func NewClient(ctx context.Context, host, token string, log *logging.Logger) *github.Client {
+	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	return NewClientFromTokenSource(ctx, host, tokenSource, log)
+}
+
+// NewClientFromTokenSource builds a GitHub client for the given host which
+// authenticates with tokens from tokenSource. Requests are traced to log.
+func NewClientFromTokenSource(ctx context.Context, host string, tokenSource oauth2.TokenSource, log *logging.Logger) *github.Client {
+	httpClient := oauth2.NewClient(ctx, tokenSource)
+	httpClient.Transport = NewLoggingTransport(httpClient.Transport, log)
+
+	client := github.NewClient(httpClient)
+	if host != "" && host != DefaultHost {
+		client.BaseURL, _ = url.Parse(APIURL(host))
+		client.UploadURL, _ = url.Parse("https://" + host + "/api/uploads/")
+	}
+	return client
+}
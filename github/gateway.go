@@ -30,6 +30,11 @@ type PullRequestsService interface {
 		pull *github.PullRequest,
 	) (*github.PullRequest, *github.Response, error)
 
+	Get(
+		ctx context.Context,
+		owner string, repo string, number int,
+	) (*github.PullRequest, *github.Response, error)
+
 	GetRaw(
 		ctx context.Context,
 		owner string, repo string, number int, opt github.RawOptions,
@@ -40,6 +45,11 @@ type PullRequestsService interface {
 		owner string, repo string, opt *github.PullRequestListOptions,
 	) ([]*github.PullRequest, *github.Response, error)
 
+	ListCommits(
+		ctx context.Context,
+		owner string, repo string, number int, opt *github.ListOptions,
+	) ([]*github.RepositoryCommit, *github.Response, error)
+
 	ListReviews(
 		ctx context.Context,
 		owner, repo string, number int,
@@ -64,14 +74,23 @@ type RepositoriesService interface {
 
 var _ RepositoriesService = (*github.RepositoriesService)(nil)
 
+// UsersService is a subset of the GitHub Users API.
+type UsersService interface {
+	Get(ctx context.Context, user string) (*github.User, *github.Response, error)
+}
+
+var _ UsersService = (*github.UsersService)(nil)
+
 // Gateway is a GitHub gateway that makes actual requests to GitHub.
 type Gateway struct {
+	host  string
 	owner string
 	repo  string
 
 	git   GitService
 	pulls PullRequestsService
 	repos RepositoriesService
+	users UsersService
 }
 
 var _ gateway.GitHub = (*Gateway)(nil)
@@ -80,16 +99,22 @@ var _ gateway.GitHub = (*Gateway)(nil)
 // repository.
 func NewGatewayForRepository(client *github.Client, repo *repo.Repo) *Gateway {
 	return &Gateway{
+		host:  repo.Host,
 		owner: repo.Owner,
 		repo:  repo.Name,
 		pulls: client.PullRequests,
 		repos: client.Repositories,
+		users: client.Users,
 		git:   client.Git,
 	}
 }
 
 func (g *Gateway) urlFor(number int) string {
-	return fmt.Sprintf("https://github.com/%v/%v/pull/%v", g.owner, g.repo, number)
+	host := g.host
+	if host == "" {
+		host = DefaultHost
+	}
+	return fmt.Sprintf("https://%v/%v/%v/pull/%v", host, g.owner, g.repo, number)
 }
 
 // IsOwned checks if this branch is local to this repository.
@@ -97,6 +122,21 @@ func (g *Gateway) IsOwned(ctx context.Context, br *github.PullRequestBranch) boo
 	return *br.Repo.Owner.Login == g.owner && *br.Repo.Name == g.repo
 }
 
+// GetUser retrieves the profile of the user with the given login.
+func (g *Gateway) GetUser(ctx context.Context, login string) (*gateway.User, error) {
+	user, _, err := g.users.Get(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GitHub user %q: %v", login, err)
+	}
+
+	return &gateway.User{
+		ID:    user.GetID(),
+		Login: user.GetLogin(),
+		Name:  user.GetName(),
+		Email: user.GetEmail(),
+	}, nil
+}
+
 // ListPullRequestReviews lists reviews for a pull request.
 func (g *Gateway) ListPullRequestReviews(ctx context.Context, number int) ([]*gateway.PullRequestReview, error) {
 	reviews, _, err := g.pulls.ListReviews(ctx, g.owner, g.repo, number)
@@ -114,6 +154,33 @@ func (g *Gateway) ListPullRequestReviews(ctx context.Context, number int) ([]*ga
 	return result, nil
 }
 
+// ListPullRequestCommits lists the commits that are part of a pull request.
+func (g *Gateway) ListPullRequestCommits(ctx context.Context, number int) ([]*gateway.PullRequestCommit, error) {
+	var result []*gateway.PullRequestCommit
+	opt := github.ListOptions{PerPage: 100}
+	for {
+		commits, res, err := g.pulls.ListCommits(ctx, g.owner, g.repo, number, &opt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits for %v: %v", g.urlFor(number), err)
+		}
+
+		for _, c := range commits {
+			author := c.GetCommit().GetAuthor()
+			result = append(result, &gateway.PullRequestCommit{
+				SHA:         c.GetSHA(),
+				AuthorName:  author.GetName(),
+				AuthorEmail: author.GetEmail(),
+				AuthorLogin: c.GetAuthor().GetLogin(),
+			})
+		}
+
+		if res == nil || res.NextPage == 0 {
+			return result, nil
+		}
+		opt.Page = res.NextPage
+	}
+}
+
 // GetBuildStatus gets the build status for the given ref.
 func (g *Gateway) GetBuildStatus(ctx context.Context, ref string) (*gateway.BuildStatus, error) {
 	s, _, err := g.repos.GetCombinedStatus(ctx, g.owner, g.repo, ref, nil)
@@ -133,6 +200,15 @@ func (g *Gateway) GetBuildStatus(ctx context.Context, ref string) (*gateway.Buil
 	return &bs, nil
 }
 
+// GetPullRequest retrieves the pull request with the given number.
+func (g *Gateway) GetPullRequest(ctx context.Context, number int) (*github.PullRequest, error) {
+	pr, _, err := g.pulls.Get(ctx, g.owner, g.repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %v: %v", g.urlFor(number), err)
+	}
+	return pr, nil
+}
+
 // ListPullRequestsByHead lists pull requests with the given head.
 func (g *Gateway) ListPullRequestsByHead(ctx context.Context, owner, branch string) ([]*github.PullRequest, error) {
 	if owner == "" {
@@ -151,6 +227,26 @@ func (g *Gateway) ListPullRequestsByHead(ctx context.Context, owner, branch stri
 	return prs, err
 }
 
+// ListAllPullRequestsByHead lists pull requests with the given head
+// regardless of their state.
+func (g *Gateway) ListAllPullRequestsByHead(ctx context.Context, owner, branch string) ([]*github.PullRequest, error) {
+	if owner == "" {
+		owner = g.owner
+	}
+
+	// TODO: account for pagination
+	prs, _, err := g.pulls.List(
+		ctx,
+		g.owner,
+		g.repo,
+		&github.PullRequestListOptions{Head: owner + ":" + branch, State: "all"})
+	if err != nil {
+		err = fmt.Errorf(
+			"failed to list pull requests with head %v:%v: %v", owner, branch, err)
+	}
+	return prs, err
+}
+
 // ListPullRequestsByBase lists pull requests made against the given merge base.
 func (g *Gateway) ListPullRequestsByBase(ctx context.Context, branch string) ([]*github.PullRequest, error) {
 	// TODO: account for pagination
@@ -188,11 +284,26 @@ func (g *Gateway) SetPullRequestBase(ctx context.Context, number int, base strin
 	return nil
 }
 
-// SquashPullRequest merges given pull request. The title and description are
-// used as-is for the commit message.
-func (g *Gateway) SquashPullRequest(ctx context.Context, pr *github.PullRequest) error {
-	result, _, err := g.pulls.Merge(ctx, g.owner, g.repo, *pr.Number, *pr.Body,
-		&github.PullRequestOptions{CommitTitle: *pr.Title, MergeMethod: "squash"})
+// SetPullRequestBody changes the description of the given pull request.
+func (g *Gateway) SetPullRequestBody(ctx context.Context, number int, body string) error {
+	_, _, err := g.pulls.Edit(ctx, g.owner, g.repo, number, &github.PullRequest{Body: &body})
+	if err != nil {
+		return fmt.Errorf("failed to change description of %v: %v", g.urlFor(number), err)
+	}
+	return nil
+}
+
+// MergePullRequest merges the given pull request. The title and description
+// are used as-is for the commit message.
+func (g *Gateway) MergePullRequest(ctx context.Context, req *gateway.MergeRequest) error {
+	method := req.Method
+	if method == "" {
+		method = gateway.MergeSquash
+	}
+
+	pr := req.PullRequest
+	result, _, err := g.pulls.Merge(ctx, g.owner, g.repo, *pr.Number, pr.GetBody(),
+		&github.PullRequestOptions{CommitTitle: pr.GetTitle(), MergeMethod: string(method)})
 	if err != nil {
 		return fmt.Errorf("failed to merge %v: %v", g.urlFor(*pr.Number), err)
 	}
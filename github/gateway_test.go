@@ -59,3 +59,109 @@ func TestListPullRequestReviews(t *testing.T) {
 		})
 	}
 }
+
+func TestListPullRequestCommits(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	prService := NewMockPullRequestsService(mockCtrl)
+	gomock.InOrder(
+		prService.EXPECT().
+			ListCommits(gomock.Any(), "foo", "bar", 42, &github.ListOptions{PerPage: 100}).
+			Return([]*github.RepositoryCommit{
+				{
+					SHA: ptr.String("abc"),
+					Commit: &github.Commit{Author: &github.CommitAuthor{
+						Name:  ptr.String("Foo"),
+						Email: ptr.String("foo@example.com"),
+					}},
+					Author: &github.User{Login: ptr.String("foo")},
+				},
+			}, &github.Response{NextPage: 2}, nil),
+		prService.EXPECT().
+			ListCommits(gomock.Any(), "foo", "bar", 42, &github.ListOptions{PerPage: 100, Page: 2}).
+			Return([]*github.RepositoryCommit{
+				{
+					SHA: ptr.String("def"),
+					Commit: &github.Commit{Author: &github.CommitAuthor{
+						Name:  ptr.String("Bar"),
+						Email: ptr.String("bar@example.com"),
+					}},
+				},
+			}, &github.Response{}, nil),
+	)
+
+	gw := Gateway{
+		owner: "foo",
+		repo:  "bar",
+		pulls: prService,
+	}
+
+	commits, err := gw.ListPullRequestCommits(context.Background(), 42)
+	require.NoError(t, err)
+	require.Equal(t, []*gateway.PullRequestCommit{
+		{SHA: "abc", AuthorName: "Foo", AuthorEmail: "foo@example.com", AuthorLogin: "foo"},
+		{SHA: "def", AuthorName: "Bar", AuthorEmail: "bar@example.com"},
+	}, commits)
+}
+
+func TestSetPullRequestBody(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	prService := NewMockPullRequestsService(mockCtrl)
+	prService.EXPECT().
+		Edit(gomock.Any(), "foo", "bar", 42, &github.PullRequest{Body: ptr.String("hello")}).
+		Return(&github.PullRequest{}, &github.Response{}, nil)
+
+	gw := Gateway{
+		owner: "foo",
+		repo:  "bar",
+		pulls: prService,
+	}
+
+	require.NoError(t, gw.SetPullRequestBody(context.Background(), 42, "hello"))
+}
+
+func TestMergePullRequest(t *testing.T) {
+	pr := &github.PullRequest{
+		Number: github.Int(42),
+		Title:  ptr.String("Fix a bug (#42)"),
+		Body:   ptr.String("Fixes a bug."),
+	}
+
+	tests := []struct {
+		give gateway.MergeMethod
+		want string
+	}{
+		{give: "", want: "squash"},
+		{give: gateway.MergeCommit, want: "merge"},
+		{give: gateway.MergeRebase, want: "rebase"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			prService := NewMockPullRequestsService(mockCtrl)
+			prService.EXPECT().
+				Merge(gomock.Any(), "foo", "bar", 42, "Fixes a bug.", &github.PullRequestOptions{
+					CommitTitle: "Fix a bug (#42)",
+					MergeMethod: tt.want,
+				}).
+				Return(&github.PullRequestMergeResult{Merged: github.Bool(true)}, &github.Response{}, nil)
+
+			gw := Gateway{
+				owner: "foo",
+				repo:  "bar",
+				pulls: prService,
+			}
+
+			require.NoError(t, gw.MergePullRequest(context.Background(), &gateway.MergeRequest{
+				PullRequest: pr,
+				Method:      tt.give,
+			}))
+		})
+	}
+}
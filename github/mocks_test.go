@@ -74,6 +74,18 @@ func (_mr *_MockPullRequestsServiceRecorder) Edit(arg0, arg1, arg2, arg3, arg4 i
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Edit", arg0, arg1, arg2, arg3, arg4)
 }
 
+func (_m *MockPullRequestsService) Get(_param0 context.Context, _param1 string, _param2 string, _param3 int) (*github.PullRequest, *github.Response, error) {
+	ret := _m.ctrl.Call(_m, "Get", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(*github.PullRequest)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockPullRequestsServiceRecorder) Get(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Get", arg0, arg1, arg2, arg3)
+}
+
 func (_m *MockPullRequestsService) GetRaw(_param0 context.Context, _param1 string, _param2 string, _param3 int, _param4 github.RawOptions) (string, *github.Response, error) {
 	ret := _m.ctrl.Call(_m, "GetRaw", _param0, _param1, _param2, _param3, _param4)
 	ret0, _ := ret[0].(string)
@@ -98,6 +110,18 @@ func (_mr *_MockPullRequestsServiceRecorder) List(arg0, arg1, arg2, arg3 interfa
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "List", arg0, arg1, arg2, arg3)
 }
 
+func (_m *MockPullRequestsService) ListCommits(_param0 context.Context, _param1 string, _param2 string, _param3 int, _param4 *github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error) {
+	ret := _m.ctrl.Call(_m, "ListCommits", _param0, _param1, _param2, _param3, _param4)
+	ret0, _ := ret[0].([]*github.RepositoryCommit)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+func (_mr *_MockPullRequestsServiceRecorder) ListCommits(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "ListCommits", arg0, arg1, arg2, arg3, arg4)
+}
+
 func (_m *MockPullRequestsService) ListReviews(_param0 context.Context, _param1 string, _param2 string, _param3 int) ([]*github.PullRequestReview, *github.Response, error) {
 	ret := _m.ctrl.Call(_m, "ListReviews", _param0, _param1, _param2, _param3)
 	ret0, _ := ret[0].([]*github.PullRequestReview)
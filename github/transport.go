@@ -0,0 +1,88 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/abhinav/git-pr/logging"
+)
+
+// NewLoggingTransport wraps the given http.RoundTripper to trace every
+// request made to GitHub to log at the Debug level. The trace includes the
+// status of the response and how many requests remain in the current rate
+// limit window.
+func NewLoggingTransport(base http.RoundTripper, log *logging.Logger) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &loggingTransport{base: base, log: log}
+}
+
+type loggingTransport struct {
+	base http.RoundTripper
+	log  *logging.Logger
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.base.RoundTrip(req)
+
+	fields := []logging.Field{
+		logging.String("method", req.Method),
+		logging.String("path", req.URL.Path),
+		logging.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		fields = append(fields, logging.Error(err))
+	} else {
+		fields = append(fields, logging.Int("status", res.StatusCode))
+		if remaining := res.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+			fields = append(fields, logging.String("rate_limit_remaining", remaining))
+		}
+	}
+	t.log.Debug("request", fields...)
+
+	return res, err
+}
+
+// NewTimeoutTransport wraps the given http.RoundTripper to abandon requests
+// that don't finish within timeout, including reading the response body.
+func NewTimeoutTransport(base http.RoundTripper, timeout time.Duration) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &timeoutTransport{base: base, timeout: timeout}
+}
+
+type timeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	res, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// cancelOnClose releases the context of a request when its response body is
+// closed.
+type cancelOnClose struct {
+	io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,62 @@
+package github
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abhinav/git-pr/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := http.Client{
+		Transport: NewLoggingTransport(nil, logging.New(&buf, logging.Debug)),
+	}
+
+	res, err := client.Get(server.URL + "/repos/abhinav/git-pr/pulls")
+	require.NoError(t, err)
+	res.Body.Close()
+
+	out := buf.String()
+	assert.Contains(t, out, "[debug] request method=GET path=/repos/abhinav/git-pr/pulls")
+	assert.Contains(t, out, "status=404 rate_limit_remaining=4999")
+}
+
+func TestTimeoutTransport(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-unblock
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	client := http.Client{Transport: NewTimeoutTransport(nil, 100*time.Millisecond)}
+
+	res, err := client.Get(server.URL + "/fast")
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err, "body must be readable before the timeout")
+	assert.Equal(t, "ok", string(body))
+
+	_, err = client.Get(server.URL + "/slow")
+	if assert.Error(t, err, "slow requests must time out") {
+		assert.Contains(t, err.Error(), "context deadline exceeded")
+	}
+}
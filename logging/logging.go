@@ -0,0 +1,146 @@
+// Package logging provides a small leveled logger for diagnostic output.
+//
+// Messages are written one per line with their fields in key=value form.
+//
+// 	[debug] git: ran command args="rev-parse --abbrev-ref HEAD" duration=2.1ms exit=0
+//
+// All methods of Logger may be called on a nil Logger. They do nothing in
+// that case. This makes it possible to leave loggers unset in tests and in
+// code that doesn't care about diagnostics.
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log message.
+type Level int
+
+const (
+	// Debug messages trace what the program is doing. They are hidden
+	// unless verbose output was requested.
+	Debug Level = iota
+
+	// Info messages are interesting to users in most cases.
+	Info
+)
+
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "debug"
+	case Info:
+		return "info"
+	default:
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+}
+
+// Field is a key-value pair attached to a log message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String builds a field with a string value.
+func String(key, value string) Field { return Field{Key: key, Value: value} }
+
+// Strings builds a field with a list of strings. The strings are joined
+// with spaces.
+func Strings(key string, values []string) Field {
+	return Field{Key: key, Value: strings.Join(values, " ")}
+}
+
+// Int builds a field with an integer value.
+func Int(key string, value int) Field { return Field{Key: key, Value: value} }
+
+// Duration builds a field with a duration.
+func Duration(key string, value time.Duration) Field { return Field{Key: key, Value: value} }
+
+// Error builds a field named "error" for the given error.
+func Error(err error) Field { return Field{Key: "error", Value: err} }
+
+// Logger writes leveled log messages to an io.Writer.
+type Logger struct {
+	// Shared between a logger and the loggers derived from it so that
+	// messages aren't interleaved.
+	mu *sync.Mutex
+
+	w     io.Writer
+	level Level
+	name  string
+}
+
+// New builds a Logger which writes messages at or above the given level
+// to w.
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{mu: new(sync.Mutex), w: w, level: level}
+}
+
+// Named returns a copy of this logger which prefixes messages with the
+// given name. Names of nested loggers are joined with dots.
+func (l *Logger) Named(name string) *Logger {
+	if l == nil {
+		return nil
+	}
+
+	newL := *l
+	if newL.name != "" {
+		name = newL.name + "." + name
+	}
+	newL.name = name
+	return &newL
+}
+
+// Enabled returns true if messages at the given level will be written.
+func (l *Logger) Enabled(level Level) bool {
+	return l != nil && level >= l.level
+}
+
+// Debug logs a message at the Debug level.
+func (l *Logger) Debug(msg string, fields ...Field) {
+	l.log(Debug, msg, fields)
+}
+
+// Info logs a message at the Info level.
+func (l *Logger) Info(msg string, fields ...Field) {
+	l.log(Info, msg, fields)
+}
+
+func (l *Logger) log(level Level, msg string, fields []Field) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "[%v] ", level)
+	if l.name != "" {
+		buf.WriteString(l.name)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(msg)
+	for _, f := range fields {
+		fmt.Fprintf(&buf, " %v=%v", f.Key, formatValue(f.Value))
+	}
+	buf.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(buf.Bytes()) // errors writing logs are ignored
+}
+
+// formatValue formats a field value, quoting it if it would otherwise be
+// ambiguous.
+func formatValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, Info)
+
+	log.Debug("hidden")
+	log.Info("shown", String("branch", "feature1"), Int("count", 2))
+	log.Named("git").Named("rebase").Info("failed",
+		Strings("args", []string{"rebase", "--onto", "master"}),
+		Error(errors.New("great sadness")),
+		Duration("duration", 2*time.Second),
+		String("empty", ""),
+	)
+
+	assert.Equal(t,
+		"[info] shown branch=feature1 count=2\n"+
+			`[info] git.rebase: failed args="rebase --onto master" error="great sadness" duration=2s empty=""`+"\n",
+		buf.String())
+}
+
+func TestLoggerEnabled(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, Debug)
+	assert.True(t, log.Enabled(Debug))
+	assert.True(t, log.Enabled(Info))
+
+	log = New(&buf, Info)
+	assert.False(t, log.Enabled(Debug))
+	assert.True(t, log.Enabled(Info))
+}
+
+func TestNilLogger(t *testing.T) {
+	var log *Logger
+	assert.False(t, log.Enabled(Info))
+	assert.Nil(t, log.Named("foo"))
+
+	// Must not panic.
+	log.Debug("foo")
+	log.Info("bar", String("baz", "qux"))
+}
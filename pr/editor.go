@@ -2,65 +2,158 @@ package pr
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"text/template"
 
 	"github.com/abhinav/git-pr/editor"
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/service"
 
 	"github.com/google/go-github/github"
 )
 
+// MessageConfig configures UpdateMessage.
+type MessageConfig struct {
+	// Editor used to edit the message.
+	Editor editor.Editor
+
+	// Template used to build the initial message. Defaults to
+	// DefaultMessageTemplate.
+	Template *template.Template
+
+	// Trailers added to the end of the message unless it already has them.
+	Trailers []Trailer
+
+	// Rules the message must follow. The editor is opened again with the
+	// problems listed if the message doesn't follow them.
+	Lint service.LintRules
+
+	// Context and GitHub gateway used to look up information about the pull
+	// request if the template asks for it.
+	Context context.Context
+	GitHub  gateway.GitHub
+}
+
 // UpdateMessage uses the given editor to edit the commit message of the given
 // PR.
-func UpdateMessage(ed editor.Editor, pr *github.PullRequest) error {
-	var buff bytes.Buffer
-	if err := _interactiveTmpl.Execute(&buff, pr); err != nil {
-		return err
+func UpdateMessage(cfg MessageConfig, pr *github.PullRequest) error {
+	// The stack section is meant for reviewers. It doesn't belong in the
+	// commit message.
+	if pr.Body != nil {
+		body := RemoveStackSection(*pr.Body)
+		pr.Body = &body
 	}
 
-	message, err := ed.EditString(buff.String())
-	if err != nil {
-		return err
+	tmpl := cfg.Template
+	if tmpl == nil {
+		tmpl = _defaultTmpl
 	}
 
-	title, body, err := _parseMessage(message)
-	if err != nil {
-		return err
+	ctx := cfg.Context
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	pr.Title = &title
-	pr.Body = &body
-	return nil
+	var buff bytes.Buffer
+	if err := tmpl.Execute(&buff, NewMessageData(ctx, cfg.GitHub, pr)); err != nil {
+		return fmt.Errorf("failed to render commit message template: %v", err)
+	}
+	message := strings.TrimRight(buff.String(), "\n")
+
+	var trailers []string
+	for _, t := range cfg.Trailers {
+		if !hasLine(message, t.String()) {
+			trailers = append(trailers, t.String())
+		}
+	}
+	if len(trailers) > 0 {
+		message += "\n\n" + strings.Join(trailers, "\n")
+	}
+
+	// Keep asking for a message until it follows the lint rules or the
+	// user gives up.
+	var problems []string
+	for {
+		buff.Reset()
+		if err := _interactiveTmpl.Execute(&buff, struct {
+			Message  string
+			URL      string
+			Problems []string
+		}{Message: message, URL: pr.GetHTMLURL(), Problems: problems}); err != nil {
+			return err
+		}
+
+		edited, err := cfg.Editor.EditString(buff.String())
+		if err != nil {
+			return err
+		}
+
+		title, body, err := _parseMessage(edited)
+		if err != nil {
+			return err
+		}
+
+		shownProblems := len(problems) > 0
+		problems = LintMessage(cfg.Lint, title, body)
+		if len(problems) == 0 {
+			pr.Title = &title
+			pr.Body = &body
+			return nil
+		}
+
+		// Editors that aren't interactive, and users who don't want to fix
+		// the message, hand back the same message. Asking again won't help.
+		edited = strings.TrimRight(stripComments(edited), "\n")
+		if shownProblems && edited == message {
+			return fmt.Errorf("commit message has problems:\n - %v", strings.Join(problems, "\n - "))
+		}
+		message = edited
+	}
 }
 
 var _interactiveTmpl = template.Must(template.New("interactive").Parse(
-	`{{.Title}} (#{{.Number}})
-
-{{if .Body}}{{.Body}}
+	`{{.Message}}
 
-{{end}}# Landing Pull Request: {{.HTMLURL}}
+{{if .Problems}}# The commit message has the following problems:
+{{range .Problems}}#  - {{.}}
+{{end}}#
+{{end}}# Landing Pull Request: {{.URL}}
 #
 # Enter the commit message above. Lines starting with '#' will be
 # ignored. There must be an empty line between the title and the body.
 # Leaving this file empty will abort the operation.
 `))
 
-func _parseMessage(s string) (title string, body string, err error) {
+// hasLine checks if s contains the given line.
+func hasLine(s, line string) bool {
+	for _, l := range strings.Split(s, "\n") {
+		if strings.TrimSpace(l) == line {
+			return true
+		}
+	}
+	return false
+}
+
+// stripComments removes lines starting with '#' from s.
+func stripComments(s string) string {
 	lines := strings.Split(s, "\n")
-	{
-		newLines := lines[:0]
-		for _, l := range lines {
-			if len(l) > 0 && l[0] == '#' {
-				continue
-			}
-
-			newLines = append(newLines, l)
+	newLines := lines[:0]
+	for _, l := range lines {
+		if len(l) > 0 && l[0] == '#' {
+			continue
 		}
-		lines = newLines
+		newLines = append(newLines, l)
 	}
+	return strings.Join(newLines, "\n")
+}
 
-	if len(lines) == 0 {
+func _parseMessage(s string) (title string, body string, err error) {
+	s = stripComments(s)
+	lines := strings.Split(s, "\n")
+	if strings.TrimSpace(s) == "" {
 		err = errors.New("file is empty")
 		return
 	}
@@ -0,0 +1,496 @@
+package pr
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/git"
+
+	"github.com/google/go-github/github"
+)
+
+// Graph is a collection of stacks of branches. Each branch in the graph
+// knows its parent and, if it was published, its pull request.
+//
+// Branches whose parents are not part of the graph are the roots of their
+// stacks. The parents of roots are the base branches of the stacks.
+//
+// 	master
+// 	 `-> feature1          (root)
+// 	      |-> feature2
+// 	      |    `-> feature3
+// 	      `-> feature4
+//
+// All operations on a Graph are deterministic. Branches are always listed
+// in the same order.
+type Graph struct {
+	parents  map[string]string              // branch -> parent
+	children map[string][]string            // branch -> sorted children
+	pulls    map[string]*github.PullRequest // branch -> pull request
+}
+
+// NewGraph builds a new empty Graph.
+func NewGraph() *Graph {
+	return &Graph{
+		parents:  make(map[string]string),
+		children: make(map[string][]string),
+		pulls:    make(map[string]*github.PullRequest),
+	}
+}
+
+// AddBranch adds a branch with the given parent to the graph. An error is
+// returned if the branch is already part of the graph or if adding it would
+// make a branch depend on itself.
+func (g *Graph) AddBranch(branch, parent string) error {
+	if g.Contains(branch) {
+		return fmt.Errorf("branch %q is already part of the graph", branch)
+	}
+
+	for p := parent; ; p = g.parents[p] {
+		if p == branch {
+			return fmt.Errorf("branch %q depends on itself", branch)
+		}
+		if !g.Contains(p) {
+			break
+		}
+	}
+
+	g.parents[branch] = parent
+
+	children := append(g.children[parent], branch)
+	sort.Strings(children)
+	g.children[parent] = children
+	return nil
+}
+
+// AddPullRequest adds the head branch of the given pull request to the
+// graph with the base branch of the pull request as its parent.
+func (g *Graph) AddPullRequest(pr *github.PullRequest) error {
+	branch := pr.Head.GetRef()
+	if err := g.AddBranch(branch, pr.Base.GetRef()); err != nil {
+		return err
+	}
+	g.pulls[branch] = pr
+	return nil
+}
+
+// AddLocalBranches adds branches whose parents were recorded locally with
+// git.SetParent. Branches that are already part of the graph are left
+// unchanged.
+func (g *Graph) AddLocalBranches(gw gateway.Git) error {
+	parents, err := git.Parents(gw)
+	if err != nil {
+		return err
+	}
+
+	branches := make([]string, 0, len(parents))
+	for branch := range parents {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+
+	for _, branch := range branches {
+		if g.Contains(branch) {
+			continue
+		}
+		if err := g.AddBranch(branch, parents[branch]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Contains returns true if the given branch is part of the graph.
+func (g *Graph) Contains(branch string) bool {
+	_, ok := g.parents[branch]
+	return ok
+}
+
+// Parent returns the parent of the given branch or an empty string if the
+// branch is not part of the graph.
+func (g *Graph) Parent(branch string) string {
+	return g.parents[branch]
+}
+
+// Children returns the branches that depend on the given branch, sorted by
+// name.
+func (g *Graph) Children(branch string) []string {
+	return g.children[branch]
+}
+
+// PullRequest returns the pull request for the given branch or nil if the
+// branch doesn't have a pull request.
+func (g *Graph) PullRequest(branch string) *github.PullRequest {
+	return g.pulls[branch]
+}
+
+// PullRequests returns the pull requests for the given branches, skipping
+// branches that don't have pull requests.
+func (g *Graph) PullRequests(branches []string) []*github.PullRequest {
+	var prs []*github.PullRequest
+	for _, br := range branches {
+		if pr := g.pulls[br]; pr != nil {
+			prs = append(prs, pr)
+		}
+	}
+	return prs
+}
+
+// Roots returns the branches at the bottoms of the stacks in this graph,
+// sorted by name.
+func (g *Graph) Roots() []string {
+	var roots []string
+	for branch, parent := range g.parents {
+		if !g.Contains(parent) {
+			roots = append(roots, branch)
+		}
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// TopologicalOrder returns all branches in the graph such that parents are
+// listed before their children.
+func (g *Graph) TopologicalOrder() []string {
+	branches := make([]string, 0, len(g.parents))
+	for _, root := range g.Roots() {
+		branches = g.appendSubtree(branches, root)
+	}
+	return branches
+}
+
+// Subtree returns the given branch and all branches that depend on it,
+// directly or indirectly, such that parents are listed before their
+// children.
+func (g *Graph) Subtree(branch string) []string {
+	return g.appendSubtree(nil, branch)
+}
+
+func (g *Graph) appendSubtree(branches []string, branch string) []string {
+	branches = append(branches, branch)
+	for _, child := range g.children[branch] {
+		branches = g.appendSubtree(branches, child)
+	}
+	return branches
+}
+
+// Leaves returns the branches in the subtree of the given branch that don't
+// have any dependents. If the branch itself has no dependents, it is the
+// only leaf.
+func (g *Graph) Leaves(branch string) []string {
+	var leaves []string
+	for _, br := range g.Subtree(branch) {
+		if len(g.children[br]) == 0 {
+			leaves = append(leaves, br)
+		}
+	}
+	return leaves
+}
+
+// PathToRoot returns the given branch followed by its ancestors, ending
+// with the root of its stack. The base branch of the stack is not included.
+func (g *Graph) PathToRoot(branch string) []string {
+	var path []string
+	for br := branch; g.Contains(br); br = g.parents[br] {
+		path = append(path, br)
+	}
+	return path
+}
+
+// Depth returns the number of ancestors the given branch has in the graph.
+// The roots of stacks have a depth of zero.
+func (g *Graph) Depth(branch string) int {
+	return len(g.PathToRoot(branch)) - 1
+}
+
+// GraphConfig configures how a Graph is loaded from GitHub.
+type GraphConfig struct {
+	Context context.Context
+	GitHub  gateway.GitHub
+
+	// If set, only pull requests for which this returns true are added to
+	// the graph. Pull requests that depend on excluded pull requests are not
+	// loaded.
+	Include func(*github.PullRequest) bool
+
+	// Maximum number of levels of dependents to load. Defaults to loading
+	// all dependents.
+	MaxDepth int
+
+	// Maximum number of concurrent requests to GitHub. Defaults to the
+	// number of CPUs available to this process.
+	Concurrency int
+}
+
+// OwnedPullRequests returns a function for GraphConfig.Include which
+// excludes pull requests from other repositories, such as forks. Their head
+// branches aren't branches of this repository and often share names like
+// "patch-1" or "master" with each other and with branches that are.
+func OwnedPullRequests(ctx context.Context, gh gateway.GitHub) func(*github.PullRequest) bool {
+	return func(pr *github.PullRequest) bool {
+		return gh.IsOwned(ctx, pr.Head)
+	}
+}
+
+// LoadGraph loads a Graph containing the given pull requests and the pull
+// requests that depend on them.
+//
+// Pull requests whose head branches are already part of the graph or are the
+// same as their base branches are skipped.
+func LoadGraph(cfg GraphConfig, pulls []*github.PullRequest) (*Graph, error) {
+	graph := NewGraph()
+	if err := loadGraph(cfg, graph, pulls); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+func loadGraph(cfg GraphConfig, graph *Graph, pulls []*github.PullRequest) error {
+	v := graphVisitor{
+		GraphConfig: &cfg,
+		mu:          new(sync.Mutex),
+		graph:       graph,
+	}
+
+	walkCfg := WalkConfig{
+		Context:     cfg.Context,
+		Concurrency: cfg.Concurrency,
+		Children: func(pr *github.PullRequest) ([]*github.PullRequest, error) {
+			return cfg.GitHub.ListPullRequestsByBase(cfg.Context, pr.Head.GetRef())
+		},
+	}
+	return Walk(walkCfg, pulls, v)
+}
+
+type graphVisitor struct {
+	*GraphConfig
+
+	mu    *sync.Mutex
+	graph *Graph
+
+	// Number of levels below the pull requests at which the walk started.
+	depth int
+
+	// Branch of the pull request whose dependents are being visited. Empty
+	// for the pull requests at which the walk started.
+	parent string
+}
+
+func (v graphVisitor) Visit(pr *github.PullRequest) (Visitor, error) {
+	if v.Include != nil && !v.Include(pr) {
+		return nil, nil
+	}
+
+	// Dependents were found by looking up pull requests based on their
+	// parents so we don't need to rely on the base of the pull request.
+	parent := v.parent
+	if parent == "" {
+		parent = pr.Base.GetRef()
+	}
+
+	branch := pr.Head.GetRef()
+	if branch == parent {
+		return nil, nil
+	}
+
+	v.mu.Lock()
+	added, err := v.add(branch, parent, pr)
+	v.mu.Unlock()
+	if err != nil || !added {
+		return nil, err
+	}
+
+	if v.MaxDepth > 0 && v.depth >= v.MaxDepth {
+		return nil, nil
+	}
+
+	// We are operating on a shallow copy of v so we can just modify and
+	// return it.
+	v.depth++
+	v.parent = branch
+	return v, nil
+}
+
+// add adds the head branch of the given pull request to the graph, returning
+// false if the branch is already part of it. Multiple pull requests may be
+// opened from the same branch to different bases. Only the first one found
+// is used.
+func (v graphVisitor) add(branch, parent string, pr *github.PullRequest) (bool, error) {
+	if v.graph.Contains(branch) {
+		return false, nil
+	}
+
+	if err := v.graph.AddBranch(branch, parent); err != nil {
+		return false, err
+	}
+	v.graph.pulls[branch] = pr
+	return true, nil
+}
+
+// StackConfig configures how the stack of a branch is loaded.
+type StackConfig struct {
+	GraphConfig
+
+	Git gateway.Git
+
+	// If set, this is called with the error if GitHub couldn't be reached,
+	// and the stack is loaded from the relationships recorded locally
+	// instead. Otherwise the error is returned.
+	Offline func(error)
+}
+
+// LoadStack loads a Graph containing the given branch, its ancestors, and
+// the branches that depend on it. Relationships come from pull requests on
+// GitHub and, for branches without pull requests, from the parents recorded
+// locally with git.SetParent. Pull requests take precedence.
+func LoadStack(cfg StackConfig, branch string) (*Graph, error) {
+	if cfg.Context == nil {
+		cfg.Context = context.Background()
+	}
+
+	gh := &errorTrackingGitHub{GitHub: cfg.GitHub}
+	cfg.GitHub = gh
+
+	graph, err := loadStack(cfg, branch)
+	if err != nil {
+		ghErr := gh.Err()
+		if ghErr == nil || cfg.Offline == nil {
+			return nil, err
+		}
+
+		cfg.Offline(ghErr)
+		graph = NewGraph()
+	}
+
+	if err := graph.AddLocalBranches(cfg.Git); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+func loadStack(cfg StackConfig, branch string) (*Graph, error) {
+	graph := NewGraph()
+
+	seen := map[string]struct{}{branch: {}}
+	for br := branch; ; {
+		parent, pr, err := stackParent(cfg, br)
+		if err != nil {
+			return nil, err
+		}
+		if parent == "" {
+			break
+		}
+
+		if _, ok := seen[parent]; ok {
+			return nil, fmt.Errorf("branch %q depends on itself", parent)
+		}
+		seen[parent] = struct{}{}
+
+		if err := graph.AddBranch(br, parent); err != nil {
+			return nil, err
+		}
+		if pr != nil {
+			graph.pulls[br] = pr
+		}
+		br = parent
+	}
+
+	dependents, err := cfg.GitHub.ListPullRequestsByBase(cfg.Context, branch)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadGraph(cfg.GraphConfig, graph, dependents); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// stackParent returns the parent of the given branch and its pull request,
+// if any. An empty string is returned if the branch doesn't have a parent.
+func stackParent(cfg StackConfig, branch string) (string, *github.PullRequest, error) {
+	prs, err := cfg.GitHub.ListPullRequestsByHead(cfg.Context, "", branch)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var included []*github.PullRequest
+	for _, pr := range prs {
+		if pr.Base.GetRef() == branch {
+			continue
+		}
+		if cfg.Include == nil || cfg.Include(pr) {
+			included = append(included, pr)
+		}
+	}
+
+	switch len(included) {
+	case 0:
+		// Branches without pull requests may have been recorded locally.
+		parent, err := git.Parent(cfg.Git, branch)
+		return parent, nil, err
+	case 1:
+		return included[0].Base.GetRef(), included[0], nil
+	default:
+		return "", nil, MultiplePullRequestsError{Branch: branch, Pulls: included}
+	}
+}
+
+// MultiplePullRequestsError is returned by LoadStack if a branch in the
+// stack has more than one open pull request.
+type MultiplePullRequestsError struct {
+	Branch string
+	Pulls  []*github.PullRequest
+}
+
+func (e MultiplePullRequestsError) Error() string {
+	urls := make([]string, len(e.Pulls))
+	for i, pr := range e.Pulls {
+		urls[i] = pr.GetHTMLURL()
+	}
+	return fmt.Sprintf(
+		"branch %q has more than one pull request: %v", e.Branch, strings.Join(urls, ", "))
+}
+
+// errorTrackingGitHub remembers the first error returned when pull requests
+// were listed.
+type errorTrackingGitHub struct {
+	gateway.GitHub
+
+	mu  sync.Mutex
+	err error
+}
+
+func (g *errorTrackingGitHub) ListPullRequestsByHead(ctx context.Context, owner, branch string) ([]*github.PullRequest, error) {
+	prs, err := g.GitHub.ListPullRequestsByHead(ctx, owner, branch)
+	g.track(err)
+	return prs, err
+}
+
+func (g *errorTrackingGitHub) ListPullRequestsByBase(ctx context.Context, branch string) ([]*github.PullRequest, error) {
+	prs, err := g.GitHub.ListPullRequestsByBase(ctx, branch)
+	g.track(err)
+	return prs, err
+}
+
+func (g *errorTrackingGitHub) track(err error) {
+	if err == nil {
+		return
+	}
+
+	g.mu.Lock()
+	if g.err == nil {
+		g.err = err
+	}
+	g.mu.Unlock()
+}
+
+// Err returns the first error encountered, if any.
+func (g *errorTrackingGitHub) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
@@ -0,0 +1,381 @@
+package pr
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph(t *testing.T) {
+	// master -> feature1 -> feature2 -> feature3
+	//       |            \
+	//       |             -> feature4
+	//       |
+	//       -> feature5
+	//
+	// dev -> feature6
+	g := NewGraph()
+	for _, edge := range [][2]string{
+		{"feature3", "feature2"},
+		{"feature1", "master"},
+		{"feature2", "feature1"},
+		{"feature4", "feature1"},
+		{"feature6", "dev"},
+		{"feature5", "master"},
+	} {
+		require.NoError(t, g.AddBranch(edge[0], edge[1]))
+	}
+
+	assert.True(t, g.Contains("feature1"))
+	assert.False(t, g.Contains("master"))
+
+	assert.Equal(t, "feature1", g.Parent("feature2"))
+	assert.Equal(t, "master", g.Parent("feature1"))
+	assert.Empty(t, g.Parent("master"))
+
+	assert.Equal(t, []string{"feature2", "feature4"}, g.Children("feature1"))
+	assert.Equal(t, []string{"feature1", "feature5"}, g.Children("master"))
+	assert.Empty(t, g.Children("feature3"))
+
+	assert.Equal(t, []string{"feature1", "feature5", "feature6"}, g.Roots())
+	assert.Equal(t,
+		[]string{"feature1", "feature2", "feature3", "feature4", "feature5", "feature6"},
+		g.TopologicalOrder())
+
+	assert.Equal(t, []string{"feature1", "feature2", "feature3", "feature4"}, g.Subtree("feature1"))
+	assert.Equal(t, []string{"feature3"}, g.Subtree("feature3"))
+
+	assert.Equal(t, []string{"feature3", "feature4"}, g.Leaves("feature1"))
+	assert.Equal(t, []string{"feature3"}, g.Leaves("feature3"))
+
+	assert.Equal(t, []string{"feature3", "feature2", "feature1"}, g.PathToRoot("feature3"))
+	assert.Equal(t, []string{"feature6"}, g.PathToRoot("feature6"))
+	assert.Empty(t, g.PathToRoot("master"))
+
+	assert.Equal(t, 2, g.Depth("feature3"))
+	assert.Equal(t, 0, g.Depth("feature5"))
+}
+
+func TestGraphAddBranchErrors(t *testing.T) {
+	g := NewGraph()
+	require.NoError(t, g.AddBranch("feature1", "master"))
+	require.NoError(t, g.AddBranch("feature2", "feature1"))
+
+	err := g.AddBranch("feature1", "dev")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `branch "feature1" is already part of the graph`)
+
+	err = g.AddBranch("feature3", "feature3")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `branch "feature3" depends on itself`)
+
+	err = g.AddBranch("master", "feature2")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `branch "master" depends on itself`)
+}
+
+func TestGraphAddLocalBranches(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	git := gatewaytest.NewMockGit(mockCtrl)
+	git.EXPECT().ListConfig(`^branch\..*\.git-pr-parent$`).Return(map[string]string{
+		"branch.feature1.git-pr-parent": "dev",
+		"branch.feature3.git-pr-parent": "feature2",
+	}, nil)
+
+	g := NewGraph()
+	require.NoError(t, g.AddPullRequest(&github.PullRequest{
+		Number: github.Int(1),
+		Head:   &github.PullRequestBranch{Ref: github.String("feature1")},
+		Base:   &github.PullRequestBranch{Ref: github.String("master")},
+	}))
+	require.NoError(t, g.AddBranch("feature2", "feature1"))
+	require.NoError(t, g.AddLocalBranches(git))
+
+	// Relationships on GitHub take precedence.
+	assert.Equal(t, "master", g.Parent("feature1"))
+	assert.Equal(t, "feature2", g.Parent("feature3"))
+
+	assert.Equal(t, 1, g.PullRequest("feature1").GetNumber())
+	assert.Nil(t, g.PullRequest("feature3"))
+	assert.Len(t, g.PullRequests(g.TopologicalOrder()), 1)
+}
+
+func TestLoadGraph(t *testing.T) {
+	pull := func(num int, head, base string) *github.PullRequest {
+		return &github.PullRequest{
+			Number: github.Int(num),
+			Head:   &github.PullRequestBranch{Ref: github.String(head)},
+			Base:   &github.PullRequestBranch{Ref: github.String(base)},
+			User:   &github.User{Login: github.String("foo")},
+		}
+	}
+
+	pr1 := pull(1, "feature1", "master")
+	pr2 := pull(2, "feature2", "feature1")
+	pr3 := pull(3, "feature3", "feature2")
+	pr4 := pull(4, "feature4", "feature1")
+	pr4.User.Login = github.String("bar")
+
+	// Pull requests from forks may use any branch names.
+	dup4 := pull(5, "feature4", "feature1")
+	self2 := pull(6, "feature2", "feature2")
+
+	tests := []struct {
+		Desc string
+
+		Include  func(*github.PullRequest) bool
+		MaxDepth int
+
+		// Dependents of different branches. If a branch isn't listed, its
+		// dependents must not be requested.
+		Dependents map[string][]*github.PullRequest
+
+		WantBranches []string
+	}{
+		{
+			Desc: "all",
+			Dependents: map[string][]*github.PullRequest{
+				"feature1": {pr4, pr2},
+				"feature2": {pr3},
+				"feature3": {},
+				"feature4": {},
+			},
+			WantBranches: []string{"feature1", "feature2", "feature3", "feature4"},
+		},
+		{
+			Desc: "include",
+			Include: func(pr *github.PullRequest) bool {
+				return pr.User.GetLogin() == "foo"
+			},
+			Dependents: map[string][]*github.PullRequest{
+				"feature1": {pr4, pr2},
+				"feature2": {pr3},
+				"feature3": {},
+			},
+			WantBranches: []string{"feature1", "feature2", "feature3"},
+		},
+		{
+			Desc: "duplicate and self-based heads",
+			Dependents: map[string][]*github.PullRequest{
+				"feature1": {pr4, pr2, dup4},
+				"feature2": {pr3, self2},
+				"feature3": {},
+				"feature4": {},
+			},
+			WantBranches: []string{"feature1", "feature2", "feature3", "feature4"},
+		},
+		{
+			Desc:     "max depth",
+			MaxDepth: 1,
+			Dependents: map[string][]*github.PullRequest{
+				"feature1": {pr4, pr2},
+			},
+			WantBranches: []string{"feature1", "feature2", "feature4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			gh := gatewaytest.NewMockGitHub(mockCtrl)
+			for branch, prs := range tt.Dependents {
+				gh.EXPECT().ListPullRequestsByBase(gomock.Any(), branch).Return(prs, nil)
+			}
+
+			g, err := LoadGraph(GraphConfig{
+				Context:  context.Background(),
+				GitHub:   gh,
+				Include:  tt.Include,
+				MaxDepth: tt.MaxDepth,
+			}, []*github.PullRequest{pr1})
+			require.NoError(t, err)
+			assert.Equal(t, tt.WantBranches, g.TopologicalOrder())
+			assert.Equal(t, pr2, g.PullRequest("feature2"))
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		gh := gatewaytest.NewMockGitHub(mockCtrl)
+		gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature1").
+			Return(nil, errors.New("great sadness"))
+
+		_, err := LoadGraph(GraphConfig{
+			Context: context.Background(),
+			GitHub:  gh,
+		}, []*github.PullRequest{pr1})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "great sadness")
+	})
+}
+
+func TestLoadStack(t *testing.T) {
+	pull := func(num int, head, base string) *github.PullRequest {
+		return &github.PullRequest{
+			Number: github.Int(num),
+			Head:   &github.PullRequestBranch{Ref: github.String(head)},
+			Base:   &github.PullRequestBranch{Ref: github.String(base)},
+		}
+	}
+
+	// master -> feature1 -> feature2 -> feature3
+	//                     \
+	//                      -> feature4
+	pr1 := pull(1, "feature1", "master")
+	pr2 := pull(2, "feature2", "feature1")
+	pr3 := pull(3, "feature3", "feature2")
+	pr4 := pull(4, "feature4", "feature1")
+
+	// Pull request from a fork with the same head as one of ours.
+	fork := pull(5, "feature3", "feature2")
+	fork.Head.Label = github.String("someone:feature3")
+
+	tests := []struct {
+		Desc string
+
+		Branch       string
+		PullRequests []*github.PullRequest
+		Offline      bool
+
+		// Locally recorded parents of branches.
+		LocalParents map[string]string
+
+		WantParents map[string]string
+		WantOffline bool
+		WantError   string
+	}{
+		{
+			Desc:         "pull requests",
+			Branch:       "feature2",
+			PullRequests: []*github.PullRequest{pr1, pr2, pr3, pr4, fork},
+			LocalParents: map[string]string{"feature5": "feature3"},
+			WantParents: map[string]string{
+				"feature1": "master",
+				"feature2": "feature1",
+				"feature3": "feature2",
+				"feature5": "feature3",
+			},
+		},
+		{
+			Desc:         "local branch without pull request",
+			Branch:       "feature5",
+			PullRequests: []*github.PullRequest{pr1, pr2, pr3, pr4},
+			LocalParents: map[string]string{"feature5": "feature3", "feature3": "dev"},
+			WantParents: map[string]string{
+				"feature1": "master",
+				"feature2": "feature1",
+				"feature3": "feature2",
+				"feature5": "feature3",
+			},
+		},
+		{
+			Desc:         "offline",
+			Branch:       "feature2",
+			Offline:      true,
+			LocalParents: map[string]string{"feature2": "feature1", "feature1": "master"},
+			WantParents:  map[string]string{"feature1": "master", "feature2": "feature1"},
+			WantOffline:  true,
+		},
+		{
+			Desc:   "cycle",
+			Branch: "feature1",
+			PullRequests: []*github.PullRequest{
+				pull(1, "feature1", "feature2"),
+				pull(2, "feature2", "feature3"),
+				pull(3, "feature3", "feature2"),
+			},
+			WantError: `branch "feature2" depends on itself`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			git := gatewaytest.NewMockGit(mockCtrl)
+			gh := gatewaytest.NewMockGitHub(mockCtrl)
+
+			if tt.Offline {
+				gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", gomock.Any()).
+					Return(nil, errors.New("great sadness")).AnyTimes()
+			} else {
+				byHead := make(map[string][]*github.PullRequest)
+				byBase := make(map[string][]*github.PullRequest)
+				for _, pr := range tt.PullRequests {
+					head, base := pr.Head.GetRef(), pr.Base.GetRef()
+					byHead[head] = append(byHead[head], pr)
+					byBase[base] = append(byBase[base], pr)
+				}
+				for head, prs := range byHead {
+					gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", head).
+						Return(prs, nil).AnyTimes()
+				}
+				for base, prs := range byBase {
+					gh.EXPECT().ListPullRequestsByBase(gomock.Any(), base).
+						Return(prs, nil).AnyTimes()
+				}
+				gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", gomock.Any()).
+					Return(nil, nil).AnyTimes()
+				gh.EXPECT().ListPullRequestsByBase(gomock.Any(), gomock.Any()).
+					Return(nil, nil).AnyTimes()
+				gh.EXPECT().IsOwned(gomock.Any(), fork.Head).Return(false).AnyTimes()
+				gh.EXPECT().IsOwned(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+			}
+
+			localConfig := make(map[string]string)
+			for branch, parent := range tt.LocalParents {
+				key := "branch." + branch + ".git-pr-parent"
+				localConfig[key] = parent
+				git.EXPECT().GetConfig(key).Return(parent, nil).AnyTimes()
+			}
+			git.EXPECT().GetConfig(gomock.Any()).Return("", nil).AnyTimes()
+			git.EXPECT().ListConfig(gomock.Any()).Return(localConfig, nil).AnyTimes()
+
+			var offline error
+			cfg := StackConfig{
+				GraphConfig: GraphConfig{
+					Context: context.Background(),
+					GitHub:  gh,
+					Include: OwnedPullRequests(context.Background(), gh),
+				},
+				Git:     git,
+				Offline: func(err error) { offline = err },
+			}
+
+			g, err := LoadStack(cfg, tt.Branch)
+			if tt.WantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.WantError)
+				return
+			}
+			require.NoError(t, err)
+
+			parents := make(map[string]string)
+			for _, br := range g.TopologicalOrder() {
+				parents[br] = g.Parent(br)
+			}
+			assert.Equal(t, tt.WantParents, parents)
+
+			if tt.WantOffline {
+				assert.Error(t, offline, "expected to go offline")
+			} else {
+				assert.NoError(t, offline, "unexpected offline fallback")
+				assert.Equal(t, pr3, g.PullRequest("feature3"))
+			}
+		})
+	}
+}
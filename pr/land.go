@@ -4,14 +4,36 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/abhinav/git-pr/gateway"
 	"github.com/abhinav/git-pr/service"
+
+	"github.com/google/go-github/github"
+	"go.uber.org/multierr"
 )
 
 // Land the given pull request.
 func (s *Service) Land(ctx context.Context, req *service.LandRequest) (*service.LandResponse, error) {
 	pr := req.PullRequest
-	if err := UpdateMessage(req.Editor, pr); err != nil {
-		return nil, err
+
+	// GitHub doesn't create a new commit when rebasing so there's no message
+	// to edit.
+	if req.MergeMethod != gateway.MergeRebase {
+		trailers, err := s.trailers(ctx, pr, req.Trailers)
+		if err != nil {
+			return nil, err
+		}
+
+		err = UpdateMessage(MessageConfig{
+			Editor:   req.Editor,
+			Template: req.MessageTemplate,
+			Trailers: trailers,
+			Lint:     req.Lint,
+			Context:  ctx,
+			GitHub:   s.gh,
+		}, pr)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// If the base branch doesn't exist locally, check it out. If it exists,
@@ -39,7 +61,11 @@ func (s *Service) Land(ctx context.Context, req *service.LandRequest) (*service.
 		}
 	}
 
-	if err := s.gh.SquashPullRequest(ctx, pr); err != nil {
+	err := s.gh.MergePullRequest(ctx, &gateway.MergeRequest{
+		PullRequest: pr,
+		Method:      req.MergeMethod,
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -47,12 +73,14 @@ func (s *Service) Land(ctx context.Context, req *service.LandRequest) (*service.
 		return nil, err
 	}
 
-	// TODO: Remove hard coded remote name
-	if err := s.git.Pull("origin", base); err != nil {
+	if err := s.git.Pull(s.remote, base); err != nil {
 		return nil, err
 	}
 
 	if req.LocalBranch != "" {
+		if err := s.reparentChildren(req.LocalBranch, base); err != nil {
+			return nil, err
+		}
 		if err := s.git.DeleteBranch(req.LocalBranch); err != nil {
 			return nil, err
 		}
@@ -63,9 +91,21 @@ func (s *Service) Land(ctx context.Context, req *service.LandRequest) (*service.
 		return nil, nil
 	}
 
-	dependents, err := s.gh.ListPullRequestsByBase(ctx, *pr.Head.Ref)
-	if err != nil {
-		return nil, err
+	// Only the direct dependents are needed here. Rebase will take care of
+	// the rest of the stack.
+	var dependents []*github.PullRequest
+	graph, loadErr := LoadGraph(GraphConfig{
+		Context:     ctx,
+		GitHub:      s.gh,
+		Include:     OwnedPullRequests(ctx, s.gh),
+		MaxDepth:    1,
+		Concurrency: s.concurrency,
+	}, []*github.PullRequest{pr})
+	if loadErr != nil {
+		// The pull request was already merged so we still clean up after it.
+		loadErr = fmt.Errorf("failed to find dependents of %v: %v", *pr.HTMLURL, loadErr)
+	} else {
+		dependents = graph.PullRequests(graph.Children(pr.Head.GetRef()))
 	}
 
 	var res service.LandResponse
@@ -74,21 +114,24 @@ func (s *Service) Land(ctx context.Context, req *service.LandRequest) (*service.
 		if err != nil {
 			return nil, fmt.Errorf("failed to rebase dependents of %v: %v", *pr.HTMLURL, err)
 		}
+		res.RebasedPullRequests = rebaseRes.RebasedPullRequests
 		res.BranchesNotUpdated = rebaseRes.BranchesNotUpdated
 	}
 
 	// TODO: What happens on branch deletion if we had dependents but none
 	// were owned by us?
 	if err := s.gh.DeleteBranch(ctx, *pr.Head.Ref); err != nil {
-		return nil, err
+		return nil, multierr.Append(loadErr, err)
 	}
 
 	if req.LocalBranch != "" {
-		// TODO: Remove hard coded remote name
-		if err := s.git.DeleteRemoteTrackingBranch("origin", req.LocalBranch); err != nil {
-			return nil, err
+		if err := s.git.DeleteRemoteTrackingBranch(s.remote, req.LocalBranch); err != nil {
+			return nil, multierr.Append(loadErr, err)
 		}
 	}
 
+	if loadErr != nil {
+		return nil, loadErr
+	}
 	return &res, nil
 }
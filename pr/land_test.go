@@ -0,0 +1,133 @@
+package pr
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceLandCleansUpAfterMerge(t *testing.T) {
+	pull := func(num int, head, base string) *github.PullRequest {
+		return &github.PullRequest{
+			Number:  github.Int(num),
+			HTMLURL: github.String(head),
+			Head: &github.PullRequestBranch{
+				Ref: github.String(head),
+				SHA: github.String(head + "sha"),
+			},
+			Base: &github.PullRequestBranch{Ref: github.String(base)},
+		}
+	}
+
+	pr := pull(1, "feature1", "master")
+
+	// Pull request from a fork that's based on feature1.
+	fork := pull(2, "patch-1", "feature1")
+
+	tests := []struct {
+		Desc string
+
+		Dependents      []*github.PullRequest
+		DependentsError error
+
+		// Local branch of the pull request and the locally recorded parents
+		// of branches.
+		LocalBranch  string
+		LocalParents map[string]string
+
+		// Parents expected to be recorded for branches.
+		WantParents map[string]string
+
+		WantError string
+	}{
+		{
+			Desc:       "fork dependents",
+			Dependents: []*github.PullRequest{fork},
+		},
+		{
+			Desc:        "local children",
+			LocalBranch: "feature1",
+			LocalParents: map[string]string{
+				"feature2": "feature1",
+				"feature3": "feature1",
+				"feature4": "feature2",
+			},
+			WantParents: map[string]string{
+				"feature2": "master",
+				"feature3": "master",
+			},
+		},
+		{
+			Desc:            "dependents unavailable",
+			DependentsError: errors.New("great sadness"),
+			WantError:       "failed to find dependents of feature1: great sadness",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			git := gatewaytest.NewMockGit(mockCtrl)
+			gh := gatewaytest.NewMockGitHub(mockCtrl)
+
+			git.EXPECT().DoesBranchExist("master").Return(true)
+			gh.EXPECT().MergePullRequest(gomock.Any(), &gateway.MergeRequest{
+				PullRequest: pr,
+				Method:      gateway.MergeRebase,
+			}).Return(nil)
+			git.EXPECT().Checkout("master").Return(nil)
+			git.EXPECT().Pull("origin", "master").Return(nil)
+
+			gh.EXPECT().IsOwned(gomock.Any(), pr.Head).Return(true).AnyTimes()
+			gh.EXPECT().IsOwned(gomock.Any(), fork.Head).Return(false).AnyTimes()
+			gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature1").
+				Return(tt.Dependents, tt.DependentsError)
+
+			// The branch is deleted even if the dependents couldn't be
+			// found.
+			gh.EXPECT().DeleteBranch(gomock.Any(), "feature1").Return(nil)
+
+			if tt.LocalBranch != "" {
+				git.EXPECT().SHA1(tt.LocalBranch).Return(pr.Head.GetSHA(), nil)
+				git.EXPECT().DeleteBranch(tt.LocalBranch).Return(nil)
+				git.EXPECT().DeleteRemoteTrackingBranch("origin", tt.LocalBranch).Return(nil)
+			}
+
+			localConfig := make(map[string]string)
+			for branch, parent := range tt.LocalParents {
+				key := "branch." + branch + ".git-pr-parent"
+				localConfig[key] = parent
+				git.EXPECT().GetConfig(key).Return(parent, nil).AnyTimes()
+			}
+			git.EXPECT().GetConfig(gomock.Any()).Return("", nil).AnyTimes()
+			git.EXPECT().ListConfig(gomock.Any()).Return(localConfig, nil).AnyTimes()
+			for branch, parent := range tt.WantParents {
+				git.EXPECT().SetConfig("branch."+branch+".git-pr-parent", parent).Return(nil)
+			}
+
+			_, err := NewService(ServiceConfig{Git: git, GitHub: gh}).
+				Land(context.Background(), &service.LandRequest{
+					PullRequest: pr,
+					LocalBranch: tt.LocalBranch,
+					MergeMethod: gateway.MergeRebase,
+				})
+			if tt.WantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.WantError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package pr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/abhinav/git-pr/service"
+)
+
+var (
+	_conventionalTitleRegexp = regexp.MustCompile(`^[a-z]+(\([^()]+\))?!?: \S`)
+	_issueRegexp             = regexp.MustCompile(`#\d+\b|/issues/\d+\b|\b[A-Z][A-Z0-9]+-\d+\b`)
+)
+
+// LintMessage checks the given commit message against the given rules. A
+// list of human-readable problems is returned if it doesn't follow them.
+func LintMessage(rules service.LintRules, title, body string) []string {
+	var problems []string
+
+	if n := utf8.RuneCountInString(title); rules.MaxTitleLength > 0 && n > rules.MaxTitleLength {
+		problems = append(problems, fmt.Sprintf(
+			"title is %v characters long; it must be at most %v", n, rules.MaxTitleLength))
+	}
+
+	if rules.ConventionalCommits && !_conventionalTitleRegexp.MatchString(title) {
+		problems = append(problems,
+			`title must be in the form "type(scope): description", e.g. "fix(parser): handle empty input"`)
+	}
+
+	if rules.BodyWidth > 0 {
+		for i, line := range strings.Split(body, "\n") {
+			// Code blocks and long URLs can't be wrapped.
+			if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") ||
+				!strings.Contains(line, " ") {
+				continue
+			}
+
+			if n := utf8.RuneCountInString(line); n > rules.BodyWidth {
+				problems = append(problems, fmt.Sprintf(
+					"line %v of the body is %v characters long; wrap it at %v", i+1, n, rules.BodyWidth))
+			}
+		}
+	}
+
+	if rules.RequireIssue && !_issueRegexp.MatchString(body) {
+		problems = append(problems, `body must reference an issue, e.g. "Fixes #123"`)
+	}
+
+	return problems
+}
@@ -0,0 +1,88 @@
+package pr
+
+import (
+	"testing"
+
+	"github.com/abhinav/git-pr/editor"
+	"github.com/abhinav/git-pr/service"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintMessage(t *testing.T) {
+	tests := []struct {
+		Desc  string
+		Rules service.LintRules
+		Title string
+		Body  string
+
+		Want []string
+	}{
+		{
+			Desc:  "no rules",
+			Title: "this title goes on and on and on and on and on and on and on and on and on",
+		},
+		{
+			Desc:  "title too long",
+			Rules: service.LintRules{MaxTitleLength: 10},
+			Title: "Fix the bug",
+			Want:  []string{"title is 11 characters long; it must be at most 10"},
+		},
+		{
+			Desc:  "conventional commit",
+			Rules: service.LintRules{ConventionalCommits: true},
+			Title: "fix(parser)!: handle empty input",
+		},
+		{
+			Desc:  "not a conventional commit",
+			Rules: service.LintRules{ConventionalCommits: true},
+			Title: "Handle empty input",
+			Want: []string{
+				`title must be in the form "type(scope): description", e.g. "fix(parser): handle empty input"`,
+			},
+		},
+		{
+			Desc:  "body too wide",
+			Rules: service.LintRules{BodyWidth: 20},
+			Title: "Fix a bug",
+			Body: "This line is short.\n" +
+				"\n" +
+				"This line is a lot longer than the limit.\n" +
+				"    indented code that goes past the limit\n" +
+				"https://example.com/a/very/long/url/that/cannot/be/wrapped",
+			Want: []string{"line 3 of the body is 41 characters long; wrap it at 20"},
+		},
+		{
+			Desc:  "issue required",
+			Rules: service.LintRules{RequireIssue: true},
+			Title: "Fix a bug (#42)",
+			Body:  "Fixes a bug.",
+			Want:  []string{`body must reference an issue, e.g. "Fixes #123"`},
+		},
+		{
+			Desc:  "issue referenced",
+			Rules: service.LintRules{RequireIssue: true},
+			Title: "Fix a bug",
+			Body:  "Fixes PROJ-123.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			assert.Equal(t, tt.Want, LintMessage(tt.Rules, tt.Title, tt.Body))
+		})
+	}
+}
+
+func TestUpdateMessageLintUnchanged(t *testing.T) {
+	// Editors that always produce the same message fail instead of being
+	// asked forever.
+	err := UpdateMessage(MessageConfig{
+		Editor: editor.NewStatic("Fix a bug\n"),
+		Lint:   service.LintRules{ConventionalCommits: true},
+	}, &github.PullRequest{Number: github.Int(1), Title: github.String("Fix a bug")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "commit message has problems:\n - title must be in the form")
+}
@@ -1,13 +1,14 @@
 package pr
 
 import (
-	"container/list"
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/abhinav/git-pr/gateway"
 	"github.com/abhinav/git-pr/git"
+	"github.com/abhinav/git-pr/logging"
 	"github.com/abhinav/git-pr/service"
 
 	"github.com/google/go-github/github"
@@ -29,28 +30,29 @@ func (s *Service) Rebase(ctx context.Context, req *service.RebaseRequest) (_ *se
 		err = multierr.Append(err, s.git.Checkout(oldBranch))
 	}(oldBranch)
 
-	if err := s.git.Fetch(&gateway.FetchRequest{Remote: "origin"}); err != nil {
+	if err := s.git.Fetch(&gateway.FetchRequest{Remote: s.remote}); err != nil {
 		return nil, err
 	}
 
-	// TODO: support remotes besides origin
-	baseRef, err := s.git.SHA1("origin/" + req.Base)
+	baseRef, err := s.git.SHA1(s.remote + "/" + req.Base)
 	if err != nil {
 		return nil, err
 	}
 
-	rebaser := git.NewBulkRebaser(s.git)
+	rebaser := git.NewBulkRebaser(s.git, s.log.Named("rebase"))
 	defer func() {
 		err = multierr.Append(err, rebaser.Cleanup())
 	}()
 
 	results, err := s.rebasePullRequests(rebasePRConfig{
 		Context:      ctx,
+		Log:          s.log,
 		GitRebaser:   rebaser,
 		GitHub:       s.gh,
 		Base:         baseRef,
 		PullRequests: req.PullRequests,
 		Author:       req.Author,
+		Concurrency:  s.concurrency,
 	})
 	if err != nil {
 		return nil, err
@@ -70,8 +72,18 @@ func (s *Service) Rebase(ctx context.Context, req *service.RebaseRequest) (_ *se
 
 		// Pushes to perform. local ref -> remote branch
 		pushes = make(map[string]string)
+
+		// New parents of local branches. branch -> parent
+		parents = make(map[string]string)
+
+		rebased []service.RebasedPullRequest
 	)
 
+	topLevel := make(map[int]struct{}, len(req.PullRequests))
+	for _, pr := range req.PullRequests {
+		topLevel[pr.GetNumber()] = struct{}{}
+	}
+
 	for _, r := range results {
 		prBranch := r.PR.Head.GetRef()
 		if sha, err := s.git.SHA1(prBranch); err == nil {
@@ -80,12 +92,37 @@ func (s *Service) Rebase(ctx context.Context, req *service.RebaseRequest) (_ *se
 			} else {
 				branchesNotUpdated = append(branchesNotUpdated, prBranch)
 			}
+
+			parent := r.PR.Base.GetRef()
+			if _, ok := topLevel[r.PR.GetNumber()]; ok {
+				parent = req.Base
+			}
+			parents[prBranch] = parent
 		}
 		pushes[r.LocalRef] = prBranch
+
+		newSHA, err := s.git.SHA1(r.LocalRef)
+		if err != nil {
+			return nil, err
+		}
+		rebased = append(rebased, service.RebasedPullRequest{
+			PullRequest: r.PR,
+			OldSHA:      r.PR.Head.GetSHA(),
+			NewSHA:      newSHA,
+		})
+	}
+	sort.Slice(rebased, func(i, j int) bool {
+		return rebased[i].PullRequest.GetNumber() < rebased[j].PullRequest.GetNumber()
+	})
+
+	// Don't push anything if the user interrupted us while rebasing.
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
+	s.log.Debug("pushing rebased pull requests", logging.Int("count", len(pushes)))
 	if err := s.git.Push(&gateway.PushRequest{
-		Remote: "origin",
+		Remote: s.remote,
 		Force:  true,
 		Refs:   pushes,
 	}); err != nil {
@@ -93,37 +130,97 @@ func (s *Service) Rebase(ctx context.Context, req *service.RebaseRequest) (_ *se
 	}
 
 	for _, br := range branchesToReset {
-		err = multierr.Append(err, s.git.ResetBranch(br, "origin/"+br))
+		err = multierr.Append(err, s.git.ResetBranch(br, s.remote+"/"+br))
+	}
+
+	// Record the new stack locally so that it's available offline.
+	for br, parent := range parents {
+		err = multierr.Append(err, git.SetParent(s.git, br, parent))
+	}
+
+	var retarget []*github.PullRequest
+	for _, pr := range req.PullRequests {
+		// TODO: --only-mine should apply
+		if pr.Base.GetRef() != req.Base {
+			retarget = append(retarget, pr)
+		}
 	}
+	err = multierr.Append(err, s.setPullRequestBases(ctx, retarget, req.Base))
 
+	// Let reviewers know where the rebased pull requests are in their stacks.
+	var rebasedTopLevel []*github.PullRequest
+	for _, pr := range req.PullRequests {
+		if _, ok := results[pr.GetNumber()]; ok {
+			rebasedTopLevel = append(rebasedTopLevel, pr)
+		}
+	}
+	// The rebase already succeeded so failing to update descriptions isn't
+	// fatal.
+	if err == nil {
+		if e := s.updateStackSections(ctx, req.Base, rebasedTopLevel); e != nil {
+			s.log.Info("failed to list stacks in pull request descriptions", logging.Error(e))
+		}
+	}
+
+	return &service.RebaseResponse{
+		RebasedPullRequests: rebased,
+		BranchesNotUpdated:  branchesNotUpdated,
+	}, err
+}
+
+// Maximum number of pull requests whose bases are changed at the same time
+// unless the concurrency was configured.
+const _setBaseConcurrency = 4
+
+// setPullRequestBases changes the bases of the given pull requests to base.
+// No new requests are made once ctx is cancelled.
+func (s *Service) setPullRequestBases(ctx context.Context, prs []*github.PullRequest, base string) (err error) {
 	var (
 		mu sync.Mutex
 		wg sync.WaitGroup
+
+		pulls = make(chan *github.PullRequest)
 	)
-	for _, pr := range req.PullRequests {
-		// TODO: --only-mine should apply
-		if pr.Base.GetRef() != req.Base {
-			wg.Add(1)
-			// TODO: fix unbounded goroutine count
-			go func(pr *github.PullRequest) {
-				defer wg.Done()
-				e := s.gh.SetPullRequestBase(ctx, *pr.Number, req.Base)
+	concurrency := s.concurrency
+	if concurrency <= 0 {
+		concurrency = _setBaseConcurrency
+	}
+	for i := 0; i < concurrency && i < len(prs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pr := range pulls {
+				s.log.Debug("changing base",
+					logging.Int("pr", pr.GetNumber()), logging.String("base", base))
+				e := s.gh.SetPullRequestBase(ctx, pr.GetNumber(), base)
 				if e == nil {
-					return
+					continue
 				}
 
 				mu.Lock()
 				err = multierr.Append(err, fmt.Errorf(
-					"failed to set base for %v to %q: %v", *pr.HTMLURL, req.Base, e))
+					"failed to set base for %v to %q: %v", pr.GetHTMLURL(), base, e))
 				mu.Unlock()
-			}(pr)
+			}
+		}()
+	}
+
+	for _, pr := range prs {
+		// select picks randomly if both cases are ready so we check this
+		// first.
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case pulls <- pr:
+		case <-ctx.Done():
 		}
 	}
+	close(pulls)
 	wg.Wait()
 
-	return &service.RebaseResponse{
-		BranchesNotUpdated: branchesNotUpdated,
-	}, err
+	return multierr.Append(err, ctx.Err())
 }
 
 type rebasedPullRequest struct {
@@ -148,76 +245,63 @@ type rebasePRConfig struct {
 	Author string
 
 	Context      context.Context
+	Log          *logging.Logger
 	GitRebaser   bulkRebaser
 	GitHub       gateway.GitHub
 	Base         string
 	PullRequests []*github.PullRequest
+	Concurrency  int
 }
 
 func rebasePullRequests(cfg rebasePRConfig) (map[int]rebasedPullRequest, error) {
-	v := rebaseVisitor{
-		rebasePRConfig: &cfg,
-		handle:         cfg.GitRebaser.Onto(cfg.Base),
-		mu:             new(sync.Mutex),
-		results:        list.New(),
-	}
-
-	walkCfg := WalkConfig{Children: getDependentPRs(cfg.Context, cfg.GitHub)}
-	if err := Walk(walkCfg, cfg.PullRequests, v); err != nil {
-		return nil, err
-	}
+	handle := cfg.GitRebaser.Onto(cfg.Base)
+
+	graph, err := LoadGraph(GraphConfig{
+		Context:     cfg.Context,
+		GitHub:      cfg.GitHub,
+		Concurrency: cfg.Concurrency,
+		Include: func(pr *github.PullRequest) bool {
+			// Don't rebase if we don't own the PR.
+			if !cfg.GitHub.IsOwned(cfg.Context, pr.Head) {
+				// TODO: There is more nuance to this. We should check if we
+				// have write access instead.
+				cfg.Log.Debug("skipping pull request owned by another repository",
+					logging.Int("pr", pr.GetNumber()))
+				return false
+			}
 
-	if err := cfg.GitRebaser.Err(); err != nil {
+			if cfg.Author != "" && pr.User.GetLogin() != cfg.Author {
+				cfg.Log.Debug("skipping pull request by another author",
+					logging.Int("pr", pr.GetNumber()),
+					logging.String("author", pr.User.GetLogin()))
+				return false
+			}
+			return true
+		},
+	}, cfg.PullRequests)
+	if err != nil {
 		return nil, err
 	}
 
-	results := make(map[int]rebasedPullRequest, v.results.Len())
-	for e := v.results.Front(); e != nil; e = e.Next() {
-		p := e.Value.(rebasedPullRequest)
-		results[p.PR.GetNumber()] = p
-	}
-
-	return results, nil
-}
-
-func getDependentPRs(
-	ctx context.Context, gh gateway.GitHub,
-) func(*github.PullRequest) ([]*github.PullRequest, error) {
-	return func(pr *github.PullRequest) ([]*github.PullRequest, error) {
-		return gh.ListPullRequestsByBase(ctx, pr.Head.GetRef())
-	}
-}
-
-type rebaseVisitor struct {
-	*rebasePRConfig
-
-	mu      *sync.Mutex
-	results *list.List // list<rebasedPullRequest>
-
-	handle git.RebaseHandle
-}
+	// Parents are rebased before their children so each branch can be
+	// rebased onto the new position of its parent.
+	handles := make(map[string]git.RebaseHandle)
+	results := make(map[int]rebasedPullRequest)
+	for _, branch := range graph.TopologicalOrder() {
+		h, ok := handles[graph.Parent(branch)]
+		if !ok {
+			h = handle
+		}
 
-func (v rebaseVisitor) Visit(pr *github.PullRequest) (Visitor, error) {
-	// Don't rebase if we don't own the PR.
-	if !v.GitHub.IsOwned(v.Context, pr.Head) {
-		// TODO: There is more nuance to this. We should check if we have
-		// write access instead.
-		// TODO: Log if we skip
-		return nil, nil
+		pr := graph.PullRequest(branch)
+		h = h.Rebase(pr.Base.GetSHA(), pr.Head.GetSHA())
+		handles[branch] = h
+		results[pr.GetNumber()] = rebasedPullRequest{PR: pr, LocalRef: h.Base()}
 	}
 
-	if v.Author != "" && pr.User.GetLogin() != v.Author {
-		// TODO: log skipped PR
-		return nil, nil
+	if err := cfg.GitRebaser.Err(); err != nil {
+		return nil, err
 	}
 
-	h := v.handle.Rebase(pr.Base.GetSHA(), pr.Head.GetSHA())
-	v.mu.Lock()
-	v.results.PushBack(rebasedPullRequest{PR: pr, LocalRef: h.Base()})
-	v.mu.Unlock()
-
-	// We are operating on a shallow copy of v so we can just modify and
-	// return it.
-	v.handle = h
-	return v, nil
+	return results, nil
 }
@@ -0,0 +1,92 @@
+package pr
+
+import (
+	"context"
+
+	"github.com/abhinav/git-pr/git"
+	"github.com/abhinav/git-pr/service"
+
+	"go.uber.org/multierr"
+)
+
+// RebaseLocal rebases a local branch and the branches that depend on it
+// using only the relationships between branches recorded locally.
+func (s *Service) RebaseLocal(ctx context.Context, req *service.RebaseLocalRequest) (_ *service.RebaseLocalResponse, err error) {
+	// Go back to the original branch after everything is done.
+	oldBranch, err := s.git.CurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+	defer func(oldBranch string) {
+		err = multierr.Append(err, s.git.Checkout(oldBranch))
+	}(oldBranch)
+
+	graph := NewGraph()
+	if err := graph.AddLocalBranches(s.git); err != nil {
+		return nil, err
+	}
+
+	rebaser := git.NewBulkRebaser(s.git, s.log.Named("rebase"))
+	defer func() {
+		err = multierr.Append(err, rebaser.Cleanup())
+	}()
+
+	// Rebase handles for the rebased positions of branches.
+	handles := make(map[string]git.RebaseHandle)
+
+	branches := graph.Subtree(req.Branch)
+	if req.Base == "" {
+		// Only the dependents of the branch are rebased.
+		handles[req.Branch] = rebaser.Onto(req.Branch)
+		branches = branches[1:]
+	}
+
+	for _, br := range branches {
+		// Stop between git operations if the user interrupted us.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		upstream := graph.Parent(br)
+		h, ok := handles[upstream]
+		if !ok {
+			// This is req.Branch. If it wasn't recorded as based on another
+			// branch, the commits that belong to it start where it forked
+			// from the new base.
+			h = rebaser.Onto(req.Base)
+			if upstream == "" {
+				upstream = req.Base
+			}
+		}
+
+		from, err := s.git.ForkPoint(upstream, br)
+		if err != nil {
+			return nil, err
+		}
+		handles[br] = h.Rebase(from, br)
+	}
+
+	if err := rebaser.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var res service.RebaseLocalResponse
+	for _, br := range branches {
+		if err := s.git.ResetBranch(br, handles[br].Base()); err != nil {
+			return nil, err
+		}
+		res.RebasedBranches = append(res.RebasedBranches, br)
+	}
+
+	if req.Base != "" {
+		if err := git.SetParent(s.git, req.Branch, req.Base); err != nil {
+			return nil, err
+		}
+	}
+
+	return &res, nil
+}
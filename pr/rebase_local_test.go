@@ -0,0 +1,192 @@
+package pr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceRebaseLocal(t *testing.T) {
+	type rebase struct {
+		Onto   string
+		From   string
+		Branch string
+	}
+
+	tests := []struct {
+		Desc    string
+		Request service.RebaseLocalRequest
+
+		// Locally recorded parents of branches.
+		Parents map[string]string // branch -> parent
+
+		// Fork points of branches from their upstreams.
+		ForkPoints map[string]string // upstream..branch -> sha
+
+		// Expected rebases. The temporary branch for each is named after it.
+		WantRebases []rebase
+
+		// If non-nil, the last rebase fails with this error.
+		RebaseError error
+
+		// Expected new parent of the branch.
+		WantParent string
+
+		WantResponse service.RebaseLocalResponse
+		WantError    string
+	}{
+		{
+			Desc:    "no children",
+			Request: service.RebaseLocalRequest{Branch: "feature1"},
+		},
+		{
+			Desc:    "children only",
+			Request: service.RebaseLocalRequest{Branch: "feature1"},
+			Parents: map[string]string{
+				"feature1": "master",
+				"feature2": "feature1",
+				"feature3": "feature2",
+			},
+			ForkPoints: map[string]string{
+				"feature1..feature2": "sha1",
+				"feature2..feature3": "sha2",
+			},
+			WantRebases: []rebase{
+				{Onto: "feature1", From: "sha1", Branch: "feature2"},
+				{Onto: "git-pr/rebase/feature2", From: "sha2", Branch: "feature3"},
+			},
+			WantResponse: service.RebaseLocalResponse{
+				RebasedBranches: []string{"feature2", "feature3"},
+			},
+		},
+		{
+			Desc:    "onto new base",
+			Request: service.RebaseLocalRequest{Branch: "feature1", Base: "dev"},
+			Parents: map[string]string{
+				"feature1": "master",
+				"feature2": "feature1",
+				"feature3": "feature1",
+			},
+			ForkPoints: map[string]string{
+				"master..feature1":   "sha0",
+				"feature1..feature2": "sha1",
+				"feature1..feature3": "sha1",
+			},
+			WantRebases: []rebase{
+				{Onto: "dev", From: "sha0", Branch: "feature1"},
+				{Onto: "git-pr/rebase/feature1", From: "sha1", Branch: "feature2"},
+				{Onto: "git-pr/rebase/feature1", From: "sha1", Branch: "feature3"},
+			},
+			WantParent: "dev",
+			WantResponse: service.RebaseLocalResponse{
+				RebasedBranches: []string{"feature1", "feature2", "feature3"},
+			},
+		},
+		{
+			Desc:       "unrecorded branch",
+			Request:    service.RebaseLocalRequest{Branch: "feature1", Base: "dev"},
+			ForkPoints: map[string]string{"dev..feature1": "sha0"},
+			WantRebases: []rebase{
+				{Onto: "dev", From: "sha0", Branch: "feature1"},
+			},
+			WantParent: "dev",
+			WantResponse: service.RebaseLocalResponse{
+				RebasedBranches: []string{"feature1"},
+			},
+		},
+		{
+			Desc:    "cycle",
+			Request: service.RebaseLocalRequest{Branch: "feature1"},
+			Parents: map[string]string{
+				"feature1": "feature2",
+				"feature2": "feature1",
+			},
+			WantError: `branch "feature2" depends on itself`,
+		},
+		{
+			Desc:    "rebase failure",
+			Request: service.RebaseLocalRequest{Branch: "feature1"},
+			Parents: map[string]string{
+				"feature2": "feature1",
+			},
+			ForkPoints: map[string]string{"feature1..feature2": "sha1"},
+			WantRebases: []rebase{
+				{Onto: "feature1", From: "sha1", Branch: "feature2"},
+			},
+			RebaseError: errors.New("merge conflict"),
+			WantError:   "merge conflict",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			git := gatewaytest.NewMockGit(mockCtrl)
+			git.EXPECT().CurrentBranch().Return("oldbranch", nil)
+			git.EXPECT().Checkout("oldbranch").Return(nil)
+
+			config := make(map[string]string)
+			for branch, parent := range tt.Parents {
+				key := "branch." + branch + ".git-pr-parent"
+				config[key] = parent
+				git.EXPECT().GetConfig(key).Return(parent, nil).AnyTimes()
+			}
+			git.EXPECT().GetConfig(gomock.Any()).Return("", nil).AnyTimes()
+			git.EXPECT().ListConfig(gomock.Any()).Return(config, nil).AnyTimes()
+
+			for key, sha := range tt.ForkPoints {
+				parts := strings.SplitN(key, "..", 2)
+				git.EXPECT().ForkPoint(parts[0], parts[1]).Return(sha, nil)
+			}
+
+			for i, r := range tt.WantRebases {
+				temp := "git-pr/rebase/" + r.Branch
+				git.EXPECT().CreateBranchAndCheckout(temp, r.Branch).Return(nil)
+
+				var err error
+				if i == len(tt.WantRebases)-1 {
+					err = tt.RebaseError
+				}
+				git.EXPECT().Rebase(&gateway.RebaseRequest{
+					Onto:   r.Onto,
+					From:   r.From,
+					Branch: temp,
+				}).Return(err)
+
+				git.EXPECT().Checkout(r.Onto).Return(nil)
+				git.EXPECT().DeleteBranch(temp).Return(nil)
+
+				if tt.WantError == "" {
+					git.EXPECT().ResetBranch(r.Branch, temp).Return(nil)
+				}
+			}
+
+			if tt.WantParent != "" {
+				key := "branch." + tt.Request.Branch + ".git-pr-parent"
+				git.EXPECT().SetConfig(key, tt.WantParent).Return(nil)
+			}
+
+			service := NewService(ServiceConfig{Git: git})
+			res, err := service.RebaseLocal(context.Background(), &tt.Request)
+			if tt.WantError != "" {
+				require.Error(t, err, "expected failure")
+				assert.Contains(t, err.Error(), tt.WantError)
+				return
+			}
+
+			require.NoError(t, err, "expected success")
+			assert.Equal(t, tt.WantResponse, *res)
+		})
+	}
+}
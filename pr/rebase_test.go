@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -49,6 +50,9 @@ func TestServiceRebase(t *testing.T) {
 		// is handling this.
 		WantBranchResets []string // branch name -> ref
 
+		// Expected parents recorded for local branches.
+		WantParents map[string]string // branch name -> parent
+
 		// Expected items in Push(). May be empty if SetupGitHub is handling
 		// this.
 		WantPushes map[string]string // local ref -> branch name
@@ -58,6 +62,10 @@ func TestServiceRebase(t *testing.T) {
 		// this.
 		WantBaseChanges []int
 
+		// Error returned when the stacks of rebased pull requests are listed
+		// in their descriptions. Rebases must succeed regardless.
+		UpdateStacksError error
+
 		WantResponse service.RebaseResponse
 		WantErrors   []string
 	}
@@ -95,6 +103,7 @@ func TestServiceRebase(t *testing.T) {
 
 			tt.WantPushes = map[string]string{"git-pr/rebase/headsha": "myfeature"}
 			tt.WantBranchResets = []string{"myfeature"}
+			tt.WantParents = map[string]string{"myfeature": "master"}
 
 			return
 		}(),
@@ -144,6 +153,7 @@ func TestServiceRebase(t *testing.T) {
 
 			tt.WantPushes = map[string]string{"git-pr/rebase/somesha": "myfeature"}
 			tt.WantBaseChanges = []int{1}
+			tt.WantParents = map[string]string{"myfeature": "master"}
 			tt.WantResponse = service.RebaseResponse{
 				BranchesNotUpdated: []string{"myfeature"},
 			}
@@ -210,7 +220,17 @@ func TestServiceRebase(t *testing.T) {
 			}
 			tt.WantBaseChanges = []int{2, 3}
 			tt.WantBranchResets = []string{"feature-1", "feature-2"}
+			tt.WantParents = map[string]string{
+				"feature-1": "dev",
+				"feature-2": "dev",
+				"feature-3": "dev",
+			}
 			tt.WantResponse = service.RebaseResponse{
+				RebasedPullRequests: []service.RebasedPullRequest{
+					{PullRequest: pr1, OldSHA: "sha1", NewSHA: "newsha1"},
+					{PullRequest: pr2, OldSHA: "sha2", NewSHA: "newsha2"},
+					{PullRequest: pr3, OldSHA: "sha3", NewSHA: "newsha3"},
+				},
 				BranchesNotUpdated: []string{"feature-3"},
 			}
 
@@ -220,6 +240,7 @@ func TestServiceRebase(t *testing.T) {
 			tt.Desc = "simple stack"
 
 			// dev -> feature-1 -> feature-2 -> feature-3
+			tt.UpdateStacksError = errors.New("great sadness")
 
 			pr := &github.PullRequest{
 				Number:  github.Int(1),
@@ -276,6 +297,10 @@ func TestServiceRebase(t *testing.T) {
 			tt.SHA1Failures = []string{"feature-2"}
 
 			tt.WantBranchResets = []string{"feature-3"}
+			tt.WantParents = map[string]string{
+				"feature-1": "dev",
+				"feature-3": "feature-2",
+			}
 			tt.WantPushes = map[string]string{
 				"git-pr/rebase/sha1": "feature-1",
 				"git-pr/rebase/sha2": "feature-2",
@@ -396,6 +421,12 @@ func TestServiceRebase(t *testing.T) {
 			tt.SHA1Failures = []string{"feature-3", "feature-6"}
 
 			tt.WantBranchResets = []string{"feature-1", "feature-5"}
+			tt.WantParents = map[string]string{
+				"feature-1": "dev",
+				"feature-2": "dev",
+				"feature-4": "feature-1",
+				"feature-5": "feature-2",
+			}
 			tt.WantPushes = map[string]string{
 				"git-pr/rebase/sha1": "feature-1",
 				"git-pr/rebase/sha2": "feature-2",
@@ -533,6 +564,7 @@ func TestServiceRebase(t *testing.T) {
 
 			tt.WantPushes = map[string]string{"git-pr/rebase/headsha": "myfeature"}
 			tt.WantBranchResets = []string{"myfeature"}
+			tt.WantParents = map[string]string{"myfeature": "dev"}
 
 			tt.SetupGitHub = func(gh *gatewaytest.MockGitHub) {
 				gh.EXPECT().SetPullRequestBase(gomock.Any(), 1, "dev").
@@ -563,6 +595,12 @@ func TestServiceRebase(t *testing.T) {
 				git.EXPECT().ResetBranch(branch, "origin/"+branch).Return(nil)
 			}
 
+			for branch, parent := range tt.WantParents {
+				key := "branch." + branch + ".git-pr-parent"
+				git.EXPECT().GetConfig(key).Return("", nil)
+				git.EXPECT().SetConfig(key, parent).Return(nil)
+			}
+
 			if len(tt.WantPushes) > 0 {
 				git.EXPECT().Push(&gateway.PushRequest{
 					Remote: "origin",
@@ -578,6 +616,11 @@ func TestServiceRebase(t *testing.T) {
 				git.EXPECT().SHA1(branch).
 					Return("", fmt.Errorf("unknown branch %q", branch))
 			}
+			if tt.RebasePRsError == nil {
+				for _, r := range tt.RebasePRsResult {
+					git.EXPECT().SHA1(r.LocalRef).Return("new"+r.PR.Head.GetSHA(), nil)
+				}
+			}
 
 			for _, prNum := range tt.WantBaseChanges {
 				gh.EXPECT().
@@ -599,6 +642,15 @@ func TestServiceRebase(t *testing.T) {
 			service.rebasePullRequests = fakeRebasePullRequests(
 				tt.RebasePRsResult, tt.RebasePRsError)
 
+			var stackUpdates []int
+			service.updateStackSections = func(_ context.Context, base string, prs []*github.PullRequest) error {
+				assert.Equal(t, tt.Request.Base, base, "stacks must be updated from the new base")
+				for _, pr := range prs {
+					stackUpdates = append(stackUpdates, pr.GetNumber())
+				}
+				return tt.UpdateStacksError
+			}
+
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 			defer cancel()
 
@@ -625,10 +677,86 @@ func TestServiceRebase(t *testing.T) {
 
 			assert.Equal(t, wantBranchesNotUpdated, gotBranchesNotUpdated,
 				"BranchesNotUpdated must match")
+
+			// Stacks are updated starting at the top-level pull requests
+			// that were rebased.
+			var wantStackUpdates []int
+			for _, pr := range tt.Request.PullRequests {
+				for _, r := range tt.RebasePRsResult {
+					if r.PR == pr {
+						wantStackUpdates = append(wantStackUpdates, pr.GetNumber())
+					}
+				}
+			}
+			assert.Equal(t, wantStackUpdates, stackUpdates, "stack updates must match")
+
+			if tt.WantResponse.RebasedPullRequests != nil {
+				assert.Equal(t, tt.WantResponse.RebasedPullRequests, res.RebasedPullRequests,
+					"RebasedPullRequests must match")
+			}
 		})
 	}
 }
 
+func TestSetPullRequestBases(t *testing.T) {
+	var prs []*github.PullRequest
+	for i := 1; i <= 20; i++ {
+		prs = append(prs, &github.PullRequest{Number: github.Int(i)})
+	}
+
+	t.Run("bounded", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		var mu sync.Mutex
+		var ongoing, maxOngoing int
+
+		gh := gatewaytest.NewMockGitHub(mockCtrl)
+		for _, pr := range prs {
+			gh.EXPECT().SetPullRequestBase(gomock.Any(), pr.GetNumber(), "master").
+				Do(func(context.Context, int, string) {
+					mu.Lock()
+					ongoing++
+					if ongoing > maxOngoing {
+						maxOngoing = ongoing
+					}
+					mu.Unlock()
+
+					time.Sleep(time.Millisecond)
+
+					mu.Lock()
+					ongoing--
+					mu.Unlock()
+				}).
+				Return(nil)
+		}
+
+		service := NewService(ServiceConfig{GitHub: gh})
+		require.NoError(t, service.setPullRequestBases(context.Background(), prs, "master"))
+		assert.True(t, maxOngoing <= _setBaseConcurrency,
+			"expected at most %v concurrent requests, got %v", _setBaseConcurrency, maxOngoing)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// No requests should be made.
+		gh := gatewaytest.NewMockGitHub(mockCtrl)
+		service := NewService(ServiceConfig{GitHub: gh})
+		err := service.setPullRequestBases(ctx, prs, "master")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "context canceled")
+	})
+}
+
+func noopUpdateStackSections(context.Context, string, []*github.PullRequest) error {
+	return nil
+}
+
 func fakeRebasePullRequests(
 	results []rebasedPullRequest, err error,
 ) func(rebasePRConfig) (map[int]rebasedPullRequest, error) {
@@ -779,14 +907,7 @@ func TestRebasePullRequests(t *testing.T) {
 					Return(nil, errors.New("great sadness"))
 			}
 
-			tt.RebaserError = errors.New("great sadness")
-			tt.WantRebases = []fakeRebase{
-				{
-					FromRef: "basesha",
-					ToRef:   "headsha",
-					GiveRef: "newsha",
-				},
-			}
+			// Nothing is rebased if we can't determine the full stack.
 			tt.WantErrors = []string{"great sadness"}
 
 			return
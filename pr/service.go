@@ -1,32 +1,70 @@
 package pr
 
 import (
+	"context"
+
 	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/logging"
 	"github.com/abhinav/git-pr/service"
+
+	"github.com/google/go-github/github"
 )
 
 // ServiceConfig specifies the different parameters for a PR service.
 type ServiceConfig struct {
 	GitHub gateway.GitHub
 	Git    gateway.Git
+
+	// Logger for diagnostic output. May be nil.
+	Log *logging.Logger
+
+	// Login of the current GitHub user. Only the descriptions of pull
+	// requests authored by this user are changed to list their stacks.
+	User string
+
+	// Name of the git remote for the GitHub repository. Defaults to
+	// "origin".
+	Remote string
+
+	// Maximum number of concurrent requests to GitHub. Defaults to the
+	// number of CPUs available to this process.
+	Concurrency int
 }
 
 // Service is a PR service.
 type Service struct {
 	gh  gateway.GitHub
 	git gateway.Git
+	log *logging.Logger
+
+	user        string
+	remote      string
+	concurrency int
 
 	// Hidden option to customize how we rebase pull requests.
 	rebasePullRequests func(rebasePRConfig) (map[int]rebasedPullRequest, error)
+
+	// Hidden option to customize how stacks are listed in the descriptions
+	// of pull requests.
+	updateStackSections func(ctx context.Context, base string, pulls []*github.PullRequest) error
 }
 
 // NewService builds a new PR service with the given configuration.
 func NewService(cfg ServiceConfig) *Service {
-	return &Service{
+	s := &Service{
 		gh:                 cfg.GitHub,
 		git:                cfg.Git,
+		log:                cfg.Log,
+		user:               cfg.User,
+		remote:             cfg.Remote,
+		concurrency:        cfg.Concurrency,
 		rebasePullRequests: rebasePullRequests,
 	}
+	if s.remote == "" {
+		s.remote = "origin"
+	}
+	s.updateStackSections = s.updateStacks
+	return s
 }
 
 var _ service.PR = (*Service)(nil)
@@ -0,0 +1,177 @@
+package pr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"go.uber.org/multierr"
+)
+
+// Markers around the section of a pull request description that lists the
+// stack the pull request is part of. Everything between them belongs to
+// git-pr. Everything else belongs to the author.
+const (
+	_stackBegin = "<!-- git-pr stack: do not edit below this line -->"
+	_stackEnd   = "<!-- git-pr stack: do not edit above this line -->"
+)
+
+// StackSection renders the section of the description of the pull request
+// for the given branch that lists its stack. The stack is listed starting at
+// its base branch.
+//
+// 	**Stack**
+//
+// 	- `master`
+// 	  - #1
+// 	    - **#2 (this pull request)**
+// 	      - #3
+func StackSection(graph *Graph, branch string) string {
+	path := graph.PathToRoot(branch)
+	if len(path) == 0 {
+		return ""
+	}
+	root := path[len(path)-1]
+
+	lines := []string{
+		_stackBegin,
+		"**Stack**",
+		"",
+		fmt.Sprintf("- `%v`", graph.Parent(root)),
+	}
+	for _, br := range graph.Subtree(root) {
+		item := br
+		if pr := graph.PullRequest(br); pr != nil {
+			item = fmt.Sprintf("#%v", pr.GetNumber())
+		}
+		if br == branch {
+			item = fmt.Sprintf("**%v (this pull request)**", item)
+		}
+
+		indent := strings.Repeat("  ", graph.Depth(br)+1)
+		lines = append(lines, indent+"- "+item)
+	}
+	lines = append(lines, _stackEnd)
+	return strings.Join(lines, "\n")
+}
+
+// SetStackSection replaces the stack section in the given pull request
+// description with section. The section is appended to the description if
+// it didn't have one. The rest of the description is left unchanged.
+func SetStackSection(body, section string) string {
+	start, end, ok := findStackSection(body)
+	if !ok {
+		if strings.TrimSpace(body) == "" {
+			return section
+		}
+		return strings.TrimRight(body, "\n") + "\n\n" + section
+	}
+	return body[:start] + section + body[end:]
+}
+
+// RemoveStackSection removes the stack section from the given pull request
+// description, if it has one.
+func RemoveStackSection(body string) string {
+	start, end, ok := findStackSection(body)
+	if !ok {
+		return body
+	}
+	return strings.TrimRight(body[:start], "\n") + body[end:]
+}
+
+// findStackSection returns the range of the given description occupied by
+// the stack section, including its markers.
+func findStackSection(body string) (start, end int, ok bool) {
+	start = strings.Index(body, _stackBegin)
+	if start < 0 {
+		return 0, 0, false
+	}
+
+	end = strings.Index(body[start:], _stackEnd)
+	if end < 0 {
+		return 0, 0, false
+	}
+	return start, start + end + len(_stackEnd), true
+}
+
+// updateStacks updates the stack sections in the descriptions of the
+// given pull requests, which are based on base, and of all other pull
+// requests in their stacks. Only pull requests authored by the current user
+// in this repository are changed.
+func (s *Service) updateStacks(ctx context.Context, base string, pulls []*github.PullRequest) (err error) {
+	if len(pulls) == 0 {
+		return nil
+	}
+
+	// The bases of the given pull requests may have only just changed so we
+	// don't trust them.
+	roots := make([]*github.PullRequest, len(pulls))
+	for i, pr := range pulls {
+		pr := *pr
+		pr.Base = &github.PullRequestBranch{Ref: github.String(base)}
+		roots[i] = &pr
+	}
+
+	owned := OwnedPullRequests(ctx, s.gh)
+
+	// Find the bottom of the stack.
+	seen := make(map[string]struct{})
+	for {
+		if _, ok := seen[base]; ok {
+			break
+		}
+		seen[base] = struct{}{}
+
+		prs, err := s.gh.ListPullRequestsByHead(ctx, "", base)
+		if err != nil {
+			return err
+		}
+
+		var parents []*github.PullRequest
+		for _, pr := range prs {
+			if owned(pr) {
+				parents = append(parents, pr)
+			}
+		}
+		if len(parents) != 1 {
+			break
+		}
+
+		roots = parents
+		base = parents[0].Base.GetRef()
+	}
+
+	graph, err := LoadGraph(GraphConfig{
+		Context:     ctx,
+		GitHub:      s.gh,
+		Include:     owned,
+		Concurrency: s.concurrency,
+	}, roots)
+	if err != nil {
+		return err
+	}
+
+	for _, branch := range graph.TopologicalOrder() {
+		pr := graph.PullRequest(branch)
+
+		// Descriptions belong to their authors.
+		if s.user == "" || pr.User.GetLogin() != s.user {
+			continue
+		}
+
+		// Pull requests that aren't part of a stack don't need the section.
+		path := graph.PathToRoot(branch)
+		body := RemoveStackSection(pr.GetBody())
+		if len(graph.Subtree(path[len(path)-1])) > 1 {
+			body = SetStackSection(pr.GetBody(), StackSection(graph, branch))
+		}
+
+		if body == pr.GetBody() {
+			continue
+		}
+
+		err = multierr.Append(err, s.gh.SetPullRequestBody(ctx, pr.GetNumber(), body))
+	}
+	return err
+}
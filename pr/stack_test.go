@@ -0,0 +1,213 @@
+package pr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStackSection(t *testing.T) {
+	// master -> feature1 -> feature2 -> feature3
+	//                    \
+	//                     -> feature4 (not published)
+	g := NewGraph()
+	for i, br := range []string{"feature1", "feature2", "feature3"} {
+		parent := "master"
+		if i > 0 {
+			parent = g.TopologicalOrder()[i-1]
+		}
+		require.NoError(t, g.AddPullRequest(&github.PullRequest{
+			Number: github.Int(i + 1),
+			Head:   &github.PullRequestBranch{Ref: github.String(br)},
+			Base:   &github.PullRequestBranch{Ref: github.String(parent)},
+		}))
+	}
+	require.NoError(t, g.AddBranch("feature4", "feature1"))
+
+	assert.Equal(t, _stackBegin+"\n"+
+		"**Stack**\n"+
+		"\n"+
+		"- `master`\n"+
+		"  - #1\n"+
+		"    - **#2 (this pull request)**\n"+
+		"      - #3\n"+
+		"    - feature4\n"+
+		_stackEnd,
+		StackSection(g, "feature2"))
+
+	assert.Empty(t, StackSection(g, "master"))
+}
+
+func TestSetStackSection(t *testing.T) {
+	section := _stackBegin + "\n- #1\n" + _stackEnd
+	newSection := _stackBegin + "\n- #2\n" + _stackEnd
+
+	tests := []struct {
+		Desc string
+		Body string
+
+		WantSet    string
+		WantRemove string
+	}{
+		{
+			Desc:       "empty",
+			WantSet:    newSection,
+			WantRemove: "",
+		},
+		{
+			Desc:       "no section",
+			Body:       "Fixes a bug.\n",
+			WantSet:    "Fixes a bug.\n\n" + newSection,
+			WantRemove: "Fixes a bug.\n",
+		},
+		{
+			Desc:       "section at end",
+			Body:       "Fixes a bug.\n\n" + section,
+			WantSet:    "Fixes a bug.\n\n" + newSection,
+			WantRemove: "Fixes a bug.",
+		},
+		{
+			Desc:       "section in the middle",
+			Body:       "Fixes a bug.\n\n" + section + "\n\nMore text.",
+			WantSet:    "Fixes a bug.\n\n" + newSection + "\n\nMore text.",
+			WantRemove: "Fixes a bug.\n\nMore text.",
+		},
+		{
+			Desc:       "unterminated section",
+			Body:       "Fixes a bug.\n\n" + _stackBegin,
+			WantSet:    "Fixes a bug.\n\n" + _stackBegin + "\n\n" + newSection,
+			WantRemove: "Fixes a bug.\n\n" + _stackBegin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			assert.Equal(t, tt.WantSet, SetStackSection(tt.Body, newSection), "SetStackSection")
+			assert.Equal(t, tt.WantRemove, RemoveStackSection(tt.Body), "RemoveStackSection")
+		})
+	}
+}
+
+func TestUpdateStacks(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pull := func(num int, author, head, base, body string) *github.PullRequest {
+		return &github.PullRequest{
+			Number: github.Int(num),
+			Body:   github.String(body),
+			User:   &github.User{Login: github.String(author)},
+			Head:   &github.PullRequestBranch{Ref: github.String(head)},
+			Base:   &github.PullRequestBranch{Ref: github.String(base)},
+		}
+	}
+
+	// master -> feature1 -> feature2 -> feature3
+	//                    \
+	//                     -> feature4 (someone else's)
+	//
+	// feature2 was just rebased onto feature1 so its base is stale.
+	pr1 := pull(1, "abhinav", "feature1", "master", "")
+	pr2 := pull(2, "abhinav", "feature2", "dev", "Second.")
+	pr3 := pull(3, "abhinav", "feature3", "feature2", "")
+	pr4 := pull(4, "someone", "feature4", "feature1", "")
+
+	// Pull request from a fork with the same head as one of ours.
+	fork := pull(5, "someone", "feature3", "feature2", "")
+	fork.Head.Label = github.String("someone:feature3")
+
+	g := NewGraph()
+	require.NoError(t, g.AddPullRequest(pr1))
+	require.NoError(t, g.AddPullRequest(pull(2, "abhinav", "feature2", "feature1", "Second.")))
+	require.NoError(t, g.AddPullRequest(pr3))
+	require.NoError(t, g.AddPullRequest(pr4))
+
+	// pr1 is already up to date.
+	pr1.Body = github.String(StackSection(g, "feature1"))
+
+	gh := gatewaytest.NewMockGitHub(mockCtrl)
+	gh.EXPECT().IsOwned(gomock.Any(), fork.Head).Return(false).AnyTimes()
+	gh.EXPECT().IsOwned(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", "feature1").
+		Return([]*github.PullRequest{pr1}, nil)
+	gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", "master").Return(nil, nil)
+	gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature1").
+		Return([]*github.PullRequest{pr2, pr4}, nil)
+	gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature2").
+		Return([]*github.PullRequest{fork, pr3}, nil)
+	gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature3").Return(nil, nil)
+	gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature4").Return(nil, nil)
+
+	// Only our own pull requests are changed.
+	gh.EXPECT().SetPullRequestBody(gomock.Any(), 2, "Second.\n\n"+StackSection(g, "feature2")).
+		Return(nil)
+	gh.EXPECT().SetPullRequestBody(gomock.Any(), 3, StackSection(g, "feature3")).
+		Return(nil)
+
+	service := NewService(ServiceConfig{GitHub: gh, User: "abhinav"})
+	require.NoError(t, service.updateStacks(
+		context.Background(), "feature1", []*github.PullRequest{pr2}))
+}
+
+func TestUpdateStacksSinglePullRequest(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pr := &github.PullRequest{
+		Number: github.Int(1),
+		User:   &github.User{Login: github.String("abhinav")},
+		Body:   github.String("Fixes a bug.\n\n" + _stackBegin + "\n- #1\n" + _stackEnd),
+		Head:   &github.PullRequestBranch{Ref: github.String("feature1")},
+		Base:   &github.PullRequestBranch{Ref: github.String("feature0")},
+	}
+
+	// The pull request is no longer part of a stack so the section is
+	// removed.
+	gh := gatewaytest.NewMockGitHub(mockCtrl)
+	gh.EXPECT().IsOwned(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", "master").Return(nil, nil)
+	gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature1").Return(nil, nil)
+	gh.EXPECT().SetPullRequestBody(gomock.Any(), 1, "Fixes a bug.").Return(nil)
+
+	service := NewService(ServiceConfig{GitHub: gh, User: "abhinav"})
+	require.NoError(t, service.updateStacks(
+		context.Background(), "master", []*github.PullRequest{pr}))
+}
+
+func TestUpdateStacksSeparateRoots(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pull := func(num int, head, body string) *github.PullRequest {
+		return &github.PullRequest{
+			Number: github.Int(num),
+			User:   &github.User{Login: github.String("abhinav")},
+			Body:   github.String(body),
+			Head:   &github.PullRequestBranch{Ref: github.String(head)},
+			Base:   &github.PullRequestBranch{Ref: github.String("master")},
+		}
+	}
+
+	// Both pull requests are based on master but neither is part of a
+	// stack. The section left over in the first is removed and none is
+	// added to the second.
+	pr1 := pull(1, "feature1", "First.\n\n"+_stackBegin+"\n- #1\n"+_stackEnd)
+	pr2 := pull(2, "feature2", "Second.")
+
+	gh := gatewaytest.NewMockGitHub(mockCtrl)
+	gh.EXPECT().IsOwned(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	gh.EXPECT().ListPullRequestsByHead(gomock.Any(), "", "master").Return(nil, nil)
+	gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature1").Return(nil, nil)
+	gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature2").Return(nil, nil)
+	gh.EXPECT().SetPullRequestBody(gomock.Any(), 1, "First.").Return(nil)
+
+	service := NewService(ServiceConfig{GitHub: gh, User: "abhinav"})
+	require.NoError(t, service.updateStacks(
+		context.Background(), "master", []*github.PullRequest{pr1, pr2}))
+}
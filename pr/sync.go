@@ -0,0 +1,254 @@
+package pr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/git"
+	"github.com/abhinav/git-pr/logging"
+	"github.com/abhinav/git-pr/service"
+
+	"github.com/google/go-github/github"
+	"go.uber.org/multierr"
+)
+
+// Sync deletes local branches whose pull requests were merged or closed,
+// fast-forwards the base branch, and rebases pull requests that depended on
+// the merged pull requests. The base branch is reported as not updated if it
+// can't be fast-forwarded.
+func (s *Service) Sync(ctx context.Context, req *service.SyncRequest) (_ *service.SyncResponse, err error) {
+	oldBranch, err := s.git.CurrentBranch()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.git.Fetch(&gateway.FetchRequest{Remote: s.remote}); err != nil {
+		return nil, err
+	}
+
+	branches, err := s.git.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	closed, err := s.closedPullRequests(ctx, branches, req.Base)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		res service.SyncResponse
+
+		// Pull requests that were merged and whose local branches will be
+		// deleted.
+		merged []*github.PullRequest
+
+		deleted = make(map[string]struct{})
+	)
+	for _, branch := range branches {
+		// Stop between git operations if the user interrupted us.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		pr, ok := closed[branch]
+		if !ok {
+			continue
+		}
+
+		sha, err := s.git.SHA1(branch)
+		if err != nil {
+			return nil, err
+		}
+
+		// Don't delete branches which have changes that never made it to
+		// GitHub.
+		if sha != pr.Head.GetSHA() {
+			res.BranchesNotDeleted = append(res.BranchesNotDeleted, branch)
+			continue
+		}
+
+		deleted[branch] = struct{}{}
+		res.DeletedBranches = append(res.DeletedBranches, branch)
+		if pr.MergedAt != nil {
+			merged = append(merged, pr)
+		}
+	}
+
+	// Go back to the original branch after everything is done unless we
+	// deleted it.
+	defer func() {
+		if _, ok := deleted[oldBranch]; !ok && oldBranch != req.Base {
+			err = multierr.Append(err, s.git.Checkout(oldBranch))
+		}
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if !s.git.DoesBranchExist(req.Base) {
+		if err := s.git.CreateBranch(req.Base, s.remote+"/"+req.Base); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.git.Checkout(req.Base); err != nil {
+		return nil, err
+	}
+
+	// Pulling would merge the remote into a base that has diverged from it.
+	if ok, err := s.git.FastForward(s.remote + "/" + req.Base); err != nil {
+		return nil, err
+	} else if !ok {
+		res.BranchesNotUpdated = append(res.BranchesNotUpdated, req.Base)
+	}
+
+	for _, branch := range res.DeletedBranches {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := s.reparentChildren(branch, closed[branch].GetBase().GetRef(), req.Base); err != nil {
+			return nil, err
+		}
+
+		if err := s.git.DeleteBranch(branch); err != nil {
+			return nil, err
+		}
+
+		// The remote tracking branch may already have been removed.
+		if _, err := s.git.SHA1(s.remote + "/" + branch); err != nil {
+			continue
+		}
+
+		if err := s.git.DeleteRemoteTrackingBranch(s.remote, branch); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pr := range merged {
+		dependents, err := s.gh.ListPullRequestsByBase(ctx, pr.Head.GetRef())
+		if err != nil {
+			return nil, err
+		}
+
+		if len(dependents) == 0 {
+			continue
+		}
+
+		rebaseRes, err := s.Rebase(ctx, &service.RebaseRequest{
+			PullRequests: dependents,
+			Base:         pr.Base.GetRef(),
+			Author:       req.Author,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to rebase dependents of %v: %v", pr.GetHTMLURL(), err)
+		}
+
+		res.RebasedPullRequests = append(res.RebasedPullRequests, rebaseRes.RebasedPullRequests...)
+		res.BranchesNotUpdated = append(res.BranchesNotUpdated, rebaseRes.BranchesNotUpdated...)
+	}
+
+	return &res, nil
+}
+
+// reparentChildren records the parent of the given branch, which is about
+// to be deleted, as the parent of its local children. If a parent wasn't
+// recorded for the branch, the first non-empty fallback is used.
+func (s *Service) reparentChildren(branch string, fallbacks ...string) error {
+	parent, err := git.Parent(s.git, branch)
+	if err != nil {
+		return err
+	}
+
+	for _, fallback := range fallbacks {
+		if parent != "" {
+			break
+		}
+		parent = fallback
+	}
+	return git.ReplaceParent(s.git, branch, parent)
+}
+
+// Maximum number of branches whose pull requests are looked up at the same
+// time unless the concurrency was configured.
+const _syncLookupConcurrency = 4
+
+// closedPullRequests looks up the pull requests of the given branches other
+// than base. The most recent pull request of each branch whose pull requests
+// are all closed is returned. No new requests are made once ctx is
+// cancelled.
+func (s *Service) closedPullRequests(ctx context.Context, branches []string, base string) (_ map[string]*github.PullRequest, err error) {
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+
+		closed = make(map[string]*github.PullRequest)
+		heads  = make(chan string)
+	)
+	concurrency := s.concurrency
+	if concurrency <= 0 {
+		concurrency = _syncLookupConcurrency
+	}
+	for i := 0; i < concurrency && i < len(branches); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for branch := range heads {
+				s.log.Debug("looking up pull requests", logging.String("branch", branch))
+				prs, e := s.gh.ListAllPullRequestsByHead(ctx, "", branch)
+
+				mu.Lock()
+				if e != nil {
+					err = multierr.Append(err, e)
+				} else if pr, ok := closedPullRequest(prs); ok {
+					closed[branch] = pr
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, branch := range branches {
+		// select picks randomly if both cases are ready so we check this
+		// first.
+		if ctx.Err() != nil {
+			break
+		}
+		if branch == base {
+			continue
+		}
+
+		select {
+		case heads <- branch:
+		case <-ctx.Done():
+		}
+	}
+	close(heads)
+	wg.Wait()
+
+	if err := multierr.Append(err, ctx.Err()); err != nil {
+		return nil, err
+	}
+	return closed, nil
+}
+
+// closedPullRequest returns the most recent pull request from the given list
+// if none of them are still open.
+func closedPullRequest(prs []*github.PullRequest) (*github.PullRequest, bool) {
+	if len(prs) == 0 {
+		return nil, false
+	}
+
+	for _, pr := range prs {
+		if pr.GetState() == "open" {
+			return nil, false
+		}
+	}
+
+	// GitHub lists pull requests newest first.
+	return prs[0], true
+}
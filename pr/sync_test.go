@@ -0,0 +1,403 @@
+package pr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceSync(t *testing.T) {
+	type testCase struct {
+		Desc string
+
+		Request       service.SyncRequest
+		CurrentBranch string
+
+		// Local branches and their SHA1 hashes.
+		Branches map[string]string
+
+		// Pull requests for the different local branches.
+		PullRequests map[string][]*github.PullRequest // branch -> PRs
+
+		// Remote tracking branches that exist for the local branches.
+		RemoteBranches []string
+
+		// Whether the base branch has commits that the remote doesn't.
+		BaseDiverged bool
+
+		// Locally recorded parents of branches.
+		LocalParents map[string]string
+
+		// Values to return from rebasePullRequests.
+		RebasePRsResult []rebasedPullRequest
+
+		// If present, these may be used for more complicated setup on the
+		// mocks.
+		SetupGit    func(*gatewaytest.MockGit)
+		SetupGitHub func(*gatewaytest.MockGitHub)
+
+		// Whether we expect to switch back to the original branch.
+		WantCheckoutOld bool
+
+		WantDeletes []string
+
+		// Parents expected to be recorded for branches.
+		WantParents map[string]string
+
+		WantResponse service.SyncResponse
+		WantErrors   []string
+	}
+
+	tests := []testCase{
+		{
+			Desc:            "nothing to do",
+			Request:         service.SyncRequest{Base: "master"},
+			CurrentBranch:   "feature1",
+			Branches:        map[string]string{"master": "sha0", "feature1": "sha1"},
+			WantCheckoutOld: true,
+			PullRequests: map[string][]*github.PullRequest{
+				"feature1": {
+					{
+						State: github.String("open"),
+						Head:  &github.PullRequestBranch{SHA: github.String("sha1")},
+					},
+				},
+			},
+		},
+		{
+			Desc:          "merged current branch",
+			Request:       service.SyncRequest{Base: "master"},
+			CurrentBranch: "feature1",
+			Branches: map[string]string{
+				"master":   "sha0",
+				"feature1": "sha1",
+				"feature2": "sha2",
+			},
+			RemoteBranches: []string{"feature1"},
+			PullRequests: map[string][]*github.PullRequest{
+				"feature1": {
+					{
+						State:    github.String("closed"),
+						MergedAt: &time.Time{},
+						Base:     &github.PullRequestBranch{Ref: github.String("master")},
+						Head: &github.PullRequestBranch{
+							SHA: github.String("sha1"),
+							Ref: github.String("feature1"),
+						},
+					},
+				},
+				"feature2": {},
+			},
+			SetupGitHub: func(gh *gatewaytest.MockGitHub) {
+				gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature1").Return(nil, nil)
+			},
+			WantDeletes: []string{"feature1"},
+			WantResponse: service.SyncResponse{
+				DeletedBranches: []string{"feature1"},
+			},
+		},
+		{
+			Desc:          "merged with local children",
+			Request:       service.SyncRequest{Base: "master"},
+			CurrentBranch: "master",
+			Branches: map[string]string{
+				"master":   "sha0",
+				"feature1": "sha1",
+				"feature2": "sha2",
+				"feature3": "sha3",
+			},
+			// feature2 and feature3 don't have pull requests.
+			LocalParents: map[string]string{
+				"feature2": "feature1",
+				"feature3": "feature2",
+			},
+			PullRequests: map[string][]*github.PullRequest{
+				"feature1": {
+					{
+						State:    github.String("closed"),
+						MergedAt: &time.Time{},
+						Base:     &github.PullRequestBranch{Ref: github.String("master")},
+						Head: &github.PullRequestBranch{
+							SHA: github.String("sha1"),
+							Ref: github.String("feature1"),
+						},
+					},
+				},
+				"feature2": {},
+				"feature3": {},
+			},
+			SetupGitHub: func(gh *gatewaytest.MockGitHub) {
+				gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature1").Return(nil, nil)
+			},
+			WantDeletes: []string{"feature1"},
+			WantParents: map[string]string{"feature2": "master"},
+			WantResponse: service.SyncResponse{
+				DeletedBranches: []string{"feature1"},
+			},
+		},
+		{
+			Desc:            "closed with local changes",
+			Request:         service.SyncRequest{Base: "master"},
+			CurrentBranch:   "master",
+			Branches:        map[string]string{"master": "sha0", "feature1": "sha1"},
+			WantCheckoutOld: false,
+			PullRequests: map[string][]*github.PullRequest{
+				"feature1": {
+					{
+						State: github.String("closed"),
+						Head:  &github.PullRequestBranch{SHA: github.String("oldsha1")},
+					},
+				},
+			},
+			WantResponse: service.SyncResponse{
+				BranchesNotDeleted: []string{"feature1"},
+			},
+		},
+		{
+			Desc:            "closed without merging",
+			Request:         service.SyncRequest{Base: "master"},
+			CurrentBranch:   "feature2",
+			Branches:        map[string]string{"master": "sha0", "feature1": "sha1", "feature2": "sha2"},
+			WantCheckoutOld: true,
+			PullRequests: map[string][]*github.PullRequest{
+				"feature1": {
+					{
+						State: github.String("closed"),
+						Head: &github.PullRequestBranch{
+							SHA: github.String("sha1"),
+							Ref: github.String("feature1"),
+						},
+					},
+				},
+				"feature2": {},
+			},
+			WantDeletes: []string{"feature1"},
+			WantResponse: service.SyncResponse{
+				DeletedBranches: []string{"feature1"},
+			},
+		},
+		func() (tt testCase) {
+			tt.Desc = "merged with dependents"
+			tt.Request = service.SyncRequest{Base: "master", Author: "abhinav"}
+			tt.CurrentBranch = "master"
+			tt.Branches = map[string]string{"master": "sha0", "feature1": "sha1"}
+			tt.RemoteBranches = []string{"feature1"}
+			tt.PullRequests = map[string][]*github.PullRequest{
+				"feature1": {
+					{
+						State:    github.String("closed"),
+						MergedAt: &time.Time{},
+						Base:     &github.PullRequestBranch{Ref: github.String("master")},
+						Head: &github.PullRequestBranch{
+							SHA: github.String("sha1"),
+							Ref: github.String("feature1"),
+						},
+					},
+				},
+			}
+
+			dependent := &github.PullRequest{
+				Number:  github.Int(2),
+				HTMLURL: github.String("http://github.com/abhinav/git-pr/pulls/2"),
+				Base: &github.PullRequestBranch{
+					Ref: github.String("feature1"),
+					SHA: github.String("sha1"),
+				},
+				Head: &github.PullRequestBranch{
+					Ref: github.String("feature2"),
+					SHA: github.String("sha2"),
+				},
+			}
+			tt.RebasePRsResult = []rebasedPullRequest{
+				{PR: dependent, LocalRef: "git-pr/rebase/sha2"},
+			}
+			tt.SetupGitHub = func(gh *gatewaytest.MockGitHub) {
+				gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "feature1").
+					Return([]*github.PullRequest{dependent}, nil)
+				gh.EXPECT().SetPullRequestBase(gomock.Any(), 2, "master").Return(nil)
+			}
+			tt.SetupGit = func(git *gatewaytest.MockGit) {
+				// Rebase
+				git.EXPECT().SHA1("origin/master").Return("sha0", nil)
+				git.EXPECT().SHA1("feature2").Return("", errors.New("unknown branch"))
+				git.EXPECT().SHA1("git-pr/rebase/sha2").Return("newsha2", nil)
+				git.EXPECT().Push(&gateway.PushRequest{
+					Remote: "origin",
+					Force:  true,
+					Refs:   map[string]string{"git-pr/rebase/sha2": "feature2"},
+				}).Return(nil)
+				git.EXPECT().Checkout("master").Return(nil)
+			}
+			tt.WantDeletes = []string{"feature1"}
+			tt.WantResponse = service.SyncResponse{
+				DeletedBranches: []string{"feature1"},
+				RebasedPullRequests: []service.RebasedPullRequest{
+					{PullRequest: dependent, OldSHA: "sha2", NewSHA: "newsha2"},
+				},
+			}
+			return
+		}(),
+		{
+			Desc:            "diverged base",
+			Request:         service.SyncRequest{Base: "master"},
+			CurrentBranch:   "feature1",
+			Branches:        map[string]string{"master": "sha0", "feature1": "sha1"},
+			BaseDiverged:    true,
+			WantCheckoutOld: true,
+			PullRequests: map[string][]*github.PullRequest{
+				"feature1": {
+					{
+						State: github.String("open"),
+						Head:  &github.PullRequestBranch{SHA: github.String("sha1")},
+					},
+				},
+			},
+			WantResponse: service.SyncResponse{
+				BranchesNotUpdated: []string{"master"},
+			},
+		},
+		{
+			Desc:          "list branches error",
+			Request:       service.SyncRequest{Base: "master"},
+			CurrentBranch: "master",
+			SetupGit: func(git *gatewaytest.MockGit) {
+				git.EXPECT().ListBranches().Return(nil, errors.New("not a git repository"))
+			},
+			WantErrors: []string{"not a git repository"},
+		},
+		{
+			Desc:          "pull request lookup error",
+			Request:       service.SyncRequest{Base: "master"},
+			CurrentBranch: "master",
+			Branches:      map[string]string{"master": "sha0", "feature1": "sha1"},
+			SetupGitHub: func(gh *gatewaytest.MockGitHub) {
+				gh.EXPECT().ListAllPullRequestsByHead(gomock.Any(), "", "feature1").
+					Return(nil, errors.New("great sadness"))
+			},
+			WantErrors: []string{"great sadness"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			git := gatewaytest.NewMockGit(mockCtrl)
+			gh := gatewaytest.NewMockGitHub(mockCtrl)
+
+			git.EXPECT().CurrentBranch().Return(tt.CurrentBranch, nil).AnyTimes()
+			git.EXPECT().Fetch(&gateway.FetchRequest{Remote: "origin"}).Return(nil).AnyTimes()
+
+			if tt.Branches != nil {
+				var branches []string
+				for br := range tt.Branches {
+					branches = append(branches, br)
+				}
+				git.EXPECT().ListBranches().Return(branches, nil)
+			}
+
+			for br, prs := range tt.PullRequests {
+				gh.EXPECT().ListAllPullRequestsByHead(gomock.Any(), "", br).Return(prs, nil)
+				if _, ok := closedPullRequest(prs); ok {
+					git.EXPECT().SHA1(br).Return(tt.Branches[br], nil)
+				}
+			}
+
+			if len(tt.WantErrors) == 0 {
+				git.EXPECT().DoesBranchExist(tt.Request.Base).Return(true)
+				git.EXPECT().Checkout(tt.Request.Base).Return(nil)
+				git.EXPECT().FastForward("origin/"+tt.Request.Base).Return(!tt.BaseDiverged, nil)
+			}
+
+			if tt.WantCheckoutOld {
+				git.EXPECT().Checkout(tt.CurrentBranch).Return(nil)
+			}
+
+			remoteBranches := make(map[string]struct{})
+			for _, br := range tt.RemoteBranches {
+				remoteBranches[br] = struct{}{}
+			}
+
+			for _, br := range tt.WantDeletes {
+				git.EXPECT().DeleteBranch(br).Return(nil)
+				if _, ok := remoteBranches[br]; ok {
+					git.EXPECT().SHA1("origin/"+br).Return("sha", nil)
+					git.EXPECT().DeleteRemoteTrackingBranch("origin", br).Return(nil)
+				} else {
+					git.EXPECT().SHA1("origin/"+br).Return("", errors.New("unknown ref"))
+				}
+			}
+
+			localConfig := make(map[string]string)
+			for branch, parent := range tt.LocalParents {
+				key := "branch." + branch + ".git-pr-parent"
+				localConfig[key] = parent
+				git.EXPECT().GetConfig(key).Return(parent, nil).AnyTimes()
+			}
+			git.EXPECT().GetConfig(gomock.Any()).Return("", nil).AnyTimes()
+			git.EXPECT().ListConfig(gomock.Any()).Return(localConfig, nil).AnyTimes()
+			for branch, parent := range tt.WantParents {
+				git.EXPECT().SetConfig("branch."+branch+".git-pr-parent", parent).Return(nil)
+			}
+
+			if tt.SetupGit != nil {
+				tt.SetupGit(git)
+			}
+			if tt.SetupGitHub != nil {
+				tt.SetupGitHub(gh)
+			}
+
+			service := NewService(ServiceConfig{Git: git, GitHub: gh})
+			service.rebasePullRequests = fakeRebasePullRequests(tt.RebasePRsResult, nil)
+			service.updateStackSections = noopUpdateStackSections
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			res, err := service.Sync(ctx, &tt.Request)
+			if len(tt.WantErrors) > 0 {
+				require.Error(t, err, "expected failure")
+				for _, msg := range tt.WantErrors {
+					assert.Contains(t, err.Error(), msg)
+				}
+				return
+			}
+
+			require.NoError(t, err, "expected success")
+			assert.Equal(t, tt.WantResponse, *res)
+		})
+	}
+}
+
+func TestServiceSyncCancelled(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	git := gatewaytest.NewMockGit(mockCtrl)
+	gh := gatewaytest.NewMockGitHub(mockCtrl)
+
+	// Nothing is looked up on GitHub and no branches are touched once the
+	// context is cancelled.
+	git.EXPECT().CurrentBranch().Return("feature1", nil)
+	git.EXPECT().Fetch(&gateway.FetchRequest{Remote: "origin"}).Return(nil)
+	git.EXPECT().ListBranches().Return([]string{"feature1", "master"}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewService(ServiceConfig{Git: git, GitHub: gh}).
+		Sync(ctx, &service.SyncRequest{Base: "master"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "context canceled")
+}
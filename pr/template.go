@@ -0,0 +1,190 @@
+package pr
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/abhinav/git-pr/gateway"
+
+	"github.com/google/go-github/github"
+)
+
+// DefaultMessageTemplate is the template used to build the commit message
+// of a pull request if the repository doesn't provide one.
+const DefaultMessageTemplate = `{{.Title}} (#{{.Number}})
+
+{{if .Body}}{{.Body}}{{end}}`
+
+var _defaultTmpl = template.Must(ParseMessageTemplate("default", DefaultMessageTemplate))
+
+// MessageData is the data available to commit message templates. All
+// fields of the pull request are available, along with the methods of
+// MessageData.
+//
+// 	{{.Title}} (#{{.Number}})
+//
+// 	{{.Body | trim | wrap 72}}
+//
+// 	{{range .Reviews}}{{if eq .Status "APPROVED"}}Reviewed-by: {{.User}}
+// 	{{end}}{{end}}
+type MessageData struct {
+	*github.PullRequest
+
+	ctx context.Context
+	gh  gateway.GitHub
+
+	reviews []*gateway.PullRequestReview
+	commits []*gateway.PullRequestCommit
+}
+
+// NewMessageData builds the data for the commit message of the given pull
+// request. Reviews and commits are retrieved from gh only if the template
+// asks for them.
+func NewMessageData(ctx context.Context, gh gateway.GitHub, pr *github.PullRequest) *MessageData {
+	return &MessageData{PullRequest: pr, ctx: ctx, gh: gh}
+}
+
+// Reviews of the pull request.
+func (d *MessageData) Reviews() ([]*gateway.PullRequestReview, error) {
+	if d.reviews != nil || d.gh == nil {
+		return d.reviews, nil
+	}
+
+	reviews, err := d.gh.ListPullRequestReviews(d.ctx, d.GetNumber())
+	if err != nil {
+		return nil, err
+	}
+	d.reviews = reviews
+	return reviews, nil
+}
+
+// Commits in the pull request. Note that this hides the number of commits
+// reported by GitHub.
+func (d *MessageData) Commits() ([]*gateway.PullRequestCommit, error) {
+	if d.commits != nil || d.gh == nil {
+		return d.commits, nil
+	}
+
+	commits, err := d.gh.ListPullRequestCommits(d.ctx, d.GetNumber())
+	if err != nil {
+		return nil, err
+	}
+	d.commits = commits
+	return commits, nil
+}
+
+var _issueRefRegexp = regexp.MustCompile(
+	`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+#(\d+)\b`)
+
+// Issues returns the numbers of issues that the description of the pull
+// request says it closes, fixes, or resolves.
+func (d *MessageData) Issues() []int {
+	var issues []int
+	seen := make(map[int]struct{})
+	for _, m := range _issueRefRegexp.FindAllStringSubmatch(d.GetBody(), -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[n]; ok {
+			continue
+		}
+		seen[n] = struct{}{}
+		issues = append(issues, n)
+	}
+	sort.Ints(issues)
+	return issues
+}
+
+// ParseMessageTemplate parses a commit message template. Besides parsing
+// it, the template is executed against a sample pull request so that
+// references to fields that don't exist are reported right away rather than
+// when a pull request is being landed.
+//
+// The following functions are available to templates in addition to the
+// text/template builtins.
+//
+// 	wrap WIDTH TEXT    wraps lines of TEXT at WIDTH columns
+// 	trim TEXT          removes leading and trailing whitespace
+// 	indent N TEXT      indents lines of TEXT by N spaces
+// 	join SEP LIST      joins LIST with SEP
+func ParseMessageTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(_templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit message template: %v", err)
+	}
+
+	sample := NewMessageData(context.Background(), nil, &github.PullRequest{
+		Number:  github.Int(1),
+		Title:   github.String("Title"),
+		Body:    github.String("Body"),
+		HTMLURL: github.String("https://github.com/owner/repo/pull/1"),
+		User:    &github.User{Login: github.String("user")},
+		Head:    &github.PullRequestBranch{Ref: github.String("feature")},
+		Base:    &github.PullRequestBranch{Ref: github.String("master")},
+	})
+	if err := tmpl.Execute(noopWriter{}, sample); err != nil {
+		return nil, fmt.Errorf("invalid commit message template: %v", err)
+	}
+
+	return tmpl, nil
+}
+
+type noopWriter struct{}
+
+func (noopWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+var _templateFuncs = template.FuncMap{
+	"wrap":   wrap,
+	"trim":   strings.TrimSpace,
+	"indent": indent,
+	"join":   join,
+}
+
+// wrap wraps each line of s at width columns. Indented lines are left alone
+// so that code blocks survive.
+func wrap(width int, s string) string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if len(line) <= width || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			out = append(out, line)
+			continue
+		}
+
+		var current string
+		for _, word := range strings.Fields(line) {
+			switch {
+			case current == "":
+				current = word
+			case len(current)+1+len(word) > width:
+				out = append(out, current)
+				current = word
+			default:
+				current += " " + word
+			}
+		}
+		out = append(out, current)
+	}
+	return strings.Join(out, "\n")
+}
+
+func indent(n int, s string) string {
+	prefix := strings.Repeat(" ", n)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func join(sep string, items []string) string {
+	return strings.Join(items, sep)
+}
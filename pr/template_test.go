@@ -0,0 +1,210 @@
+package pr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abhinav/git-pr/editor/editortest"
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMessageTemplateErrors(t *testing.T) {
+	tests := []struct {
+		Desc      string
+		Template  string
+		WantError string
+	}{
+		{
+			Desc:      "syntax error",
+			Template:  "{{.Title}",
+			WantError: "invalid commit message template",
+		},
+		{
+			Desc:      "unknown function",
+			Template:  "{{.Title | shout}}",
+			WantError: `function "shout" not defined`,
+		},
+		{
+			Desc:      "unknown field",
+			Template:  "{{.Titel}}",
+			WantError: "can't evaluate field Titel",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			_, err := ParseMessageTemplate("test", tt.Template)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.WantError)
+		})
+	}
+}
+
+func TestUpdateMessageTemplate(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tmpl, err := ParseMessageTemplate("test", `{{.Title}}
+
+{{.Body | trim | wrap 20}}
+{{range .Issues}}
+Closes #{{.}}{{end}}
+{{range .Commits}}
+{{.SHA}} by {{.AuthorName}}{{end}}
+{{range .Reviews}}{{if eq .Status "APPROVED"}}
+Approved-by: {{.User}}{{end}}{{end}}
+{{range .Labels}}
+Label: {{.Name}}{{end}}
+`)
+	require.NoError(t, err)
+
+	pr := &github.PullRequest{
+		Number:  github.Int(42),
+		Title:   github.String("Fix a bug"),
+		Body:    github.String("  This fixes a bug that has been around for ages.\n\nFixes #12, fixes #3.  "),
+		HTMLURL: github.String("https://github.com/foo/bar/pull/42"),
+		Labels:  []*github.Label{{Name: github.String("bug")}},
+	}
+
+	gh := gatewaytest.NewMockGitHub(mockCtrl)
+	gh.EXPECT().ListPullRequestCommits(gomock.Any(), 42).Return([]*gateway.PullRequestCommit{
+		{SHA: "abc", AuthorName: "Alice"},
+	}, nil)
+	gh.EXPECT().ListPullRequestReviews(gomock.Any(), 42).Return([]*gateway.PullRequestReview{
+		{User: "bob", Status: gateway.PullRequestApproved},
+		{User: "carol", Status: gateway.PullRequestChangesRequested},
+	}, nil)
+
+	ed := editortest.NewMockEditor(mockCtrl)
+	ed.EXPECT().EditString(
+		"Fix a bug\n"+
+			"\n"+
+			"This fixes a bug\n"+
+			"that has been around\n"+
+			"for ages.\n"+
+			"\n"+
+			"Fixes #12, fixes #3.\n"+
+			"\n"+
+			"Closes #3\n"+
+			"Closes #12\n"+
+			"\n"+
+			"abc by Alice\n"+
+			"\n"+
+			"Approved-by: bob\n"+
+			"\n"+
+			"Label: bug\n"+
+			"\n"+
+			"# Landing Pull Request: https://github.com/foo/bar/pull/42\n"+
+			"#\n"+
+			"# Enter the commit message above. Lines starting with '#' will be\n"+
+			"# ignored. There must be an empty line between the title and the body.\n"+
+			"# Leaving this file empty will abort the operation.\n",
+	).Return("Fix a bug\n\nThis fixes a bug.\n", nil)
+
+	require.NoError(t, UpdateMessage(MessageConfig{
+		Editor:   ed,
+		Template: tmpl,
+		Context:  context.Background(),
+		GitHub:   gh,
+	}, pr))
+	assert.Equal(t, "Fix a bug", pr.GetTitle())
+	assert.Equal(t, "This fixes a bug.\n", pr.GetBody())
+}
+
+func TestUpdateMessageDefaultTemplate(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pr := &github.PullRequest{
+		Number:  github.Int(42),
+		Title:   github.String("Fix a bug"),
+		HTMLURL: github.String("https://github.com/foo/bar/pull/42"),
+	}
+
+	// The default template doesn't need anything from GitHub.
+	ed := editortest.NewMockEditor(mockCtrl)
+	ed.EXPECT().EditString(
+		"Fix a bug (#42)\n"+
+			"\n"+
+			"# Landing Pull Request: https://github.com/foo/bar/pull/42\n"+
+			"#\n"+
+			"# Enter the commit message above. Lines starting with '#' will be\n"+
+			"# ignored. There must be an empty line between the title and the body.\n"+
+			"# Leaving this file empty will abort the operation.\n",
+	).Return("Fix a bug (#42)\n", nil)
+
+	require.NoError(t, UpdateMessage(MessageConfig{
+		Editor: ed,
+		GitHub: gatewaytest.NewMockGitHub(mockCtrl),
+	}, pr))
+	assert.Equal(t, "Fix a bug (#42)", pr.GetTitle())
+}
+
+func TestWrap(t *testing.T) {
+	assert.Equal(t,
+		"foo bar\nbaz qux\n\n    indented code that is long\nshort",
+		wrap(8, "foo bar baz qux\n\n    indented code that is long\nshort"))
+	assert.Equal(t, "averyverylongword\nfoo", wrap(4, "averyverylongword foo"))
+}
+
+func TestUpdateMessageLint(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pr := &github.PullRequest{
+		Number:  github.Int(42),
+		Title:   github.String("Fix a bug"),
+		HTMLURL: github.String("https://github.com/foo/bar/pull/42"),
+	}
+
+	footer := "# Landing Pull Request: https://github.com/foo/bar/pull/42\n" +
+		"#\n" +
+		"# Enter the commit message above. Lines starting with '#' will be\n" +
+		"# ignored. There must be an empty line between the title and the body.\n" +
+		"# Leaving this file empty will abort the operation.\n"
+
+	// The editor is opened again with the problems until they are fixed.
+	ed := editortest.NewMockEditor(mockCtrl)
+	gomock.InOrder(
+		ed.EXPECT().EditString("Fix a bug (#42)\n\n"+footer).
+			Return("Fix a bug (#42)\n\n"+footer, nil),
+		ed.EXPECT().EditString(
+			"Fix a bug (#42)\n"+
+				"\n"+
+				"# The commit message has the following problems:\n"+
+				`#  - title must be in the form "type(scope): description", e.g. "fix(parser): handle empty input"`+"\n"+
+				`#  - body must reference an issue, e.g. "Fixes #123"`+"\n"+
+				"#\n"+
+				footer,
+		).Return("fix: a bug (#42)\n\nFixes #12.\n", nil),
+	)
+
+	require.NoError(t, UpdateMessage(MessageConfig{
+		Editor: ed,
+		Lint: service.LintRules{
+			ConventionalCommits: true,
+			RequireIssue:        true,
+		},
+	}, pr))
+	assert.Equal(t, "fix: a bug (#42)", pr.GetTitle())
+	assert.Equal(t, "Fixes #12.\n", pr.GetBody())
+}
+
+func TestUpdateMessageEmptyAborts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ed := editortest.NewMockEditor(mockCtrl)
+	ed.EXPECT().EditString(gomock.Any()).Return("\n# Landing Pull Request\n\n", nil)
+
+	err := UpdateMessage(MessageConfig{Editor: ed}, &github.PullRequest{Number: github.Int(1)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file is empty")
+}
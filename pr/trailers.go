@@ -0,0 +1,114 @@
+package pr
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/service"
+
+	"github.com/google/go-github/github"
+)
+
+// Trailer is a "Key: Value" line at the end of a commit message.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+func (t Trailer) String() string {
+	return t.Key + ": " + t.Value
+}
+
+// trailers builds the trailers requested by opts for the given pull request.
+func (s *Service) trailers(ctx context.Context, pr *github.PullRequest, opts service.LandTrailers) ([]Trailer, error) {
+	var trailers []Trailer
+
+	if opts.ReviewedBy {
+		reviews, err := s.gh.ListPullRequestReviews(ctx, pr.GetNumber())
+		if err != nil {
+			return nil, err
+		}
+		for _, login := range approvers(reviews) {
+			user, err := s.gh.GetUser(ctx, login)
+			if err != nil {
+				return nil, err
+			}
+			trailers = append(trailers, Trailer{Key: "Reviewed-by", Value: identity(user)})
+		}
+	}
+
+	if opts.CoAuthoredBy {
+		commits, err := s.gh.ListPullRequestCommits(ctx, pr.GetNumber())
+		if err != nil {
+			return nil, err
+		}
+		for _, author := range coAuthors(pr.GetUser().GetLogin(), commits) {
+			trailers = append(trailers, Trailer{Key: "Co-authored-by", Value: author})
+		}
+	}
+
+	if opts.PullRequest {
+		trailers = append(trailers, Trailer{Key: "Pull-Request", Value: pr.GetHTMLURL()})
+	}
+
+	return trailers, nil
+}
+
+// approvers returns the sorted list of users whose most recent review
+// approved the pull request. Comments don't change a user's verdict.
+func approvers(reviews []*gateway.PullRequestReview) []string {
+	latest := make(map[string]gateway.PullRequestReviewState)
+	for _, r := range reviews {
+		switch r.Status {
+		case gateway.PullRequestApproved, gateway.PullRequestChangesRequested:
+			latest[r.User] = r.Status
+		}
+	}
+
+	var users []string
+	for user, status := range latest {
+		if status == gateway.PullRequestApproved {
+			users = append(users, user)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// identity formats the given user as "Name <email>" like git does for
+// authors. Users without a public email address are identified by their
+// GitHub no-reply address.
+func identity(u *gateway.User) string {
+	name := u.Name
+	if name == "" {
+		name = u.Login
+	}
+
+	email := u.Email
+	if email == "" {
+		email = fmt.Sprintf("%v+%v@users.noreply.github.com", u.ID, u.Login)
+	}
+	return fmt.Sprintf("%v <%v>", name, email)
+}
+
+// coAuthors returns the distinct authors of the given commits, in the order
+// they first appear, except the author of the pull request.
+func coAuthors(owner string, commits []*gateway.PullRequestCommit) []string {
+	var authors []string
+	seen := make(map[string]struct{})
+	for _, c := range commits {
+		if c.AuthorEmail == "" || (owner != "" && c.AuthorLogin == owner) {
+			continue
+		}
+
+		author := fmt.Sprintf("%v <%v>", c.AuthorName, c.AuthorEmail)
+		if _, ok := seen[author]; ok {
+			continue
+		}
+		seen[author] = struct{}{}
+		authors = append(authors, author)
+	}
+	return authors
+}
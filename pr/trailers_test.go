@@ -0,0 +1,119 @@
+package pr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abhinav/git-pr/editor/editortest"
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/service"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrailers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pr := &github.PullRequest{
+		Number:  github.Int(42),
+		HTMLURL: github.String("https://github.com/foo/bar/pull/42"),
+		User:    &github.User{Login: github.String("alice")},
+	}
+
+	gh := gatewaytest.NewMockGitHub(mockCtrl)
+	gh.EXPECT().ListPullRequestReviews(gomock.Any(), 42).Return([]*gateway.PullRequestReview{
+		{User: "carol", Status: gateway.PullRequestApproved},
+		{User: "bob", Status: gateway.PullRequestChangesRequested},
+		{User: "dave", Status: gateway.PullRequestApproved},
+		{User: "bob", Status: gateway.PullRequestApproved},
+		{User: "dave", Status: gateway.PullRequestChangesRequested},
+		{User: "carol", Status: "COMMENTED"},
+	}, nil)
+	gh.EXPECT().GetUser(gomock.Any(), "bob").Return(&gateway.User{
+		ID:    2,
+		Login: "bob",
+		Name:  "Bob",
+		Email: "bob@example.com",
+	}, nil)
+	// carol didn't set a name or a public email address.
+	gh.EXPECT().GetUser(gomock.Any(), "carol").Return(&gateway.User{ID: 3, Login: "carol"}, nil)
+	gh.EXPECT().ListPullRequestCommits(gomock.Any(), 42).Return([]*gateway.PullRequestCommit{
+		{SHA: "1", AuthorName: "Alice", AuthorEmail: "alice@example.com", AuthorLogin: "alice"},
+		{SHA: "2", AuthorName: "Eve", AuthorEmail: "eve@example.com"},
+		{SHA: "3", AuthorName: "Bob", AuthorEmail: "bob@example.com", AuthorLogin: "bob"},
+		{SHA: "4", AuthorName: "Eve", AuthorEmail: "eve@example.com"},
+	}, nil)
+
+	svc := NewService(ServiceConfig{GitHub: gh})
+	trailers, err := svc.trailers(context.Background(), pr, service.LandTrailers{
+		ReviewedBy:   true,
+		CoAuthoredBy: true,
+		PullRequest:  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Trailer{
+		{Key: "Reviewed-by", Value: "Bob <bob@example.com>"},
+		{Key: "Reviewed-by", Value: "carol <3+carol@users.noreply.github.com>"},
+		{Key: "Co-authored-by", Value: "Eve <eve@example.com>"},
+		{Key: "Co-authored-by", Value: "Bob <bob@example.com>"},
+		{Key: "Pull-Request", Value: "https://github.com/foo/bar/pull/42"},
+	}, trailers)
+}
+
+func TestTrailersNone(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// No requests are made if no trailers were requested.
+	svc := NewService(ServiceConfig{GitHub: gatewaytest.NewMockGitHub(mockCtrl)})
+	trailers, err := svc.trailers(
+		context.Background(), &github.PullRequest{Number: github.Int(1)}, service.LandTrailers{})
+	require.NoError(t, err)
+	assert.Empty(t, trailers)
+}
+
+func TestUpdateMessageTrailers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pr := &github.PullRequest{
+		Number:  github.Int(42),
+		Title:   github.String("Fix a bug"),
+		Body:    github.String("Fixes a bug.\n\nReviewed-by: bob"),
+		HTMLURL: github.String("https://github.com/foo/bar/pull/42"),
+	}
+
+	ed := editortest.NewMockEditor(mockCtrl)
+	ed.EXPECT().EditString(
+		"Fix a bug (#42)\n"+
+			"\n"+
+			"Fixes a bug.\n"+
+			"\n"+
+			"Reviewed-by: bob\n"+
+			"\n"+
+			"Reviewed-by: carol\n"+
+			"Pull-Request: https://github.com/foo/bar/pull/42\n"+
+			"\n"+
+			"# Landing Pull Request: https://github.com/foo/bar/pull/42\n"+
+			"#\n"+
+			"# Enter the commit message above. Lines starting with '#' will be\n"+
+			"# ignored. There must be an empty line between the title and the body.\n"+
+			"# Leaving this file empty will abort the operation.\n",
+	).Return("Fix a bug (#42)\n\nFixes a bug.\n\nReviewed-by: carol\n", nil)
+
+	require.NoError(t, UpdateMessage(MessageConfig{
+		Editor: ed,
+		Trailers: []Trailer{
+			{Key: "Reviewed-by", Value: "bob"},
+			{Key: "Reviewed-by", Value: "carol"},
+			{Key: "Pull-Request", Value: "https://github.com/foo/bar/pull/42"},
+		},
+	}, pr))
+	assert.Equal(t, "Fix a bug (#42)", pr.GetTitle())
+	assert.Equal(t, "Fixes a bug.\n\nReviewed-by: carol\n", pr.GetBody())
+}
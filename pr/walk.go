@@ -2,8 +2,10 @@ package pr
 
 import (
 	"container/list"
+	"context"
 	"fmt"
 	"runtime"
+	"strings"
 	"sync"
 
 	"go.uber.org/multierr"
@@ -28,6 +30,12 @@ type Visitor interface {
 
 // WalkConfig configures a pull request traversal.
 type WalkConfig struct {
+	// If the context is cancelled, no new pull requests will be visited and
+	// Walk will return the context's error once ongoing visits finish.
+	//
+	// Defaults to a context that is never cancelled.
+	Context context.Context
+
 	// Maximum number of pull requests to visit at the same time.
 	//
 	// Defaults to the number of CPUs available to this process.
@@ -47,6 +55,11 @@ type WalkConfig struct {
 // their children in an unspecified order. The only ordering guarantee is that
 // parents are visited before their children.
 //
+// Each pull request is visited at most once. If a pull request is its own
+// ancestor, its children are not visited and a CycleError is returned. A
+// CycleError is also returned if pull requests that the walk started at
+// depend on each other in a cycle.
+//
 // Errors encountered while visiting pull requests are collatted and presented
 // as one.
 func Walk(cfg WalkConfig, pulls []*github.PullRequest, v Visitor) error {
@@ -58,19 +71,33 @@ func Walk(cfg WalkConfig, pulls []*github.PullRequest, v Visitor) error {
 		cfg.Concurrency = runtime.NumCPU()
 	}
 
+	if cfg.Context == nil {
+		cfg.Context = context.Background()
+	}
+
 	w := walker{
+		ctx: cfg.Context,
 		// TODO: Magic number. Should make this customizable or leave it the
 		// same as Concurrency.
 		tasks:    make(chan task, 8),
 		children: cfg.Children,
+		visited:  make(map[int]struct{}),
+		edges:    make(map[int][]*github.PullRequest),
 	}
 
-	w.ongoing.Add(len(pulls))
+	tasks := make([]task, 0, len(pulls))
+	for _, pr := range pulls {
+		if w.markVisited(pr) {
+			tasks = append(tasks, task{PR: pr, Visitor: v})
+		}
+	}
+
+	w.ongoing.Add(len(tasks))
 	go func() {
 		// If pulls contains more than 8 items, we don't want to block on
 		// filling tasks just yet.
-		for _, pr := range pulls {
-			w.tasks <- task{PR: pr, Visitor: v}
+		for _, t := range tasks {
+			w.tasks <- t
 		}
 	}()
 
@@ -80,16 +107,47 @@ func Walk(cfg WalkConfig, pulls []*github.PullRequest, v Visitor) error {
 	w.ongoing.Wait()
 	close(w.tasks)
 
-	return multierr.Combine(w.errors...)
+	err := multierr.Combine(w.errors...)
+	if err == nil {
+		// A cycle through more than one of the starting pull requests isn't
+		// in the ancestry of any task because each of them is visited only
+		// once.
+		if cycle := w.findCycle(pulls); cycle != nil {
+			err = CycleError{Pulls: cycle}
+		}
+	}
+	return multierr.Append(err, cfg.Context.Err())
+}
+
+// CycleError is returned by Walk if a pull request is found to depend on
+// itself.
+type CycleError struct {
+	// Pull requests that form the cycle. The first and last pull requests
+	// in this list are the same.
+	Pulls []*github.PullRequest
+}
+
+func (e CycleError) Error() string {
+	nums := make([]string, len(e.Pulls))
+	for i, pr := range e.Pulls {
+		nums[i] = fmt.Sprintf("#%v", pr.GetNumber())
+	}
+	return fmt.Sprintf("pull requests form a cycle: %v", strings.Join(nums, " -> "))
 }
 
 // Request to visit a single pull request with a specific visitor.
 type task struct {
 	PR      *github.PullRequest
 	Visitor Visitor
+
+	// Ancestors of PR, starting at the pull request at which the walk
+	// started.
+	Ancestors []*github.PullRequest
 }
 
 type walker struct {
+	ctx context.Context
+
 	// Incoming tasks. Any worker can handle these.
 	tasks chan task
 
@@ -98,6 +156,14 @@ type walker struct {
 
 	children func(*github.PullRequest) ([]*github.PullRequest, error)
 
+	// Numbers of pull requests that were already scheduled to be visited.
+	visitedMu sync.Mutex
+	visited   map[int]struct{}
+
+	// Children of pull requests that were visited, by number.
+	edgesMu sync.Mutex
+	edges   map[int][]*github.PullRequest
+
 	// Errors encountered while processing.
 	errorsMu sync.Mutex
 	errors   []error
@@ -129,6 +195,13 @@ worker:
 			break worker
 		}
 
+		// Drop the task if the walk was cancelled. Walk will report the
+		// error.
+		if w.ctx.Err() != nil {
+			w.ongoing.Done()
+			continue
+		}
+
 		newTasks, err := w.visit(t)
 		if err != nil {
 			w.errorsMu.Lock()
@@ -143,6 +216,19 @@ worker:
 	}
 }
 
+// markVisited marks the given pull request as visited, returning false if it
+// was already visited.
+func (w *walker) markVisited(pr *github.PullRequest) bool {
+	w.visitedMu.Lock()
+	defer w.visitedMu.Unlock()
+
+	if _, ok := w.visited[pr.GetNumber()]; ok {
+		return false
+	}
+	w.visited[pr.GetNumber()] = struct{}{}
+	return true
+}
+
 func (w *walker) visit(t task) (_ []task, err error) {
 	defer func() {
 		if x := recover(); x != nil {
@@ -169,9 +255,78 @@ func (w *walker) visit(t task) (_ []task, err error) {
 		return nil, err
 	}
 
-	tasks := make([]task, len(children))
-	for i, pr := range children {
-		tasks[i] = task{PR: pr, Visitor: v}
+	w.edgesMu.Lock()
+	w.edges[t.PR.GetNumber()] = children
+	w.edgesMu.Unlock()
+
+	// Limit the capacity so that appending to the ancestry of a child
+	// doesn't modify the ancestry of its siblings.
+	ancestors := append(t.Ancestors[:len(t.Ancestors):len(t.Ancestors)], t.PR)
+	for _, pr := range children {
+		for i, a := range ancestors {
+			if a.GetNumber() == pr.GetNumber() {
+				cycle := make([]*github.PullRequest, 0, len(ancestors)-i+1)
+				cycle = append(cycle, ancestors[i:]...)
+				return nil, CycleError{Pulls: append(cycle, pr)}
+			}
+		}
+	}
+
+	tasks := make([]task, 0, len(children))
+	for _, pr := range children {
+		if w.markVisited(pr) {
+			tasks = append(tasks, task{PR: pr, Visitor: v, Ancestors: ancestors})
+		}
 	}
 	return tasks, nil
 }
+
+// findCycle looks for a cycle in the pull requests that were visited,
+// starting at the given pull requests. The first and last pull requests in
+// the returned list are the same. nil is returned if there are no cycles.
+//
+// This must not be called while the walk is ongoing.
+func (w *walker) findCycle(pulls []*github.PullRequest) []*github.PullRequest {
+	const (
+		inProgress = iota + 1
+		done
+	)
+
+	var (
+		state = make(map[int]int)
+		path  []*github.PullRequest
+		visit func(*github.PullRequest) []*github.PullRequest
+	)
+	visit = func(pr *github.PullRequest) []*github.PullRequest {
+		switch state[pr.GetNumber()] {
+		case done:
+			return nil
+		case inProgress:
+			for i, p := range path {
+				if p.GetNumber() == pr.GetNumber() {
+					cycle := make([]*github.PullRequest, 0, len(path)-i+1)
+					cycle = append(cycle, path[i:]...)
+					return append(cycle, pr)
+				}
+			}
+		}
+
+		state[pr.GetNumber()] = inProgress
+		path = append(path, pr)
+		for _, child := range w.edges[pr.GetNumber()] {
+			if cycle := visit(child); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[pr.GetNumber()] = done
+		return nil
+	}
+
+	for _, pr := range pulls {
+		if cycle := visit(pr); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
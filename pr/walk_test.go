@@ -1,9 +1,14 @@
 package pr_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
 	"testing"
+	"testing/quick"
 
 	"github.com/abhinav/git-pr/pr"
 	"github.com/abhinav/git-pr/pr/prtest"
@@ -11,6 +16,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-github/github"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockChildren struct {
@@ -161,6 +167,42 @@ func TestWalk(t *testing.T) {
 			},
 			WantErr: []string{"great sadness", "something went wrong"},
 		},
+		{
+			Desc:     "self cycle",
+			Pulls:    []int{1},
+			Children: children{1: {1}},
+			Visits:   visits{1: {VisitChildren: true}},
+			WantErr:  []string{"pull requests form a cycle: #1 -> #1"},
+		},
+		{
+			Desc:  "cycle",
+			Pulls: []int{1},
+			Children: children{
+				1: {2},
+				2: {3},
+				3: {2},
+			},
+			Visits: visits{
+				1: {VisitChildren: true},
+				2: {VisitChildren: true},
+				3: {VisitChildren: true},
+			},
+			WantErr: []string{"pull requests form a cycle: #2 -> #3 -> #2"},
+		},
+		{
+			Desc:  "duplicates",
+			Pulls: []int{1, 2, 1},
+			Children: children{
+				1: {2, 3},
+				2: {},
+				3: {},
+			},
+			Visits: visits{
+				1: {VisitChildren: true},
+				2: {VisitChildren: true},
+				3: {VisitChildren: true},
+			},
+		},
 		{
 			Desc:  "channel overflow",
 			Pulls: []int{1, 21},
@@ -273,6 +315,192 @@ func TestWalk(t *testing.T) {
 	}
 }
 
+func TestWalkCancelled(t *testing.T) {
+	t.Run("before start", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		cfg := pr.WalkConfig{
+			Context:  ctx,
+			Children: newMockChildren(ctrl).Call,
+		}
+		err := pr.Walk(cfg, fakePullRequests([]int{1, 2}), prtest.NewMockVisitor(ctrl))
+		assert.Equal(t, context.Canceled, err)
+	})
+
+	t.Run("during walk", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		getChildren := newMockChildren(ctrl)
+		getChildren.Expect(prMatcher{Number: 1}).
+			Return(fakePullRequests([]int{2, 3}), nil)
+
+		// Children of 1 must not be visited after the walk is cancelled.
+		visitor := prtest.NewMockVisitor(ctrl)
+		visitor.EXPECT().Visit(prMatcher{Number: 1}).
+			Do(func(*github.PullRequest) { cancel() }).
+			Return(visitor, nil)
+
+		cfg := pr.WalkConfig{Context: ctx, Children: getChildren.Call}
+		err := pr.Walk(cfg, fakePullRequests([]int{1}), visitor)
+		assert.Equal(t, context.Canceled, err)
+	})
+}
+
+// randomForest is a randomly generated forest of pull requests.
+type randomForest struct {
+	// Parents[i] is the index of the parent of pull request i or -1 if it's
+	// the root of a tree. Parents are always placed before their children.
+	Parents []int
+}
+
+var _ quick.Generator = randomForest{}
+
+func (randomForest) Generate(rand *rand.Rand, size int) reflect.Value {
+	parents := make([]int, rand.Intn(size+1)+1)
+	for i := range parents {
+		parents[i] = rand.Intn(i+1) - 1
+	}
+	return reflect.ValueOf(randomForest{Parents: parents})
+}
+
+// Roots returns the numbers of the roots of the forest.
+func (f randomForest) Roots() []int {
+	var roots []int
+	for i, p := range f.Parents {
+		if p < 0 {
+			roots = append(roots, i)
+		}
+	}
+	return roots
+}
+
+// Children builds a WalkConfig.Children function for this forest. extra
+// specifies additional children for some pull requests.
+func (f randomForest) Children(extra map[int]int) func(*github.PullRequest) ([]*github.PullRequest, error) {
+	children := make(map[int][]int)
+	for i, p := range f.Parents {
+		if p >= 0 {
+			children[p] = append(children[p], i)
+		}
+	}
+	for parent, child := range extra {
+		children[parent] = append(children[parent], child)
+	}
+
+	return func(pr *github.PullRequest) ([]*github.PullRequest, error) {
+		return fakePullRequests(children[pr.GetNumber()]), nil
+	}
+}
+
+// recordingVisitor is a Visitor which records the pull requests it visits.
+type recordingVisitor struct {
+	mu      sync.Mutex
+	visited []int
+}
+
+func (v *recordingVisitor) Visit(p *github.PullRequest) (pr.Visitor, error) {
+	v.mu.Lock()
+	v.visited = append(v.visited, p.GetNumber())
+	v.mu.Unlock()
+	return v, nil
+}
+
+func TestWalkVisitsForestOnce(t *testing.T) {
+	err := quick.Check(func(f randomForest, conc uint8) bool {
+		var v recordingVisitor
+		cfg := pr.WalkConfig{Children: f.Children(nil), Concurrency: int(conc % 8)}
+		if err := pr.Walk(cfg, fakePullRequests(f.Roots()), &v); err != nil {
+			t.Logf("walk failed: %v", err)
+			return false
+		}
+
+		if len(v.visited) != len(f.Parents) {
+			t.Logf("visited %v pull requests, expected %v", len(v.visited), len(f.Parents))
+			return false
+		}
+
+		// Position of each pull request in the order of visits.
+		position := make(map[int]int, len(v.visited))
+		for i, n := range v.visited {
+			if _, ok := position[n]; ok {
+				t.Logf("visited #%v more than once", n)
+				return false
+			}
+			position[n] = i
+		}
+
+		for i, p := range f.Parents {
+			if p >= 0 && position[p] > position[i] {
+				t.Logf("visited #%v before its parent #%v", i, p)
+				return false
+			}
+		}
+		return true
+	}, nil)
+	assert.NoError(t, err)
+}
+
+func TestWalkDetectsCycles(t *testing.T) {
+	err := quick.Check(func(f randomForest, from, to uint) bool {
+		// Pick a pull request and make one of its ancestors (or itself) its
+		// child.
+		child := int(from % uint(len(f.Parents)))
+		var ancestors []int
+		for i := child; i >= 0; i = f.Parents[i] {
+			ancestors = append(ancestors, i)
+		}
+		ancestor := ancestors[to%uint(len(ancestors))]
+
+		cfg := pr.WalkConfig{Children: f.Children(map[int]int{child: ancestor})}
+		err := pr.Walk(cfg, fakePullRequests(f.Roots()), new(recordingVisitor))
+		if err == nil {
+			t.Logf("expected a cycle from #%v to #%v", child, ancestor)
+			return false
+		}
+
+		cycle, ok := err.(pr.CycleError)
+		if !ok {
+			t.Logf("expected a CycleError, got %v", err)
+			return false
+		}
+
+		first, last := cycle.Pulls[0], cycle.Pulls[len(cycle.Pulls)-1]
+		return first.GetNumber() == ancestor && last.GetNumber() == ancestor
+	}, nil)
+	assert.NoError(t, err)
+}
+
+func TestWalkDetectsCyclesAcrossStartingPulls(t *testing.T) {
+	// #1 and #2 are both starting points and each is the child of the
+	// other. Neither is in the ancestry of the other when it's visited.
+	children := map[int][]int{1: {2}, 2: {1}}
+	cfg := pr.WalkConfig{
+		Children: func(p *github.PullRequest) ([]*github.PullRequest, error) {
+			return fakePullRequests(children[p.GetNumber()]), nil
+		},
+	}
+
+	err := pr.Walk(cfg, fakePullRequests([]int{1, 2}), new(recordingVisitor))
+	require.Error(t, err, "expected a cycle")
+
+	cycle, ok := err.(pr.CycleError)
+	require.True(t, ok, "expected a CycleError, got %v", err)
+
+	var nums []int
+	for _, p := range cycle.Pulls {
+		nums = append(nums, p.GetNumber())
+	}
+	assert.Equal(t, []int{1, 2, 1}, nums)
+}
+
 type prMatcher struct {
 	Number int
 }
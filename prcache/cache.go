@@ -0,0 +1,273 @@
+// Package prcache keeps a list of open pull requests on disk so that they can
+// be suggested by shell completion without talking to GitHub.
+package prcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/logging"
+
+	"github.com/google/go-github/github"
+	"go.uber.org/multierr"
+)
+
+// Pull requests that weren't seen in this long are assumed to have been
+// merged or closed without us noticing.
+const _maxAge = 14 * 24 * time.Hour
+
+// _now is replaced in tests.
+var _now = time.Now
+
+// PullRequest is an open pull request in the cache.
+type PullRequest struct {
+	Number int    `json:"number"`
+	Branch string `json:"branch"`
+	Base   string `json:"base"`
+	Title  string `json:"title"`
+
+	// Last time the pull request was listed by GitHub.
+	Seen time.Time `json:"seen"`
+}
+
+// Path returns the path to the cache for the repository whose .git
+// directory is at gitDir. This must be the directory shared by all working
+// trees of the repository.
+func Path(gitDir string) string {
+	return filepath.Join(gitDir, "git-pr", "pulls.json")
+}
+
+// Load reads the pull requests in the cache at path, sorted by number. No
+// pull requests are returned if the cache doesn't exist. Pull requests that
+// weren't seen recently are left out.
+func Load(path string) ([]*PullRequest, error) {
+	prs, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := prs[:0]
+	for _, pr := range prs {
+		if _now().Sub(pr.Seen) < _maxAge {
+			fresh = append(fresh, pr)
+		}
+	}
+	return fresh, nil
+}
+
+func load(path string) ([]*PullRequest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %v: %v", path, err)
+	}
+
+	var prs []*PullRequest
+	if err := json.Unmarshal(data, &prs); err != nil {
+		return nil, fmt.Errorf("failed to parse %v: %v", path, err)
+	}
+	return prs, nil
+}
+
+// save writes prs to path. The cache is written to a temporary file first
+// so that other git-pr processes never read a partially written cache.
+func save(path string, prs []*PullRequest) (err error) {
+	data, err := json.Marshal(prs)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to write %v: %v", path, err)
+	}
+
+	f, err := ioutil.TempFile(dir, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to write %v: %v", path, err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	_, err = f.Write(data)
+	err = multierr.Append(err, f.Close())
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %v: %v", path, err)
+	}
+	return nil
+}
+
+// Cache collects the pull requests listed through the GitHub gateways it
+// wraps and writes them to disk when it's saved. Pull requests that were
+// closed, and pull requests missing from a listing of all open pull requests
+// against their base, are removed from the cache.
+type Cache struct {
+	path string
+	log  *logging.Logger
+
+	mu      sync.Mutex
+	updates []update
+}
+
+// update is a batch of pull requests listed by GitHub.
+type update struct {
+	// If non-empty, the pull requests are all open pull requests against
+	// this base.
+	Base string
+
+	// Pull requests to add to the cache and numbers of pull requests to
+	// remove from it.
+	Open   []*PullRequest
+	Closed []int
+}
+
+// New builds a Cache for the cache at path.
+func New(path string, log *logging.Logger) *Cache {
+	return &Cache{path: path, log: log}
+}
+
+// Wrap wraps the given GitHub gateway to collect the pull requests it lists
+// into the cache.
+func (c *Cache) Wrap(gh gateway.GitHub) gateway.GitHub {
+	return &cachingGitHub{GitHub: gh, cache: c}
+}
+
+// Save writes the collected pull requests to the cache on disk. Failures to
+// update the cache are logged and otherwise ignored.
+func (c *Cache) Save() {
+	c.mu.Lock()
+	updates := c.updates
+	c.updates = nil
+	c.mu.Unlock()
+
+	if len(updates) == 0 {
+		return
+	}
+	if err := c.save(updates); err != nil {
+		c.log.Debug("failed to update pull request cache", logging.Error(err))
+	}
+}
+
+func (c *Cache) save(updates []update) error {
+	cached, err := Load(c.path)
+	if err != nil {
+		return err
+	}
+
+	byNumber := make(map[int]*PullRequest, len(cached))
+	for _, pr := range cached {
+		byNumber[pr.Number] = pr
+	}
+
+	for _, u := range updates {
+		if u.Base != "" {
+			for num, pr := range byNumber {
+				if pr.Base == u.Base {
+					delete(byNumber, num)
+				}
+			}
+		}
+		for _, num := range u.Closed {
+			delete(byNumber, num)
+		}
+		for _, pr := range u.Open {
+			byNumber[pr.Number] = pr
+		}
+	}
+
+	cached = cached[:0]
+	for _, pr := range byNumber {
+		cached = append(cached, pr)
+	}
+	sort.Slice(cached, func(i, j int) bool {
+		return cached[i].Number < cached[j].Number
+	})
+	return save(c.path, cached)
+}
+
+// add records the given pull requests. If base is non-empty, prs holds all
+// open pull requests against base and others against it are removed.
+func (c *Cache) add(ctx context.Context, gh gateway.GitHub, prs []*github.PullRequest, base string) {
+	if len(prs) == 0 && base == "" {
+		return
+	}
+
+	u := update{Base: base}
+	for _, pr := range prs {
+		// Pull requests from forks can't be checked out by branch name.
+		if pr.GetState() != "open" || pr.Head == nil || pr.Head.Repo == nil ||
+			!gh.IsOwned(ctx, pr.Head) {
+			u.Closed = append(u.Closed, pr.GetNumber())
+			continue
+		}
+
+		u.Open = append(u.Open, &PullRequest{
+			Number: pr.GetNumber(),
+			Branch: pr.Head.GetRef(),
+			Base:   pr.GetBase().GetRef(),
+			Title:  pr.GetTitle(),
+			Seen:   _now(),
+		})
+	}
+
+	c.mu.Lock()
+	c.updates = append(c.updates, u)
+	c.mu.Unlock()
+}
+
+type cachingGitHub struct {
+	gateway.GitHub
+
+	cache *Cache
+}
+
+func (g *cachingGitHub) GetPullRequest(ctx context.Context, number int) (*github.PullRequest, error) {
+	pr, err := g.GitHub.GetPullRequest(ctx, number)
+	if err == nil {
+		g.cache.add(ctx, g.GitHub, []*github.PullRequest{pr}, "")
+	}
+	return pr, err
+}
+
+func (g *cachingGitHub) ListPullRequestsByHead(ctx context.Context, owner, branch string) ([]*github.PullRequest, error) {
+	prs, err := g.GitHub.ListPullRequestsByHead(ctx, owner, branch)
+	if err == nil {
+		g.cache.add(ctx, g.GitHub, prs, "")
+	}
+	return prs, err
+}
+
+func (g *cachingGitHub) ListAllPullRequestsByHead(ctx context.Context, owner, branch string) ([]*github.PullRequest, error) {
+	prs, err := g.GitHub.ListAllPullRequestsByHead(ctx, owner, branch)
+	if err == nil {
+		g.cache.add(ctx, g.GitHub, prs, "")
+	}
+	return prs, err
+}
+
+func (g *cachingGitHub) ListPullRequestsByBase(ctx context.Context, branch string) ([]*github.PullRequest, error) {
+	prs, err := g.GitHub.ListPullRequestsByBase(ctx, branch)
+	if err == nil {
+		g.cache.add(ctx, g.GitHub, prs, branch)
+	}
+	return prs, err
+}
@@ -0,0 +1,182 @@
+package prcache
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/ptr"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pullRequest(number int, state, head, owner string) *github.PullRequest {
+	return &github.PullRequest{
+		Number: &number,
+		State:  ptr.String(state),
+		Title:  ptr.String("Change " + head),
+		Base:   &github.PullRequestBranch{Ref: ptr.String("master")},
+		Head: &github.PullRequestBranch{
+			Ref: ptr.String(head),
+			Repo: &github.Repository{
+				Owner: &github.User{Login: ptr.String(owner)},
+				Name:  ptr.String("git-pr"),
+			},
+		},
+	}
+}
+
+// useTime sets the current time to now until the returned function is
+// called.
+func useTime(now time.Time) (restore func()) {
+	old := _now
+	_now = func() time.Time { return now }
+	return func() { _now = old }
+}
+
+func TestWrap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prcache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	defer useTime(now)()
+
+	path := Path(dir)
+	assert.Equal(t, filepath.Join(dir, "git-pr", "pulls.json"), path)
+
+	prs, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, prs)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	gh := gatewaytest.NewMockGitHub(mockCtrl)
+	pr1 := pullRequest(1, "open", "feature1", "abhinav")
+	pr2 := pullRequest(2, "open", "feature2", "someone")
+	pr3 := pullRequest(3, "open", "feature3", "abhinav")
+	pr4 := pullRequest(4, "open", "feature4", "abhinav")
+	for _, pr := range []*github.PullRequest{pr1, pr3, pr4} {
+		gh.EXPECT().IsOwned(gomock.Any(), pr.Head).Return(true).AnyTimes()
+	}
+	gh.EXPECT().IsOwned(gomock.Any(), pr2.Head).Return(false).AnyTimes()
+
+	ctx := context.Background()
+	cache := New(path, nil)
+	cached := cache.Wrap(gh)
+
+	gh.EXPECT().ListPullRequestsByBase(ctx, "master").
+		Return([]*github.PullRequest{pr1, pr2, pr3}, nil)
+	_, err = cached.ListPullRequestsByBase(ctx, "master")
+	require.NoError(t, err)
+
+	// Nothing is written until the cache is saved.
+	prs, err = Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, prs)
+
+	cache.Save()
+	prs, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []*PullRequest{
+		{Number: 1, Branch: "feature1", Base: "master", Title: "Change feature1", Seen: now},
+		{Number: 3, Branch: "feature3", Base: "master", Title: "Change feature3", Seen: now},
+	}, prs)
+
+	gh.EXPECT().ListAllPullRequestsByHead(ctx, "", "feature1").Return([]*github.PullRequest{
+		pullRequest(1, "closed", "feature1", "abhinav"),
+	}, nil)
+	gh.EXPECT().ListPullRequestsByHead(ctx, "", "feature4").
+		Return([]*github.PullRequest{pr4}, nil)
+	_, err = cached.ListAllPullRequestsByHead(ctx, "", "feature1")
+	require.NoError(t, err)
+	_, err = cached.ListPullRequestsByHead(ctx, "", "feature4")
+	require.NoError(t, err)
+
+	cache.Save()
+	prs, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []*PullRequest{
+		{Number: 3, Branch: "feature3", Base: "master", Title: "Change feature3", Seen: now},
+		{Number: 4, Branch: "feature4", Base: "master", Title: "Change feature4", Seen: now},
+	}, prs)
+
+	// #3 was merged elsewhere so it's missing from the open pull requests
+	// against master.
+	gh.EXPECT().ListPullRequestsByBase(ctx, "master").
+		Return([]*github.PullRequest{pr4}, nil)
+	gh.EXPECT().GetPullRequest(ctx, 5).
+		Return(pullRequest(5, "open", "feature5", "abhinav"), nil)
+	gh.EXPECT().IsOwned(gomock.Any(), gomock.Any()).Return(true)
+	_, err = cached.ListPullRequestsByBase(ctx, "master")
+	require.NoError(t, err)
+	_, err = cached.GetPullRequest(ctx, 5)
+	require.NoError(t, err)
+
+	cache.Save()
+	prs, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []*PullRequest{
+		{Number: 4, Branch: "feature4", Base: "master", Title: "Change feature4", Seen: now},
+		{Number: 5, Branch: "feature5", Base: "master", Title: "Change feature5", Seen: now},
+	}, prs)
+
+	// Only the cache is left behind in its directory.
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "pulls.json", files[0].Name())
+}
+
+func TestLoadSkipsStale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prcache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2018, 1, 20, 0, 0, 0, 0, time.UTC)
+	defer useTime(now)()
+
+	path := Path(dir)
+	require.NoError(t, save(path, []*PullRequest{
+		{Number: 1, Branch: "feature1", Seen: now.Add(-15 * 24 * time.Hour)},
+		{Number: 2, Branch: "feature2", Seen: now.Add(-13 * 24 * time.Hour)},
+	}))
+
+	prs, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []*PullRequest{
+		{Number: 2, Branch: "feature2", Seen: now.Add(-13 * 24 * time.Hour)},
+	}, prs)
+}
+
+func TestWrapCacheUnwritable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prcache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// A file where the cache's directory should be.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "git-pr"), nil, 0644))
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	gh := gatewaytest.NewMockGitHub(mockCtrl)
+	gh.EXPECT().IsOwned(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+
+	want := []*github.PullRequest{pullRequest(1, "open", "feature1", "abhinav")}
+	gh.EXPECT().ListPullRequestsByBase(gomock.Any(), "master").Return(want, nil)
+
+	cache := New(Path(dir), nil)
+	got, err := cache.Wrap(gh).ListPullRequestsByBase(context.Background(), "master")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	cache.Save()
+}
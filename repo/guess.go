@@ -7,25 +7,53 @@ import (
 	"github.com/abhinav/git-pr/gateway"
 )
 
-var _prefixes = []string{
-	"ssh://git@github.com/",
-	"git@github.com:",
-	"https://github.com/",
-}
+// Guess determines the Repo name based on the URL of the given remote of the
+// current Git repository. Remotes on hosts other than github.com are assumed
+// to be GitHub Enterprise installations.
+func Guess(git gateway.Git, remote string) (*Repo, error) {
+	url, err := git.RemoteURL(remote)
+	if err != nil {
+		return nil, err
+	}
 
-// Guess determines the Repo name based on the current Git repository's remotes.
-func Guess(git gateway.Git) (*Repo, error) {
-	url, err := git.RemoteURL("origin")
+	host, path, ok := splitURL(url)
+	if !ok {
+		return nil, fmt.Errorf("remote %q (%v) is not a GitHub remote", remote, url)
+	}
+
+	r, err := Parse(strings.TrimSuffix(path, ".git"))
 	if err != nil {
 		return nil, err
 	}
+	if host != "github.com" {
+		r.Host = host
+	}
+	return r, nil
+}
 
-	for _, prefix := range _prefixes {
-		if strings.HasPrefix(url, prefix) {
-			url := strings.TrimPrefix(url, prefix)
-			return Parse(strings.TrimSuffix(url, ".git"))
-		}
+// splitURL splits a remote URL in one of the following forms into its host
+// and path.
+//
+// 	ssh://git@HOST/PATH
+// 	git@HOST:PATH
+// 	https://HOST/PATH
+func splitURL(url string) (host, path string, ok bool) {
+	sep := "/"
+	switch {
+	case strings.HasPrefix(url, "ssh://git@"):
+		url = strings.TrimPrefix(url, "ssh://git@")
+	case strings.HasPrefix(url, "https://"):
+		url = strings.TrimPrefix(url, "https://")
+	case strings.HasPrefix(url, "git@"):
+		url = strings.TrimPrefix(url, "git@")
+		sep = ":"
+	default:
+		return "", "", false
 	}
 
-	return nil, fmt.Errorf(`remote "origin" (%v) is not a GitHub remote`, url)
+	i := strings.Index(url, sep)
+	if i <= 0 {
+		return "", "", false
+	}
+	return url[:i], url[i+1:], true
 }
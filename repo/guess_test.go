@@ -19,6 +19,15 @@ func TestGuess(t *testing.T) {
 		{url: "git@github.com:foo/bar", want: Repo{Owner: "foo", Name: "bar"}},
 		{url: "https://github.com/baz/qux", want: Repo{Owner: "baz", Name: "qux"}},
 		{url: "ssh://git@github.com/abc/def", want: Repo{Owner: "abc", Name: "def"}},
+		{
+			url:  "git@github.example.com:foo/bar.git",
+			want: Repo{Host: "github.example.com", Owner: "foo", Name: "bar"},
+		},
+		{
+			url:  "https://github.example.com/baz/qux",
+			want: Repo{Host: "github.example.com", Owner: "baz", Name: "qux"},
+		},
+		{url: "https://github.com/foo", wantErr: "repository must be in the form owner/repo"},
 		{url: "/home/foo/bar", wantErr: `remote "origin" (/home/foo/bar) is not a GitHub remote`},
 	}
 
@@ -30,7 +39,7 @@ func TestGuess(t *testing.T) {
 			git := gatewaytest.NewMockGit(mockCtrl)
 			git.EXPECT().RemoteURL("origin").Return(tt.url, nil).AnyTimes()
 
-			got, err := Guess(git)
+			got, err := Guess(git, "origin")
 			if tt.wantErr != "" {
 				if assert.Error(t, err) {
 					assert.Contains(t, err.Error(), tt.wantErr)
@@ -6,11 +6,19 @@ import (
 	"strings"
 )
 
-// Parse parses a repository name in the format 'owner/repo'.
+// Parse parses a repository name in the format 'owner/repo'. Repositories on
+// GitHub Enterprise installations are specified as 'host/owner/repo'.
 func Parse(value string) (*Repo, error) {
+	var host string
 	parts := strings.Split(value, "/")
+	if len(parts) == 3 {
+		host, parts = parts[0], parts[1:]
+		if host == "" {
+			return nil, fmt.Errorf("host in repository %q cannot be empty", value)
+		}
+	}
 	if len(parts) != 2 {
-		return nil, errors.New("repository must be in the form owner/repo")
+		return nil, errors.New("repository must be in the form owner/repo or host/owner/repo")
 	}
 
 	owner := parts[0]
@@ -23,5 +31,8 @@ func Parse(value string) (*Repo, error) {
 		return nil, fmt.Errorf("name in repository %q cannot be empty", value)
 	}
 
-	return &Repo{Owner: owner, Name: name}, nil
+	if host == "github.com" {
+		host = ""
+	}
+	return &Repo{Host: host, Owner: owner, Name: name}, nil
 }
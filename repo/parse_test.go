@@ -16,9 +16,17 @@ func TestParse(t *testing.T) {
 			give: "foo/bar",
 			want: Repo{Owner: "foo", Name: "bar"},
 		},
+		{
+			give: "github.example.com/foo/bar",
+			want: Repo{Host: "github.example.com", Owner: "foo", Name: "bar"},
+		},
 		{
 			give:    "foobar",
-			wantErr: "repository must be in the form owner/repo",
+			wantErr: "repository must be in the form owner/repo or host/owner/repo",
+		},
+		{
+			give:    "/foo/bar",
+			wantErr: `host in repository "/foo/bar" cannot be empty`,
 		},
 		{
 			give:    "/foo",
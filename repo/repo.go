@@ -4,6 +4,10 @@ import "fmt"
 
 // Repo uniquely identifies a GitHub repository.
 type Repo struct {
+	// Host of the GitHub Enterprise installation the repository lives in.
+	// This is empty for repositories on github.com.
+	Host string
+
 	Owner string
 	Name  string
 }
@@ -12,5 +16,8 @@ func (r *Repo) String() string {
 	if r.Owner == "" && r.Name == "" {
 		return ""
 	}
+	if r.Host != "" {
+		return fmt.Sprintf("%v/%v/%v", r.Host, r.Owner, r.Name)
+	}
 	return fmt.Sprintf("%v/%v", r.Owner, r.Name)
 }
@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"text/template"
 
 	"github.com/abhinav/git-pr/editor"
+	"github.com/abhinav/git-pr/gateway"
 
 	"github.com/google/go-github/github"
 )
@@ -19,10 +21,62 @@ type LandRequest struct {
 
 	// Editor to use for editing the commit message.
 	Editor editor.Editor
+
+	// Template used to build the initial commit message. A default template
+	// is used if this is nil.
+	MessageTemplate *template.Template
+
+	// Trailers to add to the commit message.
+	Trailers LandTrailers
+
+	// Rules that the commit message must follow.
+	Lint LintRules
+
+	// How the pull request is merged. Defaults to squashing it.
+	MergeMethod gateway.MergeMethod
+}
+
+// LintRules are rules that the commit message of a pull request must follow
+// for it to be landed. Rules with zero values are disabled.
+type LintRules struct {
+	// Maximum length of the title.
+	MaxTitleLength int
+
+	// Require the title to follow the Conventional Commits format,
+	//
+	// 	type(scope): description
+	ConventionalCommits bool
+
+	// Maximum length of lines in the body. Indented lines and lines without
+	// spaces, like URLs, are exempt.
+	BodyWidth int
+
+	// Require the body to reference an issue.
+	RequireIssue bool
+}
+
+// LandTrailers specifies which trailers are added to the commit message of a
+// pull request when it is landed. The trailers are included in the message
+// presented to the user so they may be adjusted before landing.
+type LandTrailers struct {
+	// Add a Reviewed-by trailer for each user who approved the pull
+	// request.
+	ReviewedBy bool
+
+	// Add a Co-authored-by trailer for each author of commits in the pull
+	// request other than the author of the pull request.
+	CoAuthoredBy bool
+
+	// Add a Pull-Request trailer with the URL of the pull request.
+	PullRequest bool
 }
 
 // LandResponse is the response of a land request.
 type LandResponse struct {
+	// Pull requests that depended on the landed pull request and were
+	// rebased onto its base branch.
+	RebasedPullRequests []RebasedPullRequest
+
 	BranchesNotUpdated []string
 }
 
@@ -41,6 +95,71 @@ type RebaseRequest struct {
 
 // RebaseResponse is the response of the Rebase operation.
 type RebaseResponse struct {
+	// Pull requests that were rebased, sorted by number.
+	RebasedPullRequests []RebasedPullRequest
+
+	// Local branches that were not updated because their heads did not match
+	// the remotes.
+	BranchesNotUpdated []string
+}
+
+// RebasedPullRequest is a pull request that was rebased.
+type RebasedPullRequest struct {
+	PullRequest *github.PullRequest
+
+	// Heads of the pull request before and after it was rebased.
+	OldSHA string
+	NewSHA string
+}
+
+// RebaseLocalRequest is a request to rebase a local branch and the branches
+// that depend on it based on the relationships between branches recorded
+// locally. Pull requests are not consulted or changed.
+type RebaseLocalRequest struct {
+	// Branch to rebase.
+	Branch string
+
+	// Branch onto which Branch will be rebased. If empty, Branch is left
+	// unchanged and only the branches that depend on it are rebased onto its
+	// current head.
+	Base string
+}
+
+// RebaseLocalResponse is the response of the RebaseLocal operation.
+type RebaseLocalResponse struct {
+	// Local branches that were rebased.
+	RebasedBranches []string
+}
+
+// SyncRequest is a request to synchronize local branches with their pull
+// requests on GitHub.
+//
+// Local branches whose pull requests were merged or closed are deleted, the
+// base branch is updated, and pull requests that depended on merged pull
+// requests are rebased onto the base branch.
+type SyncRequest struct {
+	// Branch into which pull requests are merged.
+	Base string
+
+	// If non-empty, only pull requests by the given user will be rebased.
+	Author string
+}
+
+// SyncResponse is the response of the Sync operation.
+type SyncResponse struct {
+	// Local branches that were deleted because their pull requests were
+	// merged or closed.
+	DeletedBranches []string
+
+	// Local branches that were not deleted even though their pull requests
+	// were merged or closed because they had changes that weren't part of
+	// the pull request.
+	BranchesNotDeleted []string
+
+	// Pull requests that were rebased because the pull requests they
+	// depended on were merged.
+	RebasedPullRequests []RebasedPullRequest
+
 	// Local branches that were not updated because their heads did not match
 	// the remotes.
 	BranchesNotUpdated []string
@@ -53,4 +172,10 @@ type PR interface {
 
 	// Rebases a pull request.
 	Rebase(context.Context, *RebaseRequest) (*RebaseResponse, error)
+
+	// Rebases a stack of local branches.
+	RebaseLocal(context.Context, *RebaseLocalRequest) (*RebaseLocalResponse, error)
+
+	// Synchronizes local branches with GitHub.
+	Sync(context.Context, *SyncRequest) (*SyncResponse, error)
 }
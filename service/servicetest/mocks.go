@@ -51,3 +51,25 @@ func (_m *MockPR) Rebase(_param0 context.Context, _param1 *service.RebaseRequest
 func (_mr *_MockPRRecorder) Rebase(arg0, arg1 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCall(_mr.mock, "Rebase", arg0, arg1)
 }
+
+func (_m *MockPR) RebaseLocal(_param0 context.Context, _param1 *service.RebaseLocalRequest) (*service.RebaseLocalResponse, error) {
+	ret := _m.ctrl.Call(_m, "RebaseLocal", _param0, _param1)
+	ret0, _ := ret[0].(*service.RebaseLocalResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockPRRecorder) RebaseLocal(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "RebaseLocal", arg0, arg1)
+}
+
+func (_m *MockPR) Sync(_param0 context.Context, _param1 *service.SyncRequest) (*service.SyncResponse, error) {
+	ret := _m.ctrl.Call(_m, "Sync", _param0, _param1)
+	ret0, _ := ret[0].(*service.SyncResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (_mr *_MockPRRecorder) Sync(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCall(_mr.mock, "Sync", arg0, arg1)
+}
@@ -0,0 +1,151 @@
+package settings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/abhinav/git-pr/gateway"
+	"github.com/abhinav/git-pr/logging"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RepoFile is the name of the configuration file at the root of a
+// repository.
+const RepoFile = ".git-pr.yaml"
+
+// GitConfigSource is the source of values read from git-config.
+const GitConfigSource = "git-config"
+
+// LoadConfig configures Load.
+type LoadConfig struct {
+	// Git gateway for the repository. Settings are read from its
+	// git-config and the RepoFile at its root.
+	Git gateway.Git
+
+	// Path to the configuration file for the user. Defaults to UserFile().
+	UserFile string
+
+	// Logger for warnings about unknown settings in configuration files.
+	// May be nil.
+	Log *logging.Logger
+}
+
+// UserFile returns the path to the configuration file for the current user.
+func UserFile() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(dir, "git-pr", "config.yaml")
+}
+
+// Load loads settings from all sources. Missing configuration files are
+// ignored. Unknown settings are ignored too so that configuration files may be
+// shared with other versions of git-pr.
+func Load(cfg LoadConfig) (*Settings, error) {
+	if cfg.UserFile == "" {
+		cfg.UserFile = UserFile()
+	}
+
+	s := New()
+	files := []string{cfg.UserFile, filepath.Join(cfg.Git.RootDir(), RepoFile)}
+	for _, path := range files {
+		if err := s.loadFile(path, cfg.Log); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.loadGitConfig(cfg.Git); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Settings) loadFile(path string, log *logging.Logger) error {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %v: %v", path, err)
+	}
+
+	var data map[string]interface{}
+	if err := yaml.Unmarshal(contents, &data); err != nil {
+		return fmt.Errorf("failed to parse %v: %v", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten("", data, values); err != nil {
+		return fmt.Errorf("failed to parse %v: %v", path, err)
+	}
+
+	// Sorted so that the first error is always the same one.
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if lookup(name) == nil {
+			log.Info("ignoring unknown setting",
+				logging.String("name", name), logging.String("file", path))
+			continue
+		}
+		if err := s.Set(name, values[name], path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flatten flattens nested YAML mappings into a map from dotted names to
+// values.
+func flatten(prefix string, data map[string]interface{}, values map[string]string) error {
+	for key, value := range data {
+		name := prefix + key
+		switch v := value.(type) {
+		case map[interface{}]interface{}:
+			nested := make(map[string]interface{}, len(v))
+			for k, vv := range v {
+				nested[fmt.Sprint(k)] = vv
+			}
+			if err := flatten(name+".", nested, values); err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("%q must not be a list", name)
+		case nil:
+			// Empty values are left at their defaults.
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+func (s *Settings) loadGitConfig(g gateway.Git) error {
+	items, err := g.ListConfig(`^git-pr\.`)
+	if err != nil {
+		return err
+	}
+
+	// git-config keys are case-insensitive so they're reported in lower
+	// case.
+	for _, setting := range _settings {
+		value, ok := items[strings.ToLower(setting.GitConfigKey())]
+		if !ok {
+			continue
+		}
+		if err := s.Set(setting.Name, value, GitConfigSource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
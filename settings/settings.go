@@ -0,0 +1,269 @@
+// Package settings loads git-pr settings from configuration files and
+// git-config.
+//
+// Settings are read from the following places. Later places take precedence
+// over earlier ones.
+//
+// 	defaults built into git-pr
+// 	~/.config/git-pr/config.yaml (or $XDG_CONFIG_HOME/git-pr/config.yaml)
+// 	.git-pr.yaml at the root of the repository
+// 	git-config keys prefixed with "git-pr."
+//
+// Configuration files use the same names as git-config without the prefix.
+//
+// 	remote: upstream
+// 	base: main
+// 	trailers:
+// 	  reviewedBy: true
+package settings
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of value a setting holds.
+type Kind int
+
+// Kinds of settings.
+const (
+	String Kind = iota
+	Bool
+	Int
+)
+
+// Setting describes a git-pr setting.
+type Setting struct {
+	// Name of the setting in configuration files, e.g. "trailers.reviewedBy".
+	// The git-config key is the name prefixed with "git-pr.".
+	Name string
+
+	Kind    Kind
+	Default string
+
+	// If non-empty, the value must be one of these.
+	Choices []string
+
+	Description string
+}
+
+// GitConfigKey returns the git-config key for this setting.
+func (s *Setting) GitConfigKey() string {
+	return "git-pr." + s.Name
+}
+
+// All known settings.
+var _settings = []*Setting{
+	{
+		Name:        "remote",
+		Default:     "origin",
+		Description: "Name of the git remote for the GitHub repository.",
+	},
+	{
+		Name:        "base",
+		Default:     "master",
+		Description: "Name of the branch into which pull requests are merged.",
+	},
+	{
+		Name:        "concurrency",
+		Kind:        Int,
+		Default:     "0",
+		Description: "Maximum number of concurrent requests to GitHub. 0 picks one based on the number of CPUs.",
+	},
+	{
+		Name:        "requestTimeout",
+		Kind:        Int,
+		Default:     "60",
+		Description: "Seconds after which requests to GitHub are abandoned. 0 disables this.",
+	},
+	{
+		Name:        "gitBackend",
+		Default:     "cli",
+		Choices:     []string{"cli", "in-process"},
+		Description: "How the repository is read. in-process looks up refs and remotes without running git, which is faster for large stacks.",
+	},
+	{
+		Name:        "mergeMethod",
+		Default:     "squash",
+		Choices:     []string{"squash", "merge", "rebase"},
+		Description: "How pull requests are merged by land.",
+	},
+	{
+		Name:        "messageTemplate",
+		Description: "Path to the template for commit messages on land, relative to the root of the repository.",
+	},
+	{
+		Name:        "trailers.reviewedBy",
+		Kind:        Bool,
+		Default:     "false",
+		Description: "Add Reviewed-by trailers for approvers on land.",
+	},
+	{
+		Name:        "trailers.coAuthoredBy",
+		Kind:        Bool,
+		Default:     "false",
+		Description: "Add Co-authored-by trailers for other commit authors on land.",
+	},
+	{
+		Name:        "trailers.pullRequest",
+		Kind:        Bool,
+		Default:     "false",
+		Description: "Add a Pull-Request trailer with the URL of the pull request on land.",
+	},
+	{
+		Name:        "lint.maxTitleLength",
+		Kind:        Int,
+		Default:     "0",
+		Description: "Maximum length of commit titles on land. 0 disables this.",
+	},
+	{
+		Name:        "lint.conventionalCommits",
+		Kind:        Bool,
+		Default:     "false",
+		Description: "Require commit titles in the form \"type(scope): description\" on land.",
+	},
+	{
+		Name:        "lint.bodyWidth",
+		Kind:        Int,
+		Default:     "0",
+		Description: "Maximum width of commit message bodies on land. 0 disables this.",
+	},
+	{
+		Name:        "lint.requireIssue",
+		Kind:        Bool,
+		Default:     "false",
+		Description: "Require commit messages to reference an issue on land.",
+	},
+}
+
+// Known returns descriptions of all known settings, sorted by name.
+func Known() []*Setting {
+	settings := append([]*Setting(nil), _settings...)
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Name < settings[j].Name })
+	return settings
+}
+
+func lookup(name string) *Setting {
+	for _, s := range _settings {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Source of the default values of settings.
+const DefaultSource = "default"
+
+// Value is the effective value of a setting.
+type Value struct {
+	Setting *Setting
+	Value   string
+
+	// Where the value came from. This is DefaultSource, the path to a
+	// configuration file, or "git-config".
+	Source string
+}
+
+// Settings holds the effective values of all settings.
+type Settings struct {
+	values map[string]Value
+}
+
+// New builds Settings with default values for everything.
+func New() *Settings {
+	values := make(map[string]Value, len(_settings))
+	for _, s := range _settings {
+		values[s.Name] = Value{Setting: s, Value: s.Default, Source: DefaultSource}
+	}
+	return &Settings{values: values}
+}
+
+// Set changes the value of a setting. The value is validated against the
+// kind of the setting.
+func (s *Settings) Set(name, value, source string) error {
+	setting := lookup(name)
+	if setting == nil {
+		return fmt.Errorf("unknown setting %q in %v", name, source)
+	}
+
+	if err := validate(setting, value); err != nil {
+		return fmt.Errorf("invalid value for %q in %v: %v", name, source, err)
+	}
+
+	s.values[name] = Value{Setting: setting, Value: value, Source: source}
+	return nil
+}
+
+// Get returns the value of the given setting. It panics if the setting
+// doesn't exist.
+func (s *Settings) Get(name string) Value {
+	v, ok := s.values[name]
+	if !ok {
+		panic(fmt.Sprintf("unknown setting %q", name))
+	}
+	return v
+}
+
+// String returns the value of the given setting.
+func (s *Settings) String(name string) string {
+	return s.Get(name).Value
+}
+
+// Bool returns the value of the given boolean setting.
+func (s *Settings) Bool(name string) bool {
+	b, _ := parseBool(s.Get(name).Value)
+	return b
+}
+
+// Int returns the value of the given integer setting.
+func (s *Settings) Int(name string) int {
+	n, _ := strconv.Atoi(s.Get(name).Value)
+	return n
+}
+
+// All returns the values of all settings, sorted by name.
+func (s *Settings) All() []Value {
+	values := make([]Value, 0, len(s.values))
+	for _, setting := range Known() {
+		values = append(values, s.values[setting.Name])
+	}
+	return values
+}
+
+func validate(s *Setting, value string) error {
+	switch s.Kind {
+	case Bool:
+		if _, err := parseBool(value); err != nil {
+			return err
+		}
+	case Int:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+	}
+
+	if len(s.Choices) == 0 {
+		return nil
+	}
+	for _, c := range s.Choices {
+		if value == c {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not one of %v", value, strings.Join(s.Choices, ", "))
+}
+
+// parseBool parses booleans the same way git does.
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%q is not a boolean", value)
+	}
+}
@@ -0,0 +1,175 @@
+package settings
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abhinav/git-pr/gateway/gatewaytest"
+	"github.com/abhinav/git-pr/logging"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaults(t *testing.T) {
+	s := New()
+	assert.Equal(t, "origin", s.String("remote"))
+	assert.Equal(t, "master", s.String("base"))
+	assert.Equal(t, 0, s.Int("concurrency"))
+	assert.False(t, s.Bool("trailers.reviewedBy"))
+	assert.Equal(t, DefaultSource, s.Get("mergeMethod").Source)
+	assert.Len(t, s.All(), len(Known()))
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Value string
+
+		WantError string
+	}{
+		{Name: "remote", Value: "upstream"},
+		{Name: "concurrency", Value: "8"},
+		{Name: "trailers.reviewedBy", Value: "yes"},
+		{Name: "mergeMethod", Value: "rebase"},
+		{
+			Name:      "remotes",
+			Value:     "upstream",
+			WantError: `unknown setting "remotes" in test`,
+		},
+		{
+			Name:      "concurrency",
+			Value:     "lots",
+			WantError: `invalid value for "concurrency" in test: "lots" is not a number`,
+		},
+		{
+			Name:      "lint.requireIssue",
+			Value:     "sometimes",
+			WantError: `invalid value for "lint.requireIssue" in test: "sometimes" is not a boolean`,
+		},
+		{
+			Name:      "mergeMethod",
+			Value:     "octopus",
+			WantError: `"octopus" is not one of squash, merge, rebase`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name+"="+tt.Value, func(t *testing.T) {
+			s := New()
+			err := s.Set(tt.Name, tt.Value, "test")
+			if tt.WantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.WantError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, Value{Setting: lookup(tt.Name), Value: tt.Value, Source: "test"}, s.Get(tt.Name))
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-pr")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "repo")
+	require.NoError(t, os.Mkdir(root, 0755))
+
+	userFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, ioutil.WriteFile(userFile, []byte(
+		"remote: upstream\n"+
+			"base: develop\n"+
+			"concurrency: 2\n"), 0644))
+
+	repoFile := filepath.Join(root, ".git-pr.yaml")
+	require.NoError(t, ioutil.WriteFile(repoFile, []byte(
+		"base: main\n"+
+			"trailers:\n"+
+			"  reviewedBy: yes\n"+
+			"  signedOffBy: true\n"+
+			"lint:\n"+
+			"  maxTitleLength: 72\n"+
+			"  bodyWidth: 80\n"), 0644))
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	git := gatewaytest.NewMockGit(mockCtrl)
+	git.EXPECT().RootDir().Return(root).AnyTimes()
+	git.EXPECT().ListConfig(`^git-pr\.`).Return(map[string]string{
+		"git-pr.lint.bodywidth": "100",
+		"git-pr.unknown":        "ignored",
+	}, nil)
+
+	var logs bytes.Buffer
+	s, err := Load(LoadConfig{
+		Git:      git,
+		UserFile: userFile,
+		Log:      logging.New(&logs, logging.Info),
+	})
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "ignoring unknown setting name=trailers.signedOffBy",
+		"unknown settings must be reported")
+
+	assert.Equal(t, Value{Setting: lookup("remote"), Value: "upstream", Source: userFile}, s.Get("remote"))
+	assert.Equal(t, Value{Setting: lookup("base"), Value: "main", Source: repoFile}, s.Get("base"))
+	assert.Equal(t, 2, s.Int("concurrency"))
+	assert.True(t, s.Bool("trailers.reviewedBy"))
+	assert.Equal(t, 72, s.Int("lint.maxTitleLength"))
+	assert.Equal(t, Value{Setting: lookup("lint.bodyWidth"), Value: "100", Source: GitConfigSource}, s.Get("lint.bodyWidth"))
+	assert.Equal(t, DefaultSource, s.Get("mergeMethod").Source)
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		Desc      string
+		File      string
+		WantError string
+	}{
+		{
+			Desc:      "not YAML",
+			File:      "remote: [",
+			WantError: "failed to parse",
+		},
+		{
+			Desc:      "list",
+			File:      "remote: [origin, upstream]\n",
+			WantError: `"remote" must not be a list`,
+		},
+		{
+			Desc:      "bad value",
+			File:      "mergeMethod: fast-forward\n",
+			WantError: `"fast-forward" is not one of squash, merge, rebase`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Desc, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "git-pr")
+			require.NoError(t, err)
+			defer os.RemoveAll(root)
+
+			repoFile := filepath.Join(root, ".git-pr.yaml")
+			require.NoError(t, ioutil.WriteFile(repoFile, []byte(tt.File), 0644))
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			git := gatewaytest.NewMockGit(mockCtrl)
+			git.EXPECT().RootDir().Return(root).AnyTimes()
+			git.EXPECT().ListConfig(gomock.Any()).Return(nil, nil).AnyTimes()
+
+			_, err = Load(LoadConfig{Git: git, UserFile: filepath.Join(root, "missing.yaml")})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.WantError)
+			assert.Contains(t, err.Error(), repoFile)
+		})
+	}
+}